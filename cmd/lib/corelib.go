@@ -7,6 +7,7 @@ import "C"
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"unsafe"
 
@@ -91,6 +92,8 @@ func valueToString(val runtime.Value) string {
 		return "false"
 	case runtime.VAL_NUMBER:
 		return fmt.Sprintf("%g", val.Number)
+	case runtime.VAL_INT:
+		return strconv.FormatInt(val.Int, 10)
 	case runtime.VAL_OBJ:
 		switch obj := val.Obj.(type) {
 		case *runtime.ObjString:
@@ -102,11 +105,17 @@ func valueToString(val runtime.Value) string {
 			}
 			return "[" + strings.Join(elements, ", ") + "]"
 		case *runtime.ObjMap:
-			entries := make([]string, 0, len(obj.Entries))
-			for key, value := range obj.Entries {
-				entries = append(entries, fmt.Sprintf("%s: %s", key.Chars, valueToString(value)))
+			entries := make([]string, 0, obj.Len())
+			for key, value := range obj.All() {
+				entries = append(entries, fmt.Sprintf("%s: %s", valueToString(key), valueToString(value)))
 			}
 			return "{" + strings.Join(entries, ", ") + "}"
+		case *runtime.ObjSet:
+			elements := make([]string, 0, obj.Len())
+			for value := range obj.All() {
+				elements = append(elements, valueToString(value))
+			}
+			return "#{" + strings.Join(elements, ", ") + "}"
 		case *runtime.ObjStruct:
 			return fmt.Sprintf("<struct %s>", obj.Name.Chars)
 		case *runtime.ObjInstance:
@@ -144,8 +153,22 @@ func valueToString(val runtime.Value) string {
 			return fmt.Sprintf("<Time %s>", obj.Time.Format("15:04:05"))
 		case *runtime.ObjDateTime:
 			return fmt.Sprintf("<DateTime %s>", obj.Time.Format("2006-01-02 15:04:05"))
+		case *runtime.ObjBigInt:
+			return obj.Value.String()
+		case *runtime.ObjDecimal:
+			return obj.String()
 		case *runtime.ObjArrayIterator:
 			return fmt.Sprintf("<array iterator at %d>", obj.Index)
+		case *runtime.ObjMapIterator:
+			return fmt.Sprintf("<map iterator at %d>", obj.Index)
+		case *runtime.ObjStringIterator:
+			return fmt.Sprintf("<string iterator at %d>", obj.Index)
+		case *runtime.ObjRange:
+			return obj.String()
+		case *runtime.ObjRangeIterator:
+			return fmt.Sprintf("<range iterator at %d>", obj.Index)
+		case *runtime.ObjGenerator:
+			return fmt.Sprintf("<generator %s>", obj.Function.Name.Chars)
 		default:
 			return "<unknown object>"
 		}
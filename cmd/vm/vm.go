@@ -21,12 +21,18 @@ import (
 	"unsafe"
 
 	"github.com/cryptrunner49/tulipscript/internal/common"
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
 	"github.com/cryptrunner49/tulipscript/internal/vm"
 )
 
 func main() {
 	C.bind_tab_key()
 
+	if len(os.Args) > 1 && os.Args[1] == "--no-global-aliases" {
+		vm.GlobalAliases = false
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
 		case "-h", "--help":
@@ -58,6 +64,9 @@ Usage: tulip [options] [script]
 Options:
   -h, --help       Display this help message and exit
   -v, --version    Show version information and exit
+  --no-global-aliases
+                   Only expose standard library functions through the std modules
+                   (e.g. 'import std.str as s'), not their flat global names
 
 Modes:
   - If no script is provided, tulip starts an interactive REPL (Read-Eval-Print Loop)
@@ -178,12 +187,19 @@ func runFile(path string) {
 		// Successful execution
 	case vm.INTERPRET_COMPILE_ERROR:
 		fmt.Fprintf(os.Stderr, "Compilation error in '%s'\n", path)
-		os.Exit(65)
+		exit(65)
 	case vm.INTERPRET_RUNTIME_ERROR:
 		fmt.Fprintf(os.Stderr, "Runtime error in '%s'\n", path)
-		os.Exit(70)
+		exit(70)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown error: %d\n", result)
-		os.Exit(1)
+		exit(1)
 	}
 }
+
+// exit ends the process with code once the files the script left open are flushed and closed,
+// since os.Exit skips the deferred vm.FreeVM that would otherwise close them.
+func exit(code int) {
+	runtime.CloseFiles()
+	os.Exit(code)
+}
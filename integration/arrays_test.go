@@ -201,3 +201,108 @@ func TestArraySlices(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestArraySpread(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let a = [1, 2]
+		let b = [5]
+		println([...a, 3, 4, ...b, ...[], 6])
+		let copy = [...a]
+		push(copy, 9)
+		println(a, copy)
+	`
+	expectedOutput := "[1, 2, 3, 4, 5, 6]\n[1, 2] [1, 2, 9]\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestArraySpreadRejectsNonArray(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"spread number", "let n = 5\nprintln([...n])", "Cannot spread number into an array; expected an array, set or bytes."},
+	})
+}
+
+func TestArraySliceByRange(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let arr = [10, 20, 30, 40, 50]
+		println(arr[1..3], arr[0..=4 step 2], arr[4..0 step -1], arr[2..2])
+	`
+	expectedOutput := "[20, 30] [10, 30, 50] [50, 40, 30, 20] []\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestArraySliceByRangeOutOfBounds(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"past the end", "let arr = [1, 2, 3]\nprintln(arr[1..9])", "Slice range 1..9 is out of bounds for an array of length 3."},
+	})
+}
+
+func TestArrayHigherOrderNatives(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let nums = [3, 1, 4, 1, 5]
+		function double(n) { return n * 2 }
+		function label(n, i) { return to_str(i) + ":" + to_str(n) }
+		function isOdd(n) { return n % 2 == 1 }
+		function add(a, b) { return a + b }
+		function descending(a, b) { return b - a }
+		println(array_map(nums, double), array_map([7, 8], label))
+		println(array_filter(nums, isOdd), nums)
+		println(array_reduce(nums, add), array_reduce([], add, 10))
+		struct Person { name = ""; age = 0 }
+		let people = [Person{name = "Ann", age = 30}, Person{name = "Bob", age = 25}, Person{name = "Cy", age = 30}]
+		function byAge(a, b) { return a.age - b.age }
+		iter (let p in array_sort_by(people, byAge)) { print(p.name, "") }
+		println()
+		array_sort_by(nums, descending)
+		println(nums, array_sort_by([10, 9, 100], descending))
+	`
+	expectedOutput := "[6, 2, 8, 2, 10] [0:7, 1:8]\n[3, 1, 1, 5] [3, 1, 4, 1, 5]\n14 10\nBob Ann Cy \n[5, 4, 3, 1, 1] [100, 10, 9]\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestArrayHigherOrderNativeErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"callback fails", "function f(x) { return len(5) }\nprintln(array_map([1, 2], f))", "'len' can only be used on arrays and bytes."},
+		{"not a function", `println(array_filter([1, 2], 5))`, "'array_filter' expects a function as the second argument (got number)."},
+		{"comparator not a num", "function f(a, b) { return \"x\" }\nprintln(array_sort_by([1, 2], f))", "'array_sort_by' comparator must return a number (got string)."},
+		{"empty reduce", "function f(a, b) { return a + b }\nprintln(array_reduce([], f))", "'array_reduce' of an empty array needs an initial value."},
+	})
+}
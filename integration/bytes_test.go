@@ -0,0 +1,149 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestBytes(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let b = bytes([104, 105, 255])
+		println(b, get_runtype(b), len(b), b.length, b[2], bytes(), bytes(2))
+		b[2] = 33
+		println(bytes_to_string(b), bytes("é"), equals(bytes("hi!"), b), bytes("hi!") == b)
+		let copy = bytes(b)
+		copy[0] = 72
+		println(bytes_to_string(copy), bytes_to_string(b))
+		println(b[1:], b[:-1], b[0..2], b[0..=2 step 2])
+		let part = b[0:1]
+		part[0] = 0
+		println(b[0])
+		let total = 0
+		iter (let x in b) {
+			total = total + x
+		}
+		println(total, [...b])
+		println(bytes_to_hex(b), bytes_from_hex("00ff"), bytes_to_base64(b), bytes_to_string(bytes_from_base64("aGkh")))
+		println(bytes_concat(b, bytes([0]), std.bytes.new("x")), bytes_concat())
+	`
+	expectedOutput := "<bytes 68 69 ff> bytes 3 3 255 <bytes> <bytes 00 00>\n" +
+		"hi! <bytes c3 a9> true false\n" +
+		"Hi! hi!\n" +
+		"<bytes 69 21> <bytes 68 69> <bytes 68 69> <bytes 68 21>\n" +
+		"104\n" +
+		"242 [104, 105, 33]\n" +
+		"686921 <bytes 00 ff> aGkh hi!\n" +
+		"<bytes 68 69 21 00 78> <bytes>\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestBytesPackAndFiles(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	dir := t.TempDir()
+	script := fmt.Sprintf(`
+		let header = bytes_pack(">HbI", 513, -1, 7)
+		println(header, bytes_pack("<H", 513), bytes_pack("B B", 1, 255))
+		println(bytes_unpack(">HbI", header), bytes_unpack(">I", header, 3), bytes_unpack("<i", bytes_pack("<i", -5)))
+		println(bytes_unpack("<Q", bytes_pack("<Q", 18446744073709551615n)), bytes_unpack("<q", bytes_pack("<q", -2)))
+		println(bytes_unpack("<fd", bytes_pack("<fd", 1.5, 0.1)), bytes_pack(">f", 1))
+		let path = path_join("%s", "data.bin")
+		println(write_file_bytes(path, header), read_file_bytes(path), read_file_bytes(path_join("%s", "none")).kind)
+	`, filepath.ToSlash(dir), filepath.ToSlash(dir))
+	expectedOutput := "<bytes 02 01 ff 00 00 00 07> <bytes 01 02> <bytes 01 ff>\n" +
+		"[513, -1, 7] [7] [-5]\n" +
+		"[18446744073709551615] [-2]\n" +
+		"[1.5, 0.1] <bytes 3f 80 00 00>\n" +
+		"null <bytes 02 01 ff 00 00 00 07> not_found\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "data.bin")); err != nil || string(data) != "\x02\x01\xff\x00\x00\x00\x07" {
+		t.Errorf("Expected write_file_bytes to write the packed header, got %q (%v)", data, err)
+	}
+}
+
+func TestBytesAsPointerArguments(t *testing.T) {
+	if goruntime.GOOS != "linux" {
+		t.Skip("needs the GNU C library")
+	}
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		use "libc.so.6" {
+			void memset(void*, int32_t, size_t)
+			size_t strlen(char*)
+		}
+		let buffer = bytes(4)
+		memset(buffer, 65, 3)
+		println(buffer, strlen(buffer), strlen("tulip"))
+	`
+	expectedOutput := "<bytes 41 41 41 00> 3 5\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+
+	// A bad argument after a Bytes one is rejected before the call, once the C copy was made.
+	expectRuntimeErrors(t, []errorCase{
+		{"bad argument after bytes", "use \"libc.so.6\" {\n\tvoid memset(void*, int32_t, size_t)\n}\nmemset(bytes(4), \"A\", 3)", "Argument 2 of 'memset' must be a number."},
+	})
+}
+
+func TestBytesErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"index out of bounds", "bytes(2)[2]", "Bytes index out of bounds."},
+		{"index not integer", "bytes(2)[0.5]", "Bytes index must be an integer."},
+		{"byte too large", "let b = bytes(1)\nb[0] = 256", "A byte must be an integer from 0 to 255 (got 256)."},
+		{"byte not number", `let b = bytes(1)` + "\n" + `b[0] = "a"`, "A byte must be an integer from 0 to 255 (got a)."},
+		{"array element", "bytes([1, -1])", "'bytes' array elements must be integers from 0 to 255 (element 1 is -1)."},
+		{"negative size", "bytes(-1)", "'bytes' size must be from 0 to 1073741824 (got -1)."},
+		{"huge size", "bytes(9000000000000000000)", "'bytes' size must be from 0 to 1073741824 (got 9000000000000000000)."},
+		{"invalid utf8", "bytes_to_string(bytes([255]))", "'bytes_to_string' expects UTF-8 encoded bytes."},
+		{"bad hex", `bytes_from_hex("xyz")`, "'bytes_from_hex' failed: encoding/hex: invalid byte: U+0078 'x'"},
+		{"bad base64", `bytes_from_base64("!!")`, "'bytes_from_base64' failed: illegal base64 data at input byte 0"},
+		{"pack code", `bytes_pack("z", 1)`, "'bytes_pack' format has an unknown code 'z'; expected one of b, B, h, H, i, I, q, Q, f and d."},
+		{"pack count", `bytes_pack("bb", 1)`, "'bytes_pack' format 'bb' has 2 fields but 1 values were given."},
+		{"pack overflow", `bytes_pack("b", 128)`, "'bytes_pack' field 1 ('b') expects an integer that fits in 8 bits (got 128)."},
+		{"pack unsigned", `bytes_pack("H", -1)`, "'bytes_pack' field 1 ('H') expects an integer that fits in 16 bits (got -1)."},
+		{"unpack short", `bytes_unpack("I", bytes(3))`, "'bytes_unpack' format 'I' needs 4 bytes at offset 0, but there are only 3 bytes."},
+		{"range out of bounds", "bytes(2)[0..5]", "Slice range 0..5 is out of bounds for bytes of length 2."},
+		{"unknown property", "bytes(2).size", "Cannot access property 'size' on bytes; only 'length' is supported."},
+	})
+}
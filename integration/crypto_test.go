@@ -0,0 +1,101 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestHashing(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(md5("abc"), sha1("abc"))
+		println(sha256("abc"))
+		println(sha512(bytes("abc")))
+		println(crc32("abc"), crc32("abc", "bytes"), len(sha256("", "bytes")))
+		println(hmac("sha256", "key", "The quick brown fox jumps over the lazy dog"))
+		println(std.crypto.hmac("md5", bytes("key"), "abc"), equals(sha1("abc", "bytes"), hex_decode(sha1("abc"))))
+	`
+	expectedOutput := "900150983cd24fb0d6963f7d28e17f72 a9993e364706816aba3e25717850c26c9cd0d89d\n" +
+		"ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad\n" +
+		"ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f\n" +
+		"352441c2 <bytes 35 24 41 c2> 32\n" +
+		"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8\n" +
+		"d2fe98063f876b03193afb49b4979591 true\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestEncodingAndSecureRandom(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(base64_encode("hi"), base64_decode("aGk="), base32_encode("hi!"), bytes_to_string(base32_decode("NBUSC===")))
+		println(base64url_encode(bytes([251, 255])), base64url_decode("-_8"), base64url_decode("-_8="), hex_encode("hi"), hex_decode("6869"))
+		println(std.encoding.hex_encode == std.bytes.to_hex, base64_decode == bytes_from_base64, bytes_to_hex("hi"))
+		println(url_encode("a b&c=d/é"), url_decode("a+b%26c"), std.encoding.url_decode(url_encode("x y")))
+		let key = random_bytes(16)
+		println(get_runtype(key), len(key), len(random_bytes(0)), equals(key, random_bytes(16)))
+		let uuid = /^[0-9a-f]{8}-[0-9a-f]{4}-(?P<version>[47])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/
+		println(uuid.named(uuid_v4())["version"], uuid.named(std.crypto.uuid_v7())["version"], uuid_v4() == uuid_v4())
+		let ids = []
+		for (let i = 0; i < 5000; i++) { push(ids, uuid_v7()) }
+		println(equals(ids, array_sort(ids + [])))
+		let deck = [1, 2, 3, 4, 5]
+		shuffle(deck)
+		let total = 0
+		iter (let card in deck) {
+			total = total + card
+		}
+		println(len(deck), total, str_length(random_string(12)))
+	`
+	expectedOutput := "aGk= <bytes 68 69> NBUSC=== hi!\n" +
+		"-_8 <bytes fb ff> <bytes fb ff> 6869 <bytes 68 69>\n" +
+		"true true 6869\n" +
+		"a+b%26c%3Dd%2F%C3%A9 a b&c x y\n" +
+		"bytes 16 0 false\n" +
+		"4 7 false\n" +
+		"true\n" +
+		"5 15 12\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestCryptoErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"hash number", "sha256(1)", "'sha256' expects a string or bytes as argument 1 (got number)."},
+		{"hash format", `md5("a", "base64")`, `'md5' format must be "hex" or "bytes" (got 'base64').`},
+		{"hmac algorithm", `hmac("sha3", "k", "a")`, "'hmac' algorithm must be one of md5, sha1, sha256 and sha512 (got 'sha3')."},
+		{"hmac key", `hmac("sha256", 1, "a")`, "'hmac' expects a string or bytes as argument 2 (got number)."},
+		{"bad base64", `base64_decode("!!")`, "'bytes_from_base64' failed: illegal base64 data at input byte 0"},
+		{"bad base32", `base32_decode("1")`, "'base32_decode' failed: illegal base32 data at input byte 0"},
+		{"bad hex", `hex_decode("xyz")`, "'bytes_from_hex' failed: encoding/hex: invalid byte: U+0078 'x'"},
+		{"bad url escape", `url_decode("%zz")`, `'url_decode' failed: invalid URL escape "%zz"`},
+		{"negative size", "random_bytes(-1)", "'random_bytes' expects an integer size from 0 to 1073741824."},
+		{"huge size", "random_bytes(9000000000000000000)", "'random_bytes' expects an integer size from 0 to 1073741824."},
+		{"uuid argument", "uuid_v4(1)", "'uuid_v4' expects 0 arguments."},
+		{"encode wrong type", "base64_encode([1])", "'bytes_to_base64' expects a string or bytes as argument 1 (got array)."},
+	})
+}
@@ -0,0 +1,178 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestCSVParse(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let text = "name,age
+ana,31
+bo,27
+"
+		println(csv_parse(text))
+		println(csv_parse(text, {header: true}))
+		println(csv_parse("1;2
+3;4", {delimiter: ";", header: ["x", "y"]}))
+		println(csv_parse(""), csv_parse("", {header: true}))
+	`
+	expectedOutput := "[[name, age], [ana, 31], [bo, 27]]\n" +
+		"[{name: ana, age: 31}, {name: bo, age: 27}]\n" +
+		"[{x: 1, y: 2}, {x: 3, y: 4}]\n" +
+		"[] []\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestCSVFormatAndWrite(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	path := filepath.ToSlash(filepath.Join(t.TempDir(), "out.csv"))
+	script := fmt.Sprintf(`
+		let quote = to_str('"')
+		print(csv_format([["id", "note"], [1, "a, b"], [2, "say " + quote + "hi" + quote], [3, null]]))
+		print(csv_format([{city: "Oslo", temp: -3}, {temp: 21, city: "Rome"}]))
+		print(csv_format([{city: "Oslo", temp: -3}], {header: false, delimiter: "|"}))
+		print(csv_format([[1, true]], {header: ["n", "flag"]}))
+		print(csv_format([{a: 1, b: 2}], {header: ["b", "c"]}))
+		csv_write("%s", [{x: 1, y: "two words"}])
+		print(read_file("%s"))
+	`, path, path)
+	expectedOutput := "id,note\n1,\"a, b\"\n2,\"say \"\"hi\"\"\"\n3,\n" +
+		"city,temp\nOslo,-3\nRome,21\n" +
+		"Oslo|-3\n" +
+		"n,flag\n1,true\n" +
+		"b,c\n2,\n" +
+		"x,y\n1,two words\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	path := filepath.Join(t.TempDir(), "people.csv")
+	if err := os.WriteFile(path, []byte("name,age\n\"Smith, Ana\",31\nBo,27\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf(`
+		iter (let person in csv_reader("%s", {header: true})) {
+			println(person["name"], "is", person["age"])
+		}
+		let reader = csv_reader("%s")
+		println(get_runtype(reader), iter_value(reader))
+		iter_next(reader)
+		iter_next(reader)
+		iter_next(reader)
+		println(iter_done(reader), iter_value(reader))
+	`, filepath.ToSlash(path), filepath.ToSlash(path))
+	expectedOutput := "Smith, Ana is 31\nBo is 27\n" +
+		"csv reader [name, age]\n" +
+		"true null\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+// TestCSVReaderClosesOnFree checks that a reader the script stops reading early does not keep
+// its file open once the VM is freed.
+func TestCSVReaderClosesOnFree(t *testing.T) {
+	if goruntime.GOOS != "linux" {
+		t.Skip("lists open files through /proc")
+	}
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	if err := os.WriteFile(path, []byte("a\nb\nc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vm.InitVM([]string{"tulipscript"})
+	script := fmt.Sprintf(`let reader = csv_reader("%s")
+println(iter_value(reader))`, filepath.ToSlash(path))
+	output := captureOutput(t, func() {
+		if result := core.Interpret(script, "<script>"); result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+	if output != "[a]\n" {
+		t.Errorf("Expected %q, got %q", "[a]\n", output)
+	}
+	if !fileOpen(t, path) {
+		t.Fatalf("Expected the unfinished reader to keep %s open", path)
+	}
+	vm.FreeVM()
+	if fileOpen(t, path) {
+		t.Errorf("Expected FreeVM to close the file of the unfinished reader")
+	}
+}
+
+// fileOpen reports whether this process has a descriptor open on path.
+func fileOpen(t *testing.T, path string) bool {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("cannot list open files: ", err)
+	}
+	for _, entry := range entries {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", entry.Name())); err == nil && target == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCSVErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.csv")
+	if err := os.WriteFile(bad, []byte("a,b\n1,2\n3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expectRuntimeErrors(t, []errorCase{
+		{"ragged text", "csv_parse(\"a,b\n1\")", "'csv_parse' failed: record on line 2: wrong number of fields"},
+		{"bare quote", `csv_parse("a,b" + to_str('"') + "c")`, `'csv_parse' failed: parse error on line 1, column 4: bare " in non-quoted-field`},
+		{"bad delimiter", `csv_parse("a", {delimiter: ",,"})`, "'csv_parse' option 'delimiter' must be a single character other than a quote or a newline."},
+		{"unknown option", `csv_parse("a", {sep: ";"})`, "'csv_parse' does not have an option sep."},
+		{"missing file", fmt.Sprintf(`csv_reader("%s")`, filepath.ToSlash(filepath.Join(dir, "missing.csv"))), "Error reading file: open "},
+		{"ragged file", fmt.Sprintf("iter (let row in csv_reader(\"%s\")) {\n}", filepath.ToSlash(bad)), "record on line 3: wrong number of fields"},
+		{"bad record", `csv_format([[1], 2])`, "'csv_format' records must be arrays or maps (record 1 is number)."},
+		{"unwritable target", fmt.Sprintf(`csv_write("%s", [[1]])`, filepath.ToSlash(filepath.Join(dir, "no", "such", "dir.csv"))), "Error writing file: open "},
+	})
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestFileHandles(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "log.txt"), []byte("first\r\nsecond\n3"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf(`
+		let dir = "%s"
+		let f = std.fs.open(path_join(dir, "log.txt"))
+		println(get_runtype(f), f.mode, f.closed)
+		println(f.read_line(), bytes_to_string(f.read_bytes(3)), f.read_line(), f.read_line(), f.read_line(), f.read_bytes(2))
+		println(f.seek(0), f.read_bytes(5), f.seek(-1, "end"), f.read_bytes(10))
+		println(f.seek(8), f.seek(-1, "current"), f.read_line())
+		println(f.seek(0), len(f.read_bytes(9000000000000000000)), f.read_bytes(9000000000000000000))
+		f.seek(0)
+		iter (let line in f) {
+			println("line:", line)
+		}
+		println(f.close(), f.closed, f.close())
+
+		let out = open_file(path_join(dir, "out.txt"), "w")
+		out.write_line("one")
+		out.write(2)
+		out.write(bytes([51]))
+		println(read_file(path_join(dir, "out.txt")) == "", out.flush(), read_file(path_join(dir, "out.txt")))
+		out.close()
+
+		let both = open_file(path_join(dir, "out.txt"), "a+")
+		both.write_line("")
+		both.write("three")
+		both.seek(0)
+		println(both.read_line(), both.seek(0, "current"))
+		both.close()
+
+		function countLines(file) {
+			let count = 0
+			iter (let line in file) {
+				count = count + 1
+			}
+			return count
+		}
+		println(with_file(path_join(dir, "out.txt"), countLines), with_file(path_join(dir, "out.txt"), "r", countLines))
+		println(open_file(path_join(dir, "missing.txt")).kind, with_file(path_join(dir, "missing.txt"), countLines).kind)
+	`, filepath.ToSlash(dir))
+	expectedOutput := "file r false\n" +
+		"first sec ond 3 null null\n" +
+		"0 <bytes 66 69 72 73 74> 14 <bytes 33>\n" +
+		"8 7 second\n" +
+		"0 15 null\n" +
+		"line: first\n" +
+		"line: second\n" +
+		"line: 3\n" +
+		"null true null\n" +
+		"true null one\n23\n" +
+		"one 4\n" +
+		"3 3\n" +
+		"not_found not_found\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestFileHandlesCloseOnFreeAndError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "buffered.txt")
+
+	// A file the script never closes is flushed and closed when the VM is freed.
+	vm.InitVM([]string{"tulipscript"})
+	script := fmt.Sprintf(`let f = open_file("%s", "w")
+f.write("kept")`, filepath.ToSlash(path))
+	if result := core.Interpret(script, "<script>"); result != 0 {
+		t.Fatalf("Interpretation failed: %d", result)
+	}
+	vm.FreeVM()
+	if data, err := os.ReadFile(path); err != nil || string(data) != "kept" {
+		t.Errorf("Expected the buffered write to be flushed on FreeVM, got %q (%v)", data, err)
+	}
+
+	// with_file closes the file even when the function fails.
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+	script = fmt.Sprintf(`let saved = null
+function fail(file) {
+	saved = file
+	file.write("partial")
+	return len(5)
+}
+with_file("%s", "w", fail)`, filepath.ToSlash(path))
+	captureStderr(t, func() {
+		if result := core.Interpret(script, "<script>"); result != 2 {
+			t.Errorf("Expected runtime error, got exit code %d", result)
+		}
+	})
+	if data, err := os.ReadFile(path); err != nil || string(data) != "partial" {
+		t.Errorf("Expected with_file to flush and close the file, got %q (%v)", data, err)
+	}
+	output := captureOutput(t, func() {
+		core.Interpret("println(saved.closed)", "<script>")
+	})
+	if output != "true\n" {
+		t.Errorf("Expected %q, got %q", "true\n", output)
+	}
+}
+
+// TestFileHandlesFlushOnErrorExit runs the tulip command, which exits with os.Exit after a
+// runtime error and so skips its deferred vm.FreeVM, and checks that open files are still flushed.
+func TestFileHandlesFlushOnErrorExit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the tulip command")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "tulip")
+	if out, err := exec.Command(goTool, "build", "-o", binary, "../cmd/vm").CombinedOutput(); err != nil {
+		t.Skipf("cannot build the tulip command: %v\n%s", err, out)
+	}
+
+	path := filepath.Join(dir, "out.txt")
+	scriptPath := filepath.Join(dir, "fail.tlp")
+	script := fmt.Sprintf(`let f = open_file("%s", "w")
+f.write_line("hello")
+len(5)`, filepath.ToSlash(path))
+	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(binary, scriptPath)
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 70 {
+		t.Fatalf("Expected exit code 70, got %v\n%s", err, out)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "hello\n" {
+		t.Errorf("Expected the buffered write to be flushed before exiting, got %q (%v)", data, err)
+	}
+}
+
+func TestFileHandleErrors(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	expectRuntimeErrors(t, []errorCase{
+		{"open arity", "open_file()", "'open_file' expects 1 or 2 arguments (file path, [mode])."},
+		{"open bad mode", fmt.Sprintf(`open_file("%s/x.txt", "rw")`, dir), "'open_file' mode must be one of r, w, a, r+, w+ and a+ (got 'rw')."},
+		{"with not function", fmt.Sprintf(`with_file("%s/x.txt", "w", 1)`, dir), "'with_file' expects a function as the last argument (got number)."},
+		{"read after close", fmt.Sprintf(`let f = open_file("%s/x.txt", "w")%sf.close()%sf.read_line()`, dir, "\n", "\n"), "after it has been closed."},
+		{"write read-only", fmt.Sprintf(`open_file("%s/x.txt", "w").close()%sopen_file("%s/x.txt").write("x")`, dir, "\n", dir), "opened with mode 'r': file is not open for writing."},
+		{"read write-only", fmt.Sprintf(`open_file("%s/x.txt", "a").read_line()`, dir), "opened with mode 'a': file is not open for reading."},
+		{"read_bytes negative", fmt.Sprintf(`open_file("%s/x.txt", "w+").read_bytes(-1)`, dir), "'read_bytes' expects a non-negative integer (got number)."},
+		{"seek whence", fmt.Sprintf(`open_file("%s/x.txt", "w").seek(0, "middle")`, dir), `'seek' whence must be "start", "current" or "end".`},
+		{"unknown property", fmt.Sprintf(`open_file("%s/x.txt", "w").size`, dir), "Property 'size' does not exist on file."},
+	})
+}
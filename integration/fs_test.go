@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestFileSystemNatives(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	dir := filepath.ToSlash(t.TempDir())
+	script := fmt.Sprintf(`
+		function base(path) { return path_basename(path) }
+		let dir = "%s"
+		let notes = path_join(dir, "notes.txt")
+		write_file(notes, "one")
+		println(append_file(notes, "+two"), append_file(path_join(dir, "new.txt"), "x"), read_file(notes))
+		println(file_exists(notes), file_exists(path_join(dir, "nope")), std.fs.exists(dir))
+		let info = file_stat(notes)
+		println(info["name"], info["size"], info["is_dir"], info["mtime"].year > 2000, file_stat(dir)["is_dir"])
+		println(make_dir(path_join(dir, "a", "b")), make_dir(path_join(dir, "a")), list_dir(dir))
+		println(copy_file(notes, path_join(dir, "copy.txt")), read_file(path_join(dir, "copy.txt")))
+		println(rename_path(path_join(dir, "copy.txt"), path_join(dir, "a", "moved.txt")), list_dir(path_join(dir, "a")))
+		println(array_map(glob(path_join(dir, "*.txt")), base))
+		println(remove_path(notes), remove_path(path_join(dir, "a"), true), list_dir(dir))
+	`, dir)
+	expectedOutput := "null null one+two\n" +
+		"true false true\n" +
+		"notes.txt 7 false true true\n" +
+		"null null [a, new.txt, notes.txt]\n" +
+		"null one+two\n" +
+		"null [b, moved.txt]\n" +
+		"[new.txt, notes.txt]\n" +
+		"null null [new.txt]\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestFileSystemErrorValues(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "full"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "full", "f.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := fmt.Sprintf(`
+		let dir = "%s"
+		let missing = file_stat(path_join(dir, "missing.txt"))
+		println(is_error(missing), get_runtype(missing), missing.kind, missing.path == path_join(dir, "missing.txt"))
+		println(list_dir(path_join(dir, "missing")).kind, remove_path(path_join(dir, "full")).kind)
+		println(copy_file(dir, path_join(dir, "copy")).kind, rename_path(path_join(dir, "nope"), dir).kind)
+		println(append_file(path_join(dir, "no", "such.txt"), "x").kind, is_error(null), is_error("not_found"))
+	`, filepath.ToSlash(dir))
+	expectedOutput := "true error not_found true\n" +
+		"not_found not_empty\n" +
+		"is_dir not_found\n" +
+		"not_found false false\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestPathAndTempNatives(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(path_join("a", "b", "../c.txt"), path_basename("/x/y.tar.gz"), path_dirname("/x/y.tar.gz"), path_ext("/x/y.tar.gz"))
+		println(std.fs.join("a", "b"), std.fs.basename("dir/"), std.fs.ext("README"), path_abs("/x/../y"))
+		let file = temp_file("report-*.csv")
+		let dir = temp_dir()
+		println(file_exists(file), ends_with(file, ".csv"), file_stat(file)["size"], file_stat(dir)["is_dir"])
+		println(remove_path(file), remove_path(dir), file_exists(file), file_exists(dir))
+	`
+	expectedOutput := "a/c.txt y.tar.gz /x .gz\n" +
+		"a/b dir  /y\n" +
+		"true true 0 true\n" +
+		"null null false false\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestFileSystemArgumentErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"stat number", "file_stat(1)", "'file_stat' expects a string (path) as argument 1 (got number)."},
+		{"append arity", `append_file("x")`, "'append_file' expects 2 arguments (file path, content)."},
+		{"bad recursive", `remove_path("x", 1)`, "'remove_path' expects a boolean (recursive) as argument 2 (got number)."},
+		{"bad glob", `glob("[")`, "'glob' pattern '[' is malformed."},
+		{"join without args", "path_join()", "'path_join' expects at least 1 argument (path elements)."},
+		{"join number", `path_join("a", 2)`, "'path_join' expects a string (path element) as argument 2 (got number)."},
+		{"temp pattern", "temp_dir(3)", "'temp_dir' expects a string (pattern) as argument 1 (got number)."},
+		{"error property", `file_stat("/no/such/path").code`, "Property 'code' does not exist on error."},
+	})
+}
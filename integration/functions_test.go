@@ -52,3 +52,53 @@ func TestFunctionWithString(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestDestructuredParameters(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function describe(label, [x, y], {name, unit = "cm"}) {
+			let total = x + y
+			return label + ": " + name + " " + to_str(total) + unit
+		}
+		println(describe("size", [3, 4], {"name": "box"}))
+	`
+	expectedOutput := "size: box 7cm\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestSpreadArguments(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function sum3(x, y, z) { return x + y + z }
+		let pair = [1, 2]
+		println(sum3(...pair, 10))
+		println(sum3(0, ...[1], ...[2]))
+		println(...["a", "b"])
+	`
+	expectedOutput := "13\n3\na b\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
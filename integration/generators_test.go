@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestGeneratorIteration(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function* count(n) {
+			let i = 0
+			while (i < n) {
+				yield i
+				i = i + 1
+			}
+		}
+		function* squares(source) {
+			iter (let v in source) {
+				yield v * v
+			}
+		}
+		iter (let x in squares(count(4))) {
+			print(x, "")
+		}
+		println()
+		function* stopAt(text, stop) {
+			iter (let ch in text) {
+				if (ch == stop) { return; }
+				yield ch
+			}
+		}
+		iter (let ch in stopAt("tulip", "i")) {
+			print(ch)
+		}
+		println()
+	`
+	expectedOutput := "0 1 4 9 \ntul\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestGeneratorNextAndDone(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function* pair() {
+			yield "a"
+			yield "b"
+		}
+		let g = pair()
+		println(g, get_runtype(g))
+		println(g.done(), g.next(), g.next(), g.done(), g.next())
+
+		function* counter() {
+			let total = 0
+			function add(n) {
+				total = total + n
+				return total
+			}
+			yield add
+			yield total
+			yield total
+		}
+		let c = counter()
+		let add = c.next()
+		add(5)
+		println(c.next())
+		add(2)
+		println(c.next())
+	`
+	expectedOutput := "<generator pair> generator\nfalse a b true null\n5\n7\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestGeneratorKeepsStackAcrossDeepCalls(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function depth(n) {
+			if (n == 0) { return 0 }
+			return depth(n - 1) + 1
+		}
+		function* grow() {
+			let total = 1
+			function get() { return total }
+			yield get
+			total = total + depth(60)
+			yield total
+		}
+		let g = grow()
+		let get = g.next()
+		println(g.next(), get())
+	`
+	expectedOutput := "61 61\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	scripts := map[string]struct {
+		source   string
+		exitCode int
+	}{
+		"yield outside generator":  {`function f() { yield 1 }`, 1},
+		"return value":             {`function* f() { return 1 }`, 1},
+		"runtime error in body":    {"function* g() { yield 1\n println(len(5)) }\niter (let x in g()) { println(x) }", 2},
+		"generator resumes itself": {"function* g() { yield me.next() }\nlet me = g()\nprintln(me.next())", 2},
+	}
+	for name, test := range scripts {
+		vm.InitVM([]string{"tulipscript"})
+		result := core.Interpret(test.source, "<script>")
+		vm.FreeVM()
+		if int(result) != test.exitCode {
+			t.Errorf("%s: expected exit code %d, got %d", name, test.exitCode, result)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestJSONEncode(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		struct Point { x = 1; y = 2 }
+		let data = {name: "tulip <3>", tags: ["a", "b"], 1: true, none: null, ratio: 0.5}
+		println(json_encode(data))
+		println(json_encode({b: 1, a: {d: [], c: {}}}, {sort_keys: true, indent: 2}))
+		println(json_encode(Point{x = 5}), json_encode(#{3, 1}), json_encode([1.0, 2.5, -7]))
+		println(json_encode([Date(2024, 3, 9), DateTime(2024, 3, 9, 14, 5, 0), Time(8, 30, 0)]))
+		println(json_encode([decimal("1.50"), bigint("123456789012345678901234")]))
+		let shared = [1]
+		println(json_encode([shared, shared]), str_length(std.json.encode("a	b")))
+	`
+	expectedOutput := "{\"name\":\"tulip <3>\",\"tags\":[\"a\",\"b\"],\"1\":true,\"none\":null,\"ratio\":0.5}\n" +
+		"{\n  \"a\": {\n    \"c\": {},\n    \"d\": []\n  },\n  \"b\": 1\n}\n" +
+		"{\"x\":5,\"y\":2} [3,1] [1,2.5,-7]\n" +
+		"[\"2024-03-09\",\"2024-03-09T14:05:00Z\",\"08:30:00\"]\n" +
+		"[1.50,123456789012345678901234]\n" +
+		"[[1],[1]] 6\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestJSONDecode(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"name": "hé", "ports": [80, 443], "ratio": 1.5, "big": 1e400, "debug": false, "extra": null}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := fmt.Sprintf(`
+		let config = json_decode(read_file("%s"))
+		println(config)
+		println(get_runtype(config["ports"][0]), get_runtype(config["ratio"]), config["big"])
+		println(json_decode(" [1, [2, {}]] "), json_decode("3"), json_decode("true"))
+		map_remove(config, "big")
+		println(equals(json_decode(json_encode(config)), config))
+		let ids = json_decode("[99999999999999999999, -9223372036854775809, 9223372036854775807, 1e20]")
+		println(ids, get_runtype(ids[0]), get_runtype(ids[3]), json_encode(ids) == "[99999999999999999999,-9223372036854775809,9223372036854775807,100000000000000000000]")
+		println(equals(json_decode(json_encode(ids)), ids), json_decode(json_encode(2 ** 70)) == 2 ** 70)
+	`, filepath.ToSlash(path))
+	expectedOutput := "{name: hé, ports: [80, 443], ratio: 1.5, big: +Inf, debug: false, extra: null}\n" +
+		"number number +Inf\n" +
+		"[1, [2, {}]] 3 true\n" +
+		"true\n" +
+		"[99999999999999999999, -9223372036854775809, 9223372036854775807, 1e+20] bigint number true\n" +
+		"true true\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"cycle", "let a = [1]\npush(a, a)\njson_encode(a)", "'json_encode' failed: cycle detected in array"},
+		{"map cycle", "let m = {}\nm[\"self\"] = m\njson_encode(m)", "'json_encode' failed: cycle detected in map"},
+		{"function", "function f() {}\njson_encode([f])", "'json_encode' failed: cannot encode a value of type closure"},
+		{"nan", "json_encode(math.nan)", "'json_encode' failed: cannot encode NaN"},
+		{"unknown option", "json_encode(1, {pretty: true})", "'json_encode' does not have an option pretty."},
+		{"bad indent", "json_encode(1, {indent: -1})", "'json_encode' option 'indent' must be a non-negative integer or a string."},
+		{"decode number", "json_decode(1)", "'json_decode' expects a string (got number)."},
+	})
+
+	documents := map[string]string{
+		"{\"a\": tru}":                  "line 1, column 10: invalid character '}' in literal true (expecting 'e')",
+		"[1, 2":                         "line 1, column 6: unexpected end of JSON input",
+		"{\n  \"é\": 1,\n  \"b\": ]\n}": "line 3, column 8: invalid character ']' looking for beginning of value",
+		"[1]\n  x":                      "line 2, column 3: invalid character 'x' after top-level value",
+		"{\"a\": 1,}":                   "line 1, column 9: invalid character '}' looking for beginning of object key string",
+	}
+	var cases []errorCase
+	for document, message := range documents {
+		path := filepath.Join(t.TempDir(), "bad.json")
+		if err := os.WriteFile(path, []byte(document), 0644); err != nil {
+			t.Fatal(err)
+		}
+		script := fmt.Sprintf(`json_decode(read_file("%s"))`, filepath.ToSlash(path))
+		cases = append(cases, errorCase{document, script, "Runtime Error: 'json_decode' failed at " + message})
+	}
+	expectRuntimeErrors(t, cases)
+}
@@ -77,3 +77,242 @@ func TestIteratorLoop(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestIteratorLoopDestructuring(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function show(pairs) {
+			iter (let [k, v] in pairs) {
+				let line = k + "=" + to_str(v)
+				println(line)
+			}
+		}
+		show([["a", 1], ["b", 2]])
+		iter (let item in [1, 2]) {
+			let doubled = item * 2
+			println(item, doubled)
+		}
+	`
+	expectedOutput := "a=1\nb=2\n1 2\n2 4\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestIteratorLoopOverMap(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let stock = {"pears": 2, "apples": 5}
+		iter (let name in stock) {
+			println(name)
+		}
+		iter (let [name, count] in stock) {
+			println(name, count)
+		}
+	`
+	expectedOutput := "pears\napples\npears 2\napples 5\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestIteratorLoopOverStringGraphemes(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := "iter (let ch in \"ae\u0301👍🏽🇳🇴\") { print(\"[\" + ch + \"]\") }"
+	expectedOutput := "[a][e\u0301][👍🏽][🇳🇴]"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestIteratorLoopOverInstance(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		struct Countdown { from = 3; next = null; done = null }
+		let countdown = Countdown{}
+		function countdownDone() { return countdown.from == 0 }
+		function countdownNext() {
+			countdown.from = countdown.from - 1
+			return countdown.from + 1
+		}
+		countdown.done = countdownDone
+		countdown.next = countdownNext
+		iter (let n in countdown) {
+			print(n)
+		}
+	`
+	expectedOutput := "321"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestIteratorLoopRejectsNonIterable(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"number", `iter (let x in 42) { print(x) }`, "Cannot iterate over number; expected an array, map, set, string, bytes, range, generator or iterator."},
+	})
+}
+
+func TestIteratorLoopRecursion(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function sum(node) {
+			if (is_int(node)) {
+				return node
+			}
+			let total = 0
+			iter (let child in node) {
+				total = total + sum(child)
+			}
+			return total
+		}
+		println(sum([1, [2, [3, 4]], 5, [[6]]]))
+	`
+	expectedOutput := "21\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestNestedIteratorLoopsOnOneLine(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		iter (let a in [1, 2]) { iter (let b in ["x", "y"]) { print(to_str(a) + b + " ") } }
+	`
+	expectedOutput := "1x 1y 2x 2y "
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestRangeIteration(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		iter (let i in 0..4) { print(i) }
+		println()
+		iter (let i in 1..=3) { print(i) }
+		println()
+		iter (let i in 10..0 step -3) { print(i, "") }
+		println()
+		iter (let i in 5..5) { print(i) }
+		let n = 3
+		let step = 2
+		println(0..n*2 step step, 1..=n, get_runtype(0..1))
+	`
+	expectedOutput := "0123\n123\n10 7 4 1 \n0..6 step 2 1..=3 range\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestRangeExtremeBounds(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function first(r) {
+			iter (let i in r) { return i }
+			return "empty"
+		}
+		let wide = -9223372036854775807..9223372036854775807
+		println(first(wide), 0 in wide, -9223372036854775807 in wide, 9223372036854775807 in wide)
+		let down = 9223372036854775807..=-9223372036854775807 step -2
+		println(first(down), 1 in down, 0 in down, -9223372036854775807 in down, 5 in 9223372036854775807..0)
+		let full = (-9223372036854775807 - 1)..=9223372036854775807
+		println(first(full), 42 in full, equals(full, full), equals(wide, -9223372036854775807..9223372036854775807 step 1))
+		iter (let i in 9223372036854775805..=9223372036854775807) { print(i, "") }
+		println()
+	`
+	expectedOutput := "-9223372036854775807 true true false\n" +
+		"9223372036854775807 true false true false\n" +
+		"-9223372036854775808 true true true\n" +
+		"9223372036854775805 9223372036854775806 9223372036854775807 \n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestRangeRejectsBadBounds(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"zero step", `iter (let i in 0..10 step 0) { println(i) }`, "Range step cannot be zero."},
+		{"float bounds", `iter (let i in 0..2.5) { println(i) }`, "Range bounds must be integers (got number and number)."},
+	})
+}
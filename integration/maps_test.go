@@ -80,3 +80,138 @@ func TestMapAddition(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestMapSpread(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		struct Point { x = 1; y = 2 }
+		let defaults = {"host": "localhost", "port": 80}
+		let config = {...defaults, "port": 8080}
+		println(config["host"], config["port"], defaults["port"])
+		let merged = {"x": 0, ...Point{}, "label": "p"}
+		println(merged["x"], merged["y"], merged["label"])
+		println({"a": 1, "a": 2}["a"])
+		struct Record { zeta = 1; alpha = 2; mid = 3 }
+		println({...Record{}}, map_keys({"first": 0, ...Record{}}))
+	`
+	expectedOutput := "localhost 8080 80\n1 2 p\n2\n{alpha: 2, mid: 3, zeta: 1} [first, alpha, mid, zeta]\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMapHigherOrderNatives(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let stock = {"apples": 5, "pears": 0, "kiwis": 3}
+		function inStock(count) { return count > 0 }
+		function describe(count, fruit) { return fruit + "=" + to_str(count) }
+		let available = map_filter(stock, inStock)
+		println(map_size(available), map_contains_key(available, "pears"), available["kiwis"])
+		let labels = map_map_values(stock, describe)
+		println(labels["apples"], labels["pears"], map_size(stock), stock["apples"])
+	`
+	expectedOutput := "2 false 3\napples=5 pears=0 3 5\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMapInsertionOrder(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let m = {"zebra": 1, "apple": 2, "mango": 3}
+		m["kiwi"] = 4
+		m["zebra"] = 5
+		println(m)
+		println(map_keys(m), map_values(m), to_str(m))
+		map_remove(m, "apple")
+		m["apple"] = 6
+		println(m)
+		println(m + {"fig": 7, "zebra": 0}, m - {"mango": 0})
+		let big = {}
+		for (let i = 0; i < 20; i++) { big[to_str(i)] = i }
+		for (let i = 0; i < 18; i++) { map_remove(big, to_str(i)) }
+		big["x"] = 1
+		println(big, map_size(big))
+	`
+	expectedOutput := "{zebra: 5, apple: 2, mango: 3, kiwi: 4}\n" +
+		"[zebra, apple, mango, kiwi] [5, 2, 3, 4] {zebra: 5, apple: 2, mango: 3, kiwi: 4}\n" +
+		"{zebra: 5, mango: 3, kiwi: 4, apple: 6}\n" +
+		"{zebra: 0, mango: 3, kiwi: 4, apple: 6, fig: 7} {zebra: 5, kiwi: 4, apple: 6}\n" +
+		"{18: 18, 19: 19, x: 1} 3\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMapHashableKeys(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let m = {1: "one", 2.5: "half", true: "yes", null: "none", "1": "string one", [-1]: "last"}
+		println(m)
+		println(m[1.0], m["1"], m[5 / 2], m[true], m[null], m[-1], m[false])
+		let counts = {}
+		iter (let n in [42, 7, 42, 42]) {
+			if (n in counts) { counts[n] = counts[n] + 1 } else { counts[n] = 1 }
+		}
+		println(counts, map_keys(counts), map_contains_key(counts, 7.0))
+		map_remove(counts, 7)
+		counts[1.0] = 0
+		println(counts)
+	`
+	expectedOutput := "{1: one, 2.5: half, true: yes, null: none, 1: string one, -1: last}\n" +
+		"one string one half yes none last null\n" +
+		"{42: 3, 7: 1} [42, 7] true\n" +
+		"{42: 3, 1: 0}\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMapRejectsUnhashableKeys(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"array literal key", `let m = {[[1, 2]]: "pair"}`, "Map key must be a string, number, boolean or null (got array)."},
+		{"map key native", `map_contains_key({}, {})`, "Map key must be a string, number, boolean or null (got map)."},
+	})
+}
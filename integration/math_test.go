@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestMathModule(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(math.pi, math.inf, math.is_nan(math.nan), get_runtype(math))
+		println(math.sqrt(16), math.abs(-3), math.abs(-2.5), math.sign(-7), math.hypot(3, 4))
+		println(math.floor(2.7), math.ceil(2.1), math.round(2.5), math.round(-2.5), math.trunc(-2.7), math.round(3.14159, 2))
+		println(get_runtype(math.floor(2.7)), math.floor(math.inf))
+		println(math.sin(0), math.cos(math.pi), math.degrees(math.pi), math.atan2(0, -1) == math.pi)
+		println(math.log(math.e), math.log(8, 2), math.log10(1000), math.pow(2, 10), math.exp(0))
+		println(math.min(3, 1, 2), math.max(3, 1.5, 2), math.max([4, 9, 2]), math.clamp(15, 0, 10))
+		println(math.gcd(12, 18), math.gcd(-12, 18, 8), math.lcm(4, 6), math.lcm(3, 0))
+		println(math.abs(math.min_int), math.gcd(math.min_int, 0), math.lcm(2 ** 62, 3), get_runtype(math.lcm(2 ** 62, 3)))
+		println(math.is_finite(math.inf), math.is_finite(3), math.is_inf(-math.inf))
+	`
+	expectedOutput := "3.141592653589793 +Inf true module\n" +
+		"4 3 2.5 -1 5\n" +
+		"2 3 3 -3 -2 3.14\n" +
+		"number +Inf\n" +
+		"0 -1 180 true\n" +
+		"1 3 3 1024 1\n" +
+		"1 3 9 10\n" +
+		"6 2 12 0\n" +
+		"9223372036854775808 9223372036854775808 13835058055282163712 bigint\n" +
+		"false true true\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMathElementWise(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(math.sqrt([1, 4, 9]), math.abs([-1, 2.5]), math.floor([1.5, -1.5]))
+		println(math.pow([1, 2, 3], 2), math.round([1.234, 5.678], 1), math.clamp([-5, 5, 50], 0, 10))
+		println(math.is_nan([1, math.nan]), math.sqrt([]))
+	`
+	expectedOutput := "[1, 2, 3] [1, 2.5] [1, -2]\n" +
+		"[1, 4, 9] [1.2, 5.7] [0, 5, 10]\n" +
+		"[false, true] []\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"string argument", `math.sqrt("4")`, "'math.sqrt' requires a number or an array of numbers (got string)."},
+		{"non-number element", `math.abs([1, "2"])`, "'math.abs' requires an array of numbers (got string element)."},
+		{"fractional gcd", `math.gcd(1.5, 3)`, "'math.gcd' requires integers (got number)."},
+		{"empty max", `math.max([])`, "'math.max' expects at least one number."},
+		{"inverted clamp range", `math.clamp(1, 10, 0)`, "'math.clamp' low bound must not be greater than the high bound."},
+	})
+}
@@ -1,7 +1,9 @@
 package integration
 
 import (
-	"os"
+	"fmt"
+	"path/filepath"
+	goruntime "runtime"
 	"strconv"
 	"testing"
 
@@ -80,13 +82,13 @@ func TestFileOperations(t *testing.T) {
 	vm.InitVM([]string{"tulipscript"})
 	t.Cleanup(vm.FreeVM)
 
-	script := `
-		let filename = "test.txt"
+	script := fmt.Sprintf(`
+		let filename = "%s"
 		let content = "Hello, World!"
 		write_file(filename, content)
 		let readContent = read_file(filename)
 		print(readContent)
-	`
+	`, filepath.ToSlash(filepath.Join(t.TempDir(), "test.txt")))
 	expectedOutput := "Hello, World!"
 
 	output := captureOutput(t, func() {
@@ -99,9 +101,6 @@ func TestFileOperations(t *testing.T) {
 	if output != expectedOutput {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
-
-	// Clean up
-	os.Remove("test.txt")
 }
 
 func TestDateCreation(t *testing.T) {
@@ -170,6 +169,36 @@ func TestDateTimeCreation(t *testing.T) {
 	}
 }
 
+func TestExternUnsignedIntegers(t *testing.T) {
+	if goruntime.GOOS != "linux" {
+		t.Skip("needs the GNU C library")
+	}
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		use "libc.so.6" {
+			uint64_t strtoull(char*, char*, int32_t)
+			size_t strnlen(char*, size_t)
+		}
+		let max = strtoull("18446744073709551615", null, 10)
+		println(max, get_runtype(max), strtoull("42", null, 10), strtoull("9223372036854775808", null, 10))
+		println(strnlen("tulip", max), strnlen("tulip", 3))
+	`
+	expectedOutput := "18446744073709551615 bigint 42 9223372036854775808\n5 3\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
 func TestRandomString(t *testing.T) {
 	vm.InitVM([]string{"tulipscript"})
 	t.Cleanup(vm.FreeVM)
@@ -212,3 +241,71 @@ func TestSprintf(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestSprintfVerbs(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(sprintf("[%.2f] [%5d] [%-6s] [%x] [%08.3f] [%#X]", 3.14159, 42, "ab", 255, -3.14159, 255))
+		println(sprintf("[%+d] [%05d] [%*d] [%-*d] [%.*f] [%o] [%b]", 7, -42, 4, 9, 3, 9, 1, 2.25, 8, 5))
+		println(sprintf("[%v] [%s] [%q] [%t] [%c] [%T] [%.3s] [%%]", [1, "a"], 2.5, "hi", false, 65, 1.5, "abcdef"))
+		println(sprintf("[%d] [%x] [%d] [%e] [%g]", bigint("12345678901234567890"), "hi", 4.0, 1234.5, 0.25))
+		println(sprintf("[%f] [%.1f] [%08.2f] [%+.3f]", decimal("19.99"), decimal("2.25"), decimal("-1.5"), decimal("3")))
+		printf("%d items at %.2f\n", 3, 9.5)
+		println(errorf("code %04d: %s", 7, "not found"))
+	`
+	expectedOutput := "[3.14] [   42] [ab    ] [ff] [-003.142] [0XFF]\n" +
+		"[+7] [-0042] [   9] [9  ] [2.2] [10] [101]\n" +
+		"[[1, a]] [2.5] [\"hi\"] [false] [A] [number] [abc] [%]\n" +
+		"[12345678901234567890] [6869] [4] [1.234500e+03] [0.25]\n" +
+		"[19.99] [2.2] [-0001.50] [+3.000]\n" +
+		"3 items at 9.50\n" +
+		"code 0007: not found\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestSprintfErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"verb mismatch", `sprintf("%d", "x")`, "'sprintf' verb '%d' cannot format string."},
+		{"fractional int", `sprintf("%d", 1.5)`, "'sprintf' verb '%d' cannot format number."},
+		{"missing argument", `sprintf("%d %d", 1)`, "'sprintf' format has more verbs than arguments (1 given)."},
+		{"extra argument", `printf("%d", 1, 2)`, "'printf' got 2 arguments but the format only uses 1."},
+		{"unknown verb", `sprintf("%y", 1)`, "'sprintf' format has an unknown verb '%y'."},
+		{"incomplete verb", `errorf("100%", 1)`, "'errorf' format ends with an incomplete verb."},
+		{"bad star argument", `sprintf("%*d", "a", 1)`, "'sprintf' expects an integer up to 1000000 for '*' (got string)."},
+	})
+}
+
+func TestIntegerConversions(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(parse_int("42"), parse_int("ff", 16), parse_int("0b101", 0), parse_int("abc"))
+		println(to_int(3.9), to_int(-3.9), to_int("12"), to_float(3) / 2)
+		println(to_str(12345678901234567), len([1, 2, 3]) * 2)
+	`
+	expectedOutput := "42 255 5 null\n3 -3 12 1.5\n12345678901234567 6\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
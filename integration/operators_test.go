@@ -161,3 +161,268 @@ func TestDecrementOperators(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestNumericLiterals(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(0xFF, 0o755, 0b1010)
+		println(1e-9, 6.02e23, 2E+2)
+		println(1_000_000 == 1000000, 0xFF_FF)
+	`
+	expectedOutput := "255 493 10\n1e-09 6.02e+23 200\ntrue 65535\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMalformedNumericLiterals(t *testing.T) {
+	expectCompileErrors(t, []errorCase{
+		{"bad hex digit", "println(0xZZ)", "Error at '0xZZ': Invalid hexadecimal literal '0xZZ'; contains a digit not allowed in base 16."},
+		{"bad binary digit", "println(0b102)", "Error at '0b102': Invalid binary literal '0b102'; contains a digit not allowed in base 2."},
+		{"empty octal", "println(0o)", "Error at '0o': Invalid octal literal '0o'; expected digits after the '0o' prefix."},
+		{"double separator", "println(1__000)", "Error at '1__000': Invalid number literal '1__000'; digit separator '_' must appear between two digits."},
+		{"trailing separator", "println(1_)", "Error at '1_': Invalid number literal '1_'; digit separator '_' must appear between two digits."},
+		{"empty exponent", "println(1e)", "Error at '1e': Invalid number literal '1e'; must be a valid number."},
+		{"letter suffix", "println(12abc)", "Error at '12abc': Invalid number literal '12abc'; must be a valid number."},
+	})
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let id = 9007199254740993
+		println(id + 1, 2 ** 62, 0xFFFFFFFFFFFFFFFF)
+		println(7 / 2, 7 /_ 2, -7 /_ 2, 7 % 3, -7 % 3)
+		println(1 + 0.5, 2 ** -1, 1 == 1.0, 3 > 2.5)
+		println(get_runtype(1), get_runtype(1.5), is_int(1), is_int(1.0), is_int(2n), is_int("1"))
+		println(2 ** 64, (-3) ** 41, (-2) ** 63, is_int(2 ** 64), math.pow(2, 64))
+		let max = math.max_int
+		let min = math.min_int
+		println(max + 1, min - 1, max * 2, -min, min /_ -1, max + 1 - 1, is_int(max + 1))
+		println(to_int((max * 31) & 0xFFFFFFFF), get_runtype(to_int(2n)))
+	`
+	expectedOutput := "9007199254740994 4611686018427387904 -1\n3.5 3 -4 1 -1\n1.5 0.5 true true\nnumber number true false true false\n" +
+		"18446744073709551616 -36472996377170786403 -9223372036854775808 true 18446744073709551616\n" +
+		"9223372036854775808 -9223372036854775809 18446744073709551614 9223372036854775808 9223372036854775808 9223372036854775807 true\n" +
+		"4294967265 number\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(0xF0 & 0x3C, 0xF0 | 0x0F, 0xFF ^ 0x0F, ~0)
+		println(1 << 40, -16 >> 2, 6 & 3 == 2)
+		let flags = 0b0101
+		println(flags & 1 == 1 && (flags | 2) == 7)
+		println(1 << 64, 1 << 63, 3 << 62, -1 << 63, 0 << 100)
+		println((1 << 64) >> 1, (1 << 64) | 1, (1 << 70) & 0xFF, 5n << 1, -8n >> 100)
+		println(~(1 << 63), ~(1 << 64) & 0xFF, ~5n, ~~(1 << 63) == 1 << 63)
+	`
+	expectedOutput := "48 255 240 -1\n1099511627776 -4 true\ntrue\n" +
+		"18446744073709551616 9223372036854775808 13835058055282163712 -9223372036854775808 0\n" +
+		"9223372036854775808 18446744073709551617 0 10 -1\n" +
+		"-9223372036854775809 255 -6 true\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestIntegerOverflowLimits(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"huge shift", "1 << (1 << 40)", "Shift count 1099511627776 is too large for '<<'."},
+		{"huge exponent", "2 ** (1 << 40)", "Exponent 1099511627776 is too large for '**'."},
+		{"bigint to_int", "to_int(1 << 64)", "'to_int' cannot convert 18446744073709551616 to a 64-bit integer."},
+		{"bit not of a float", "~1.5", "Operand for '~' must be an integer (got number)."},
+	})
+}
+
+func TestBigIntArithmetic(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let big = 123456789012345678901234567890n
+		println(big * 2, big + 1, -big)
+		println(2n ** 100, 0xFFn, 7n / 2n, -7n /_ 2n, -7n % 2n)
+		println(big > 1, 1n == 1, get_runtype(big), to_str(10n))
+	`
+	expectedOutput := "246913578024691357802469135780 123456789012345678901234567891 -123456789012345678901234567890\n" +
+		"1267650600228229401496703205376 255 3 -4 -1\n" +
+		"true true bigint 10\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let price = decimal("19.99")
+		println(price * 3, price + 1, decimal("20.00"), decimal(0.1) + decimal(0.2))
+		println(decimal_round(decimal("2.345"), 2), decimal_round(decimal("2.345"), 2, "half_up"))
+		decimal_set_scale(2)
+		decimal_set_rounding("half_up")
+		println(decimal("10") / 3, decimal("2") / 3, price % 5, decimal("1.5") ** 2)
+		println(decimal("1.10") == decimal("1.1"), price > 19, [decimal("1.50")])
+		printf("%s\n", price)
+	`
+	expectedOutput := "59.97 20.99 20.00 0.3\n2.34 2.35\n3.33 0.67 4.99 2.25\ntrue true [1.50]\n19.99\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestDecimalRejectsFloat(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"decimal plus float", `println(decimal("1.00") + 0.5)`, "Cannot mix decimal and number in '+'; convert explicitly with decimal(), bigint() or to_float()."},
+	})
+}
+
+func TestDecimalPowScaleLimit(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"positive exponent", `decimal("1.25") ** 2000000000`, "Exponent 2000000000 is too large for '**': the result would have more than 2147483647 decimal places."},
+		{"negative exponent", `decimal("1.25") ** -2000000000`, "Exponent -2000000000 is too large for '**': the result would have more than 2147483647 decimal places."},
+	})
+}
+
+func TestInOperator(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let r = 1..=10 step 3
+		println(4 in r, 5 in r, 10 in r, 0 in 0..0)
+		println(20 in [10, 20], 3 in [10, 20], "a" in {"a": 1}, "b" in {"a": 1})
+		println("ell" in "hello", "xyz" in "hello", 2 + 1 in 0..5 == true)
+	`
+	expectedOutput := "true false true false\ntrue false true false\ntrue false true\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestEqualityComparesObjectIdentity(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let a = [1, 2]
+		let b = [1, 2]
+		println(a == a, a == b, a != b, "x" + "y" == "xy", 1 == 1.0)
+		struct P { x = 0 }
+		let p = P{x = 1}
+		let m = {"k": p}
+		println(p == p, p == P{x = 1}, m == m, m == {"k": p})
+		println(array_contains([a], a), array_contains([a], b), index_of([b, a], a), map_contains_value(m, p), a in [b, a])
+	`
+	expectedOutput := "true false true true true\n" +
+		"true false true false\n" +
+		"true false 1 true true\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestDeepEquals(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		println(equals([1, [2, {"a": 3}]], [1, [2, {"a": 3}]]), equals([1, 2], [1, 2, 3]), equals([1], [1.0]))
+		println(equals({"a": 1, "b": 2}, {"b": 2, "a": 1}), equals({"a": 1}, {"a": "1"}), equals(#{1, 2}, #{2, 1}))
+		struct P { x = 0; tags = null }
+		struct Q { x = 0; tags = null }
+		println(equals(P{x = 1, tags = ["t"]}, P{x = 1, tags = ["t"]}), equals(P{x = 1}, P{x = 2}), equals(P{}, Q{}))
+		println(equals(Date(2024, 1, 2), Date(2024, 1, 2)), equals(0..3, 0..=2), equals(null, null), equals("a", "b"))
+		let left = [1]
+		push(left, left)
+		let right = [1]
+		push(right, right)
+		let other = [2]
+		push(other, other)
+		println(equals(left, right), equals(left, other))
+	`
+	expectedOutput := "true false true\n" +
+		"true false true\n" +
+		"true false false\n" +
+		"true true true false\n" +
+		"true false\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestRegexMatching(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let pair = /(\d+)-(\d+)/
+		println(pair, get_runtype(pair), pair.pattern, pair.flags)
+		println(pair.test("a 10-20 b"), pair.match("a 10-20 b"), pair.match("none"))
+		let date = /(?P<year>\d{4})-(?P<month>\d\d)(-(?P<day>\d\d))?/
+		println(date.match("on 2024-03"), date.named("on 2024-03-09"), date.named("x"))
+		println(/\w+/.find_all("one two three"), pair.find_all("1-2, 3-4"), /o/.find_all("foo boo", 2))
+		let shout = regex("HELLO", "i")
+		println(shout.test("say hello"), shout, std.regex.new("^b", "m").find_all("a
+b
+bc"))
+	`
+	expectedOutput := "/(\\d+)-(\\d+)/ regex (\\d+)-(\\d+) \n" +
+		"true [10-20, 10, 20] null\n" +
+		"[2024-03, 2024, 03, null, null] {year: 2024, month: 03, day: 09} null\n" +
+		"[one, two, three] [[1-2, 1, 2], [3-4, 3, 4]] [o, o]\n" +
+		"true /HELLO/i [b, b]\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestRegexReplaceAndSplit(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		function add(whole, a, b) { return to_int(a) + to_int(b) }
+		function upper(whole) { return to_upper(whole) }
+		let pair = /(\d+)-(\d+)/
+		println(pair.replace("1-2 and 3-4", "$2-$1"), pair.replace("1-2 and 3-4", add))
+		println(/(?P<word>\w+)@/.replace("ana@ bo@", "${word}!"), /[aeiou]/.replace("tulip", upper))
+		println(/\s*,\s*/.split("a , b,c"), /,/.split("a,b,c", 2), /x/.split("abc"))
+		println(/a\/b/.test("xa/by"), /[/]/.test("/"))
+		let x = 8
+		let y = 2
+		println(x / y / 2, (x) / 2, [x][0] / 4)
+	`
+	expectedOutput := "2-1 and 4-3 3 and 7\n" +
+		"ana! bo! tUlIp\n" +
+		"[a, b, c] [a, b,c] [abc]\n" +
+		"true true\n" +
+		"2 4 2\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestRegexErrors(t *testing.T) {
+	expectCompileErrors(t, []errorCase{
+		{"bad pattern", "let r = /(a/", "Error at '/(a/': Invalid regular expression /(a/: error parsing regexp: missing closing ): `(a`"},
+		{"unknown flag", "let r = /a/x", "Error at '/a/x': Invalid regular expression /a/x: unknown flag 'x'; the supported flags are i, m, s and U"},
+		{"unterminated", "let r = /abc", "Error: Invalid token 'Unterminated regular expression literal.' encountered."},
+	})
+
+	expectRuntimeErrors(t, []errorCase{
+		{"bad runtime pattern", `regex("(")`, "'regex' failed: error parsing regexp: missing closing ): `(`"},
+		{"bad runtime flag", `regex("a", "g")`, "'regex' failed: unknown flag 'g'; the supported flags are i, m, s and U"},
+		{"unknown property", `/a/.nope`, "Property 'nope' does not exist on regex."},
+		{"bad subject", `/a/.test(1)`, "'test' expects a string (got number)."},
+		{"bad replacement", `/a/.replace("a", 1)`, "'replace' expects a string or a function as the replacement (got number)."},
+		{"bad limit", `/a/.split("a", 0)`, "'split' limit must be a positive integer."},
+		{"callback fails", "function f(m) { return len(5) }\n/a/.replace(\"a\", f)", "'len' can only be used on arrays and bytes."},
+	})
+}
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestSetLiteralAndNatives(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let s = #{3, 1, 3, 2.0, 2}
+		println(s, #{}, get_runtype(s))
+		println(set_add(s, 4), set_add(s, 1.0), set_remove(s, 3), set_remove(s, 3))
+		println(set_has(s, 4), set_has(s, "4"), set_size(s), 2 in s, 9 in s)
+		println(set_values(s), [...s, 5], #{...["a", "a"], true, null})
+		iter (let x in #{"b", "a", "c"}) { print(x) }
+		println()
+	`
+	expectedOutput := "#{3, 1, 2} #{} set\n" +
+		"true false true false\n" +
+		"true false 3 true false\n" +
+		"[1, 2, 4] [1, 2, 4, 5] #{a, true, null}\n" +
+		"bac\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let a = #{1, 2, 3}
+		let b = #{3, 4}
+		println(a + b, a - b, a * b, a ^ b)
+		println(b - a, #{} * a, 6 ^ 3, to_str(a))
+	`
+	expectedOutput := "#{1, 2, 3, 4} #{1, 2} #{3} #{1, 2, 4}\n" +
+		"#{4} #{} 5 #{1, 2, 3}\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"unhashable literal element", `let s = #{[1, 2]}`, "Set element must be a string, number, boolean or null (got array)."},
+		{"unhashable added element", `set_add(#{}, {})`, "Set element must be a string, number, boolean or null (got map)."},
+		{"set native on an array", `set_size([1, 2])`, "'set_size' can only be used on sets (got array)."},
+		{"union with an array", `let s = #{1} + [2]`, "Operands must be of the same type for '+'. Got set and array."},
+	})
+}
@@ -0,0 +1,120 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
+)
+
+func TestStdModules(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		import std.str as s
+		import std.array as a
+		import std.map as m
+		println(s.to_upper("abc"), s.contains("tulip", "li"), s.length("héllo"))
+		let xs = [3, 1, 2]
+		a.push(xs, 0)
+		println(a.sort(xs), a.len(xs), a.contains(xs, 2))
+		println(m.keys({x: 1, y: 2}), std.set.has(#{1, 2}, 2), std.math.sqrt(9))
+		println(s.trim == trim, a.len == len, std.io.println == println, math == std.math)
+		println(get_runtype(std), get_runtype(std.fs.read_file))
+	`
+	expectedOutput := "ABC true 5\n" +
+		"[0, 1, 2, 3] 4 true\n" +
+		"[x, y] true 3\n" +
+		"true true true true\n" +
+		"module native function\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestStdWithoutGlobalAliases(t *testing.T) {
+	vm.GlobalAliases = false
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(func() {
+		vm.FreeVM()
+		vm.GlobalAliases = true
+	})
+
+	script := `
+		import std.str as s
+		println(s.trim("  core  "), len([1, 2]), to_str(1) + "!")
+	`
+	expectedOutput := "core 2 1!\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+
+	expectRuntimeErrors(t, []errorCase{
+		{"string alias", `trim(" x ")`, "Global variable 'trim' is not defined."},
+		{"map alias", `map_keys({})`, "Global variable 'map_keys' is not defined."},
+		{"math module", `math.pi`, "Global variable 'math' is not defined."},
+	})
+}
+
+func TestBuiltinsAreConst(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"assign core builtin", `len = 3`, "Cannot assign to global constant 'len'."},
+		{"assign global alias", `to_upper = null`, "Cannot assign to global constant 'to_upper'."},
+		{"assign std module", `std = 1`, "Cannot assign to global constant 'std'."},
+		{"assign math module", `math = 1`, "Cannot assign to global constant 'math'."},
+		{"assign arguments", `args = []`, "Cannot assign to global constant 'args'."},
+		{"assign print function", "function p() { print = 0 }\np()", "Cannot assign to global constant 'print'."},
+		{"redeclare core builtin", `let len = 5`, "Cannot redeclare builtin 'len' at the top level; use another name, or declare it inside a block or function to shadow it there."},
+		{"redeclare as function", "function print() {}", "Cannot redeclare builtin 'print' at the top level; use another name, or declare it inside a block or function to shadow it there."},
+		{"redeclare std module", `let std = {}`, "Cannot redeclare builtin 'std' at the top level; use another name, or declare it inside a block or function to shadow it there."},
+	})
+
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	// A declaration inside a block or function still shadows a builtin there, and a top-level
+	// declaration replaces a global that is not a core builtin.
+	script := `
+		{
+			let trim = 5
+			println(trim, std.str.trim(" ok "))
+		}
+		function count(len) {
+			return len
+		}
+		println(count(3), len([1, 2]))
+		let split = "parts"
+		const math = 2
+		function regex() { return "mine" }
+		println(split, math, regex(), std.str.split("a,b", ","), std.math.pi > 3)
+	`
+	expectedOutput := "5 ok\n3 2\nparts 2 mine [a, b] true\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
@@ -52,3 +52,67 @@ func TestEmojiStructs(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestUnicodeStringNatives(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let s = "héllo wörld"
+		println(str_length(s), str_byte_length(s), char_at(s, 1), char_at(s, 7), char_at(s, 11))
+		println(substring(s, 6, 11), substring("日本語テキスト", 2, 4), substring(s, 8, 100))
+		println(str_index_of(s, "wörld"), str_last_index_of(s, "l"), str_index_of(s, "x"))
+		let chars = to_chars("añ日")
+		println(chars, len(chars))
+	`
+	expectedOutput := "11 13 é ö null\n" +
+		"wörld 語テ rld\n" +
+		"6 9 -1\n" +
+		"[a, ñ, 日] 3\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestUnicodeGraphemeNatives(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	// "e" + combining acute, a thumbs up with a skin tone, a flag and a family ZWJ sequence.
+	script := "let s = \"e\u0301👍🏽🇳🇴👨‍👩‍👧\"\n" +
+		"println(str_grapheme_length(s), str_length(s), str_byte_length(s))\n" +
+		"let parts = str_graphemes(s)\n" +
+		"println(len(parts), parts[1], parts[3], str_length(parts[3]))\n" +
+		"println(str_graphemes(\"\"), str_graphemes(\"ok\"))\n" +
+		// Hangul jamo and syllables: L V T, LV T + LVT T, L LV, and LVT V, which breaks.
+		"println(str_grapheme_length(\"\u1100\u1161\u11A8\"), str_grapheme_length(\"\uAC00\u11A8\uAC01\u11A8\"), " +
+		"str_grapheme_length(\"\u1100\uAC00\"), str_grapheme_length(\"\uAC01\u1161\"))\n" +
+		// The Arabic number sign prepends, a Devanagari vowel sign is a spacing mark and a tab
+		// keeps a combining mark from attaching to it.
+		"println(str_grapheme_length(\"\u0600\u0661\u0662\"), str_grapheme_length(\"\u0915\u093F\"), " +
+		"str_grapheme_length(\"\t\u0301\"), str_grapheme_length(\"\u0600\n\"))\n"
+	expectedOutput := "4 11 37\n" +
+		"4 👍🏽 👨‍👩‍👧 5\n" +
+		"[] [o, k]\n" +
+		"1 2 1 2\n" +
+		"2 1 2 2\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
@@ -4,24 +4,40 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/cryptrunner49/tulipscript/internal/core"
+	"github.com/cryptrunner49/tulipscript/internal/vm"
 )
 
 // captureOutput captures the stdout output of the function f and returns it as a string.
 // It ensures proper pipe handling and error checking.
 func captureOutput(t *testing.T, f func()) string {
 	t.Helper()
+	return captureFile(t, &os.Stdout, f)
+}
+
+// captureStderr captures the stderr output of the function f, such as runtime error messages.
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	return captureFile(t, &os.Stderr, f)
+}
+
+// captureFile redirects *file to a pipe while f runs and returns what was written to it.
+func captureFile(t *testing.T, file **os.File, f func()) string {
+	t.Helper()
 
-	// Save the original stdout
-	old := os.Stdout
-	defer func() { os.Stdout = old }()
+	// Save the original file
+	old := *file
+	defer func() { *file = old }()
 
 	// Create a pipe to capture output
 	r, w, err := os.Pipe()
 	if err != nil {
 		t.Fatalf("Failed to create pipe: %v", err)
 	}
-	os.Stdout = w
+	*file = w
 
 	// Run the function
 	f()
@@ -44,3 +60,42 @@ func captureOutput(t *testing.T, f func()) string {
 
 	return buf.String()
 }
+
+// errorCase is a script that must fail with an error whose message contains message.
+type errorCase struct {
+	name    string
+	script  string
+	message string
+}
+
+// expectRuntimeErrors runs each case in a fresh VM and checks that it stops with a runtime error
+// reporting the expected message, so a case cannot pass on an unrelated error such as a typo in
+// its script.
+func expectRuntimeErrors(t *testing.T, cases []errorCase) {
+	t.Helper()
+	expectErrors(t, cases, 2, "runtime")
+}
+
+// expectCompileErrors runs each case in a fresh VM and checks that it fails to compile with the
+// expected message.
+func expectCompileErrors(t *testing.T, cases []errorCase) {
+	t.Helper()
+	expectErrors(t, cases, 1, "compile")
+}
+
+// expectErrors runs the cases of expectRuntimeErrors and expectCompileErrors, which differ in the
+// exit code core.Interpret returns.
+func expectErrors(t *testing.T, cases []errorCase, exitCode int, kind string) {
+	t.Helper()
+	for _, c := range cases {
+		vm.InitVM([]string{"tulipscript"})
+		var result int
+		stderr := captureStderr(t, func() {
+			result = core.Interpret(c.script, "<script>")
+		})
+		vm.FreeVM()
+		if result != exitCode || !strings.Contains(stderr, c.message) {
+			t.Errorf("%s: expected %s error %q, got exit code %d and %q", c.name, kind, c.message, result, stderr)
+		}
+	}
+}
@@ -56,3 +56,70 @@ func TestNegativeNumbers(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expectedOutput, output)
 	}
 }
+
+func TestArrayDestructuring(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		let [a, b, ...rest] = [1, 2, 3, 4]
+		const [x, [y, z]] = [5, [6, 7]]
+		println(a, b, rest, x, y, z)
+		{
+			let [first, ...others] = ["only"]
+			println(first, others)
+		}
+	`
+	expectedOutput := "1 2 [3, 4] 5 6 7\nonly []\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestMapDestructuring(t *testing.T) {
+	vm.InitVM([]string{"tulipscript"})
+	t.Cleanup(vm.FreeVM)
+
+	script := `
+		struct Person { name = "Ann"; age = 30 }
+		let {name, age = 0} = {"name": "Bob"}
+		println(name, age)
+		{
+			let {name: who, age, city = "Oslo"} = Person{}
+			println(who, age, city)
+		}
+		let {address: {street}} = {"address": {"street": "Main"}}
+		println(street)
+	`
+	expectedOutput := "Bob 0\nAnn 30 Oslo\nMain\n"
+
+	output := captureOutput(t, func() {
+		result := core.Interpret(script, "<script>")
+		if result != 0 {
+			t.Fatalf("Interpretation failed: %d", result)
+		}
+	})
+
+	if output != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output)
+	}
+}
+
+func TestDestructuringShapeMismatch(t *testing.T) {
+	expectRuntimeErrors(t, []errorCase{
+		{"too few elements", `let [a, b] = [1]`, "Cannot destructure an array of length 1; expected exactly 2 elements."},
+		{"too many elements", `let [a] = [1, 2]`, "Cannot destructure an array of length 2; expected exactly 1 elements."},
+		{"too few before rest", `let [a, b, ...rest] = [1]`, "Cannot destructure an array of length 1; expected at least 2 elements."},
+		{"array pattern on string", `let [a] = "text"`, "Cannot destructure string with an array pattern; expected an array."},
+		{"missing key", `let {missing} = {"present": 1}`, "Cannot destructure missing key 'missing' (add a default, e.g. '{missing = null}')."},
+		{"map pattern on number", `let {name} = 42`, "Cannot destructure number with a map pattern; expected a map or struct instance."},
+	})
+}
@@ -1,9 +1,12 @@
 package compiler
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/cryptrunner49/tulipscript/internal/common"
 	"github.com/cryptrunner49/tulipscript/internal/debug"
@@ -18,8 +21,9 @@ var compiledFiles = make(map[string]*runtime.ObjFunction)
 type FunctionType int
 
 const (
-	TYPE_FUNCTION FunctionType = iota // Regular function definition.
-	TYPE_SCRIPT                       // Top-level script execution.
+	TYPE_FUNCTION  FunctionType = iota // Regular function definition.
+	TYPE_SCRIPT                        // Top-level script execution.
+	TYPE_GENERATOR                     // Generator function ('function*').
 )
 
 // Parser holds the current and previous tokens and error flags for parsing.
@@ -90,6 +94,11 @@ const (
 	PREC_AND                          // Logical AND.
 	PREC_EQUALITY                     // Equality operators.
 	PREC_COMPARISON                   // Comparison operators.
+	PREC_RANGE                        // Ranges ('..', '..=').
+	PREC_BIT_OR                       // Bitwise OR.
+	PREC_BIT_XOR                      // Bitwise XOR.
+	PREC_BIT_AND                      // Bitwise AND.
+	PREC_SHIFT                        // Bit shifts.
 	PREC_TERM                         // Term operators (addition, subtraction).
 	PREC_FACTOR                       // Factor operators (multiplication, division).
 	PREC_UNARY                        // Unary operators.
@@ -129,10 +138,18 @@ func init() {
 	rules[token.TOKEN_STAR_STAR] = ParseRule{nil, binary, PREC_FACTOR}
 	rules[token.TOKEN_FLOOR] = ParseRule{nil, binary, PREC_FACTOR}
 	rules[token.TOKEN_PERCENT_PERCENT] = ParseRule{nil, binary, PREC_FACTOR}
-	rules[token.TOKEN_PIPE] = ParseRule{nil, nil, PREC_NONE}
+	rules[token.TOKEN_PIPE] = ParseRule{nil, binary, PREC_BIT_OR}
+	rules[token.TOKEN_CARET] = ParseRule{nil, binary, PREC_BIT_XOR}
+	rules[token.TOKEN_AMPERSAND] = ParseRule{nil, binary, PREC_BIT_AND}
+	rules[token.TOKEN_LESS_LESS] = ParseRule{nil, binary, PREC_SHIFT}
+	rules[token.TOKEN_GREATER_GREATER] = ParseRule{nil, binary, PREC_SHIFT}
+	rules[token.TOKEN_TILDE] = ParseRule{unary, nil, PREC_NONE}
+	rules[token.TOKEN_DOT_DOT] = ParseRule{nil, rangeExpression, PREC_RANGE}
+	rules[token.TOKEN_DOT_DOT_EQUAL] = ParseRule{nil, rangeExpression, PREC_RANGE}
+	rules[token.TOKEN_IN] = ParseRule{nil, binary, PREC_COMPARISON}
 	rules[token.TOKEN_QUESTION] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_AT] = ParseRule{nil, nil, PREC_NONE}
-	rules[token.TOKEN_HASH] = ParseRule{nil, nil, PREC_NONE}
+	rules[token.TOKEN_HASH] = ParseRule{setLiteral, nil, PREC_NONE}
 	rules[token.TOKEN_DOLLAR] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_COLON] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_BANG] = ParseRule{unary, instance, PREC_CALL}
@@ -149,6 +166,7 @@ func init() {
 	rules[token.TOKEN_CHAR] = ParseRule{charLiteral, nil, PREC_NONE}
 	rules[token.TOKEN_STRING] = ParseRule{stringLiteral, nil, PREC_NONE}
 	rules[token.TOKEN_NUMBER] = ParseRule{number, nil, PREC_NONE}
+	rules[token.TOKEN_REGEX] = ParseRule{regexLiteral, nil, PREC_NONE}
 	rules[token.TOKEN_AND] = ParseRule{nil, and, PREC_AND}
 	rules[token.TOKEN_CLASS] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_ELSE] = ParseRule{nil, nil, PREC_NONE}
@@ -178,13 +196,14 @@ func init() {
 	rules[token.TOKEN_DEF] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_MOD] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_AS] = ParseRule{nil, nil, PREC_NONE}
+	rules[token.TOKEN_YIELD] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_ERROR] = ParseRule{nil, nil, PREC_NONE}
 	rules[token.TOKEN_EOF] = ParseRule{nil, nil, PREC_NONE}
 }
 
 // dot handles property access on objects (e.g., object.field).
 func dot(canAssign bool) {
-	consume(token.TOKEN_IDENTIFIER, "Expected a property name after '.' (e.g., 'object.field').")
+	consumePropertyName("Expected a property name after '.' (e.g., 'object.field').")
 	name := identifierConstant(parser.previous)
 	if canAssign && match(token.TOKEN_EQUAL) {
 		expression()
@@ -273,6 +292,8 @@ func statement() {
 		continueStatement()
 	} else if match(token.TOKEN_RETURN) {
 		returnStatement()
+	} else if match(token.TOKEN_YIELD) {
+		yieldStatement()
 	} else if match(token.TOKEN_LEFT_BRACE) {
 		beginScope()
 		block()
@@ -310,8 +331,12 @@ func declaration() {
 
 // call compiles a function call by parsing the argument list and emitting the call opcode.
 func call(canAssign bool) {
-	argCount := argumentList()
-	emitBytes(byte(runtime.OP_CALL), argCount)
+	argCount, spread := argumentList()
+	if spread {
+		emitByte(byte(runtime.OP_CALL_SPREAD))
+	} else {
+		emitBytes(byte(runtime.OP_CALL), argCount)
+	}
 }
 
 // parsePrecedence compiles an expression based on a minimum precedence, handling operators accordingly.
@@ -408,6 +433,19 @@ func charLiteral(canAssign bool) {
 	emitConstant(runtime.Value{Type: runtime.VAL_OBJ, Obj: runtime.NewObjString(str)})
 }
 
+// regexLiteral compiles a regex literal such as /a+b/i into a regex constant, so the pattern is
+// compiled once and errors in it are reported at compile time.
+func regexLiteral(canAssign bool) {
+	text := parser.previous.Start
+	end := strings.LastIndexByte(text, '/')
+	re, err := runtime.NewRegex(text[1:end], text[end+1:])
+	if err != nil {
+		reportError(fmt.Sprintf("Invalid regular expression %s: %v", text, err))
+		return
+	}
+	emitConstant(runtime.ObjVal(re))
+}
+
 // makeConstant adds a constant value to the current chunk and returns its index.
 func makeConstant(val runtime.Value) uint8 {
 	constant := currentChunk().AddConstant(val)
@@ -430,12 +468,108 @@ func emitConstant(val runtime.Value) {
 
 // number compiles a numeric literal by parsing it and emitting the constant.
 func number(canAssign bool) {
-	val, err := strconv.ParseFloat(parser.previous.Start, 64)
-	if err != nil {
-		reportError(fmt.Sprintf("Invalid number literal '%s'; must be a valid number.", parser.previous.Start))
+	val, ok := numberLiteral()
+	if !ok {
 		return
 	}
-	emitConstant(runtime.Value{Type: runtime.VAL_NUMBER, Number: val})
+	emitConstant(val)
+}
+
+// numberLiteral converts the previous TOKEN_NUMBER into its value, reporting a compile
+// error and returning false when the literal is malformed.
+func numberLiteral() (runtime.Value, bool) {
+	val, err := parseNumberLiteral(parser.previous.Start)
+	if err != nil {
+		reportError(err.Error())
+		return runtime.Value{Type: runtime.VAL_NULL}, false
+	}
+	return val, true
+}
+
+// parseNumberLiteral parses the source text of a numeric literal. It understands decimal
+// numbers with optional fraction and exponent (1.5, 6.02e23, 1e-9), the prefixed integer
+// forms 0x, 0o and 0b, and '_' separators placed between two digits (1_000_000).
+// Literals without a fraction or exponent become 64-bit integers; prefixed literals may
+// use all 64 bits, so 0xFFFFFFFFFFFFFFFF is the bit pattern of -1. An 'n' suffix makes
+// an integer literal an arbitrary-precision BigInt (123n, 0xFFn).
+func parseNumberLiteral(text string) (runtime.Value, error) {
+	null := runtime.Value{Type: runtime.VAL_NULL}
+	body, isBig := strings.CutSuffix(text, "n")
+	base, kind, digits := 10, "number", body
+	if len(body) >= 2 && body[0] == '0' {
+		switch body[1] {
+		case 'x', 'X':
+			base, kind, digits = 16, "hexadecimal", body[2:]
+		case 'o', 'O':
+			base, kind, digits = 8, "octal", body[2:]
+		case 'b', 'B':
+			base, kind, digits = 2, "binary", body[2:]
+		}
+	}
+
+	for i := 0; i < len(digits); i++ {
+		if digits[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(digits)-1 || !isLiteralDigit(digits[i-1], base) || !isLiteralDigit(digits[i+1], base) {
+			return null, fmt.Errorf("Invalid %s literal '%s'; digit separator '_' must appear between two digits.", kind, text)
+		}
+	}
+	digits = strings.ReplaceAll(digits, "_", "")
+	if base != 10 && digits == "" {
+		return null, fmt.Errorf("Invalid %s literal '%s'; expected digits after the '%s' prefix.", kind, text, body[:2])
+	}
+
+	if isBig {
+		n, ok := new(big.Int).SetString(digits, base)
+		if !ok {
+			return null, fmt.Errorf("Invalid BigInt literal '%s'; must be an integer followed by 'n' (e.g., '123n').", text)
+		}
+		return runtime.ObjVal(runtime.NewBigInt(n)), nil
+	}
+
+	if base != 10 {
+		n, err := strconv.ParseUint(digits, base, 64)
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return null, fmt.Errorf("The %s literal '%s' does not fit in 64 bits; add an 'n' suffix for a BigInt.", kind, text)
+			}
+			return null, fmt.Errorf("Invalid %s literal '%s'; contains a digit not allowed in base %d.", kind, text, base)
+		}
+		return runtime.IntVal(int64(n)), nil
+	}
+
+	if !strings.ContainsAny(digits, ".eE") {
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err == nil {
+			return runtime.IntVal(n), nil
+		}
+		if errors.Is(err, strconv.ErrRange) {
+			return null, fmt.Errorf("The integer literal '%s' does not fit in 64 bits; write it as a BigInt ('%sn') or a float ('%s.0').", text, text, text)
+		}
+		return null, fmt.Errorf("Invalid number literal '%s'; must be a valid number.", text)
+	}
+
+	val, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return null, fmt.Errorf("The number literal '%s' is out of range.", text)
+		}
+		return null, fmt.Errorf("Invalid number literal '%s'; must be a valid number.", text)
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: val}, nil
+}
+
+// isLiteralDigit reports whether c is a valid digit in the given base.
+func isLiteralDigit(c byte, base int) bool {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c-'0') < base
+	case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		return base == 16
+	default:
+		return false
+	}
 }
 
 // unary compiles a unary operator expression (handles prefix ++x and --x).
@@ -446,6 +580,8 @@ func unary(canAssign bool) {
 	switch operatorType {
 	case token.TOKEN_MINUS:
 		emitByte(byte(runtime.OP_NEGATE))
+	case token.TOKEN_TILDE:
+		emitByte(byte(runtime.OP_BIT_NOT))
 	case token.TOKEN_BANG:
 		emitByte(byte(runtime.OP_NOT))
 	case token.TOKEN_PLUS_PLUS:
@@ -477,11 +613,11 @@ func unary(canAssign bool) {
 		}
 
 		// Prefix ++x: Load, increment, store, leave new value on stack
-		emitByte(byte(runtime.OP_POP))                                   // Remove old value from stack
-		emitBytes(getOp, uint8(arg))                                     // Load variable value
-		emitConstant(runtime.Value{Type: runtime.VAL_NUMBER, Number: 1}) // Push 1
-		emitByte(byte(runtime.OP_ADD))                                   // Increment
-		emitBytes(setOp, uint8(arg))                                     // Store back to variable
+		emitByte(byte(runtime.OP_POP))  // Remove old value from stack
+		emitBytes(getOp, uint8(arg))    // Load variable value
+		emitConstant(runtime.IntVal(1)) // Push 1
+		emitByte(byte(runtime.OP_ADD))  // Increment
+		emitBytes(setOp, uint8(arg))    // Store back to variable
 	case token.TOKEN_MINUS_MINUS:
 		// Ensure the operand is a variable (identifier)
 		if parser.previous.Type != token.TOKEN_IDENTIFIER {
@@ -511,12 +647,29 @@ func unary(canAssign bool) {
 		}
 
 		// Prefix --x: Load, decrement, store, leave new value on stack
-		emitByte(byte(runtime.OP_POP))                                   // Remove old value from stack
-		emitBytes(getOp, uint8(arg))                                     // Load variable value
-		emitConstant(runtime.Value{Type: runtime.VAL_NUMBER, Number: 1}) // Push 1
-		emitByte(byte(runtime.OP_SUBTRACT))                              // Decrement
-		emitBytes(setOp, uint8(arg))                                     // Store back to variable
+		emitByte(byte(runtime.OP_POP))      // Remove old value from stack
+		emitBytes(getOp, uint8(arg))        // Load variable value
+		emitConstant(runtime.IntVal(1))     // Push 1
+		emitByte(byte(runtime.OP_SUBTRACT)) // Decrement
+		emitBytes(setOp, uint8(arg))        // Store back to variable
+	}
+}
+
+// rangeExpression compiles 'start..end' or 'start..=end', followed by an optional 'step n'. The
+// start value is already on the stack.
+func rangeExpression(canAssign bool) {
+	flags := byte(0)
+	if parser.previous.Type == token.TOKEN_DOT_DOT_EQUAL {
+		flags |= runtime.RANGE_INCLUSIVE
+	}
+	parsePrecedence(PREC_RANGE + 1)
+	// 'step' is only a keyword right after a range, so it stays usable as a variable name.
+	if check(token.TOKEN_IDENTIFIER) && parser.current.Start == "step" {
+		advance()
+		parsePrecedence(PREC_RANGE + 1)
+		flags |= runtime.RANGE_HAS_STEP
 	}
+	emitBytes(byte(runtime.OP_RANGE), flags)
 }
 
 // binary compiles a binary operator expression.
@@ -541,6 +694,16 @@ func binary(canAssign bool) {
 		emitByte(byte(runtime.OP_FLOOR))
 	case token.TOKEN_PERCENT_PERCENT:
 		emitByte(byte(runtime.OP_PERCENT))
+	case token.TOKEN_AMPERSAND:
+		emitByte(byte(runtime.OP_BIT_AND))
+	case token.TOKEN_PIPE:
+		emitByte(byte(runtime.OP_BIT_OR))
+	case token.TOKEN_CARET:
+		emitByte(byte(runtime.OP_BIT_XOR))
+	case token.TOKEN_LESS_LESS:
+		emitByte(byte(runtime.OP_SHIFT_LEFT))
+	case token.TOKEN_GREATER_GREATER:
+		emitByte(byte(runtime.OP_SHIFT_RIGHT))
 	case token.TOKEN_BANG_EQUAL:
 		emitBytes(byte(runtime.OP_EQUAL), byte(runtime.OP_NOT))
 	case token.TOKEN_EQUAL_EQUAL:
@@ -553,6 +716,8 @@ func binary(canAssign bool) {
 		emitByte(byte(runtime.OP_LESS))
 	case token.TOKEN_LESS_EQUAL:
 		emitBytes(byte(runtime.OP_GREATER), byte(runtime.OP_NOT))
+	case token.TOKEN_IN:
+		emitByte(byte(runtime.OP_IN))
 	}
 }
 
@@ -661,7 +826,7 @@ func namedVariable(name token.Token, canAssign bool) {
 		// the incremented value, leaving the original value on the stack.
 		emitBytes(getOp, uint8(arg))
 		emitByte(byte(runtime.OP_DUP))
-		emitConstant(runtime.Value{Type: runtime.VAL_NUMBER, Number: 1})
+		emitConstant(runtime.IntVal(1))
 		emitByte(byte(runtime.OP_ADD))
 		emitBytes(setOp, uint8(arg))
 		emitByte(byte(runtime.OP_POP))
@@ -675,7 +840,7 @@ func namedVariable(name token.Token, canAssign bool) {
 		// the decremented value, leaving the original value on the stack.
 		emitBytes(getOp, uint8(arg))
 		emitByte(byte(runtime.OP_DUP))
-		emitConstant(runtime.Value{Type: runtime.VAL_NUMBER, Number: 1})
+		emitConstant(runtime.IntVal(1))
 		emitByte(byte(runtime.OP_SUBTRACT))
 		emitBytes(setOp, uint8(arg))
 		emitByte(byte(runtime.OP_POP))
@@ -706,6 +871,7 @@ func initCompiler(compiler *Compiler, funcType FunctionType, scriptDir string) {
 	if funcType != TYPE_SCRIPT {
 		current.function.Name = runtime.CopyString(parser.previous.Start)
 	}
+	current.function.IsGenerator = funcType == TYPE_GENERATOR
 	current.localCount++
 	local := &current.locals[current.localCount-1]
 	local.depth = 0
@@ -3,7 +3,6 @@ package compiler
 import (
 	"fmt"
 	"path/filepath"
-	"strconv"
 
 	"github.com/cryptrunner49/tulipscript/internal/runtime"
 	"github.com/cryptrunner49/tulipscript/internal/token"
@@ -16,7 +15,12 @@ func declareVariable() {
 		return
 	}
 
-	name := parser.previous
+	declareLocal(parser.previous)
+}
+
+// declareLocal adds a local variable with the given name, reporting an error if the current scope
+// already declares it.
+func declareLocal(name token.Token) {
 	for i := current.localCount - 1; i >= 0; i-- {
 		local := current.locals[i]
 		if local.depth != -1 && local.depth < current.scopeDepth {
@@ -38,14 +42,27 @@ func defineConstVariable(global uint8) {
 	}
 }
 
+// functionKind reads the optional '*' after 'function' that declares a generator.
+func functionKind() FunctionType {
+	if match(token.TOKEN_STAR) {
+		return TYPE_GENERATOR
+	}
+	return TYPE_FUNCTION
+}
+
 func fnDeclaration() {
+	funcType := functionKind()
 	global := parseVariable("Expected a function name after 'function' (e.g., 'fn myFunc()').")
 	markInitialized()
-	function(TYPE_FUNCTION)
+	function(funcType)
 	defineVariable(global)
 }
 
 func varDeclaration() {
+	if isPatternStart() {
+		patternDeclaration(false)
+		return
+	}
 	global := parseVariable("Expected a variable name after 'var' (e.g., 'var x').")
 	if match(token.TOKEN_EQUAL) {
 		expression()
@@ -75,8 +92,8 @@ func structDeclaration() {
 				var defaultValue runtime.Value
 				if match(token.TOKEN_EQUAL) {
 					if match(token.TOKEN_NUMBER) {
-						val, _ := strconv.ParseFloat(parser.previous.Start, 64)
-						defaultValue = runtime.Value{Type: runtime.VAL_NUMBER, Number: val}
+						val, _ := numberLiteral()
+						defaultValue = val
 					} else if match(token.TOKEN_STRING) {
 						text := parser.previous.Start
 						str := text[1 : len(text)-1]
@@ -93,9 +110,9 @@ func structDeclaration() {
 						if !check(token.TOKEN_RIGHT_BRACKET) {
 							for {
 								if match(token.TOKEN_NUMBER) {
-									val, _ := strconv.ParseFloat(parser.previous.Start, 64)
-									elements = append(elements, runtime.Value{Type: runtime.VAL_NUMBER, Number: val})
-									emitConstant(runtime.Value{Type: runtime.VAL_NUMBER, Number: val})
+									val, _ := numberLiteral()
+									elements = append(elements, val)
+									emitConstant(val)
 								} else if match(token.TOKEN_STRING) {
 									text := parser.previous.Start
 									str := text[1 : len(text)-1]
@@ -128,7 +145,7 @@ func structDeclaration() {
 						emitBytes(byte(runtime.OP_ARRAY), byte(len(elements)))
 					} else if match(token.TOKEN_LEFT_BRACE) {
 						// Parse map literal and collect key-value pairs
-						pairs := make(map[*runtime.ObjString]runtime.Value)
+						pairs := runtime.NewMap()
 						for !check(token.TOKEN_RIGHT_BRACE) && !check(token.TOKEN_EOF) {
 							var key *runtime.ObjString
 							if match(token.TOKEN_STRING) {
@@ -144,8 +161,8 @@ func structDeclaration() {
 							consume(token.TOKEN_COLON, "Expected ':' after map key.")
 							var value runtime.Value
 							if match(token.TOKEN_NUMBER) {
-								val, _ := strconv.ParseFloat(parser.previous.Start, 64)
-								value = runtime.Value{Type: runtime.VAL_NUMBER, Number: val}
+								val, _ := numberLiteral()
+								value = val
 								emitConstant(value)
 							} else if match(token.TOKEN_STRING) {
 								text := parser.previous.Start
@@ -167,7 +184,7 @@ func structDeclaration() {
 								value = runtime.Value{Type: runtime.VAL_NULL}
 								expression() // Consume invalid expression
 							}
-							pairs[key] = value
+							pairs.Set(runtime.ObjVal(key), value)
 							if !match(token.TOKEN_COMMA) {
 								break
 							}
@@ -175,12 +192,8 @@ func structDeclaration() {
 						consume(token.TOKEN_RIGHT_BRACE, "Expected '}' after map literal.")
 
 						// Create ObjMap and emit OP_MAP
-						objMap := runtime.NewMap()
-						for k, v := range pairs {
-							objMap.Entries[k] = v
-						}
-						defaultValue = runtime.Value{Type: runtime.VAL_OBJ, Obj: objMap}
-						emitBytes(byte(runtime.OP_MAP), byte(len(pairs)))
+						defaultValue = runtime.Value{Type: runtime.VAL_OBJ, Obj: pairs}
+						emitBytes(byte(runtime.OP_MAP), byte(pairs.Len()))
 					} else {
 						reportError("Expected a literal value (number, string, true, false, null, array, or map) for field default.")
 						defaultValue = runtime.Value{Type: runtime.VAL_NULL}
@@ -215,27 +228,15 @@ func structDeclaration() {
 	defineVariable(nameConstant)
 }
 
-func compileModuleFunction() runtime.Value {
+func compileModuleFunction(funcType FunctionType) runtime.Value {
 	var fnCompiler Compiler
 
 	// Set up a new compiler instance for the module function, initializing it with the function type
 	// and script directory.
-	initCompiler(&fnCompiler, TYPE_FUNCTION, current.scriptDir)
+	initCompiler(&fnCompiler, funcType, current.scriptDir)
 	beginScope()
 	consume(token.TOKEN_LEFT_PAREN, "Expected '(' after function name to start parameter list.")
-	if !check(token.TOKEN_RIGHT_PAREN) {
-		for {
-			current.function.Arity++
-			if current.function.Arity > 255 {
-				errorAtCurrent("Function cannot have more than 255 parameters.")
-			}
-			paramConstant := parseVariable("Expected a parameter name (e.g., 'x' in 'fn foo(x)').")
-			defineVariable(paramConstant)
-			if !match(token.TOKEN_COMMA) {
-				break
-			}
-		}
-	}
+	parameterList()
 	consume(token.TOKEN_RIGHT_PAREN, "Expected ')' to close parameter list.")
 	consume(token.TOKEN_LEFT_BRACE, "Expected '{' to start function body.")
 	block()
@@ -282,8 +283,8 @@ func modDeclarationField() (*runtime.ObjString, runtime.Value) {
 			var defVal runtime.Value
 			if match(token.TOKEN_EQUAL) {
 				if match(token.TOKEN_NUMBER) {
-					val, _ := strconv.ParseFloat(parser.previous.Start, 64)
-					defVal = runtime.Value{Type: runtime.VAL_NUMBER, Number: val}
+					val, _ := numberLiteral()
+					defVal = val
 				} else if match(token.TOKEN_STRING) {
 					text := parser.previous.Start
 					str := text[1 : len(text)-1]
@@ -305,10 +306,11 @@ func modDeclarationField() (*runtime.ObjString, runtime.Value) {
 			nestedFieldNames = append(nestedFieldNames, fName)
 			nestedFieldDefaults = append(nestedFieldDefaults, defVal)
 		} else if match(token.TOKEN_FN) {
+			funcType := functionKind()
 			consume(token.TOKEN_IDENTIFIER, "Expected function name in nested module.")
 			fName := runtime.NewObjString(parser.previous.Start)
 			markInitialized()
-			fnCVal := compileModuleFunction()
+			fnCVal := compileModuleFunction(funcType)
 			// Optionally consume a semicolon.
 			match(token.TOKEN_SEMICOLON)
 			nestedFieldNames = append(nestedFieldNames, fName)
@@ -422,8 +424,8 @@ func modDeclaration() {
 			var defVal runtime.Value
 			if match(token.TOKEN_EQUAL) {
 				if match(token.TOKEN_NUMBER) {
-					val, _ := strconv.ParseFloat(parser.previous.Start, 64)
-					defVal = runtime.Value{Type: runtime.VAL_NUMBER, Number: val}
+					val, _ := numberLiteral()
+					defVal = val
 				} else if match(token.TOKEN_STRING) {
 					text := parser.previous.Start
 					str := text[1 : len(text)-1]
@@ -440,9 +442,9 @@ func modDeclaration() {
 					if !check(token.TOKEN_RIGHT_BRACKET) {
 						for {
 							if match(token.TOKEN_NUMBER) {
-								val, _ := strconv.ParseFloat(parser.previous.Start, 64)
-								elements = append(elements, runtime.Value{Type: runtime.VAL_NUMBER, Number: val})
-								emitConstant(runtime.Value{Type: runtime.VAL_NUMBER, Number: val})
+								val, _ := numberLiteral()
+								elements = append(elements, val)
+								emitConstant(val)
 							} else if match(token.TOKEN_STRING) {
 								text := parser.previous.Start
 								str := text[1 : len(text)-1]
@@ -475,7 +477,7 @@ func modDeclaration() {
 					emitBytes(byte(runtime.OP_ARRAY), byte(len(elements)))
 				} else if match(token.TOKEN_LEFT_BRACE) {
 					// Parse map literal and collect key-value pairs
-					pairs := make(map[*runtime.ObjString]runtime.Value)
+					pairs := runtime.NewMap()
 					for !check(token.TOKEN_RIGHT_BRACE) && !check(token.TOKEN_EOF) {
 						var key *runtime.ObjString
 						if match(token.TOKEN_STRING) {
@@ -491,8 +493,8 @@ func modDeclaration() {
 						consume(token.TOKEN_COLON, "Expected ':' after map key.")
 						var value runtime.Value
 						if match(token.TOKEN_NUMBER) {
-							val, _ := strconv.ParseFloat(parser.previous.Start, 64)
-							value = runtime.Value{Type: runtime.VAL_NUMBER, Number: val}
+							val, _ := numberLiteral()
+							value = val
 							emitConstant(value)
 						} else if match(token.TOKEN_STRING) {
 							text := parser.previous.Start
@@ -514,19 +516,15 @@ func modDeclaration() {
 							value = runtime.Value{Type: runtime.VAL_NULL}
 							expression() // Consume invalid expression
 						}
-						pairs[key] = value
+						pairs.Set(runtime.ObjVal(key), value)
 						if !match(token.TOKEN_COMMA) {
 							break
 						}
 					}
 					consume(token.TOKEN_RIGHT_BRACE, "Expected '}' after map literal.")
 					// Create ObjMap and emit OP_MAP
-					objMap := runtime.NewMap()
-					for k, v := range pairs {
-						objMap.Entries[k] = v
-					}
-					defVal = runtime.Value{Type: runtime.VAL_OBJ, Obj: objMap}
-					emitBytes(byte(runtime.OP_MAP), byte(len(pairs)))
+					defVal = runtime.Value{Type: runtime.VAL_OBJ, Obj: pairs}
+					emitBytes(byte(runtime.OP_MAP), byte(pairs.Len()))
 				} else {
 					reportError("Expected a literal value (number, string, true, false, null, array, or map) for variable initializer in module.")
 					defVal = runtime.Value{Type: runtime.VAL_NULL}
@@ -538,10 +536,11 @@ func modDeclaration() {
 			fieldNames = append(fieldNames, fName)
 			fieldDefaults = append(fieldDefaults, defVal)
 		} else if match(token.TOKEN_FN) {
+			funcType := functionKind()
 			consume(token.TOKEN_IDENTIFIER, "Expected function name in module declaration.")
 			fName := runtime.NewObjString(parser.previous.Start)
 			markInitialized()
-			fnCVal := compileModuleFunction()
+			fnCVal := compileModuleFunction(funcType)
 			match(token.TOKEN_SEMICOLON)
 			fieldNames = append(fieldNames, fName)
 			fieldDefaults = append(fieldDefaults, fnCVal)
@@ -616,9 +615,8 @@ func useDeclaration() {
 
 	// Parse function declarations until '}'
 	for !check(token.TOKEN_RIGHT_BRACE) && !check(token.TOKEN_EOF) {
-		// Parse return type (e.g., "int", "bool", "size_t")
-		consume(token.TOKEN_IDENTIFIER, "Expected return type before function name.")
-		returnType := parser.previous.Start
+		// Parse return type (e.g., "int", "bool", "size_t", "char*")
+		returnType := externType("Expected return type before function name.")
 		returnTypeConstant := makeConstant(runtime.Value{Type: runtime.VAL_OBJ, Obj: runtime.NewObjString(returnType)})
 
 		// Parse function name
@@ -630,11 +628,9 @@ func useDeclaration() {
 		consume(token.TOKEN_LEFT_PAREN, "Expected '(' after function name.")
 		var paramTypes []string
 		if !check(token.TOKEN_RIGHT_PAREN) {
-			consume(token.TOKEN_IDENTIFIER, "Expected parameter type.")
-			paramTypes = append(paramTypes, parser.previous.Start)
+			paramTypes = append(paramTypes, externType("Expected parameter type."))
 			for match(token.TOKEN_COMMA) {
-				consume(token.TOKEN_IDENTIFIER, "Expected parameter type after ','.")
-				paramTypes = append(paramTypes, parser.previous.Start)
+				paramTypes = append(paramTypes, externType("Expected parameter type after ','."))
 			}
 		}
 		consume(token.TOKEN_RIGHT_PAREN, "Expected ')' after parameters.")
@@ -660,7 +656,22 @@ func useDeclaration() {
 	consumeOptionalSemicolon()
 }
 
+// externType parses a C type in a 'use' declaration: a type name, followed by '*' for a pointer
+// type such as "char*" or "void*".
+func externType(message string) string {
+	consume(token.TOKEN_IDENTIFIER, message)
+	cType := parser.previous.Start
+	if match(token.TOKEN_STAR) {
+		cType += "*"
+	}
+	return cType
+}
+
 func constDeclaration() {
+	if isPatternStart() {
+		patternDeclaration(true)
+		return
+	}
 	global := parseVariable("Expected a constant name after 'const' (e.g., 'const x = 5;').")
 	// Require an initializer
 	if !match(token.TOKEN_EQUAL) {
@@ -0,0 +1,192 @@
+package compiler
+
+import (
+	"github.com/cryptrunner49/tulipscript/internal/lexer"
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+	"github.com/cryptrunner49/tulipscript/internal/token"
+)
+
+// sourcePosition is a snapshot of the scanner and parser, used to compile a pattern after the code
+// that produces the value it destructures (e.g., 'let [a, b] = pair' compiles 'pair' first).
+type sourcePosition struct {
+	lexer    lexer.State
+	current  token.Token
+	previous token.Token
+}
+
+// savePosition records the current scanner and parser position.
+func savePosition() sourcePosition {
+	return sourcePosition{lexer: lexer.SaveState(), current: parser.current, previous: parser.previous}
+}
+
+// restorePosition moves the scanner and parser back (or forward) to a saved position.
+func restorePosition(position sourcePosition) {
+	lexer.RestoreState(position.lexer)
+	parser.current = position.current
+	parser.previous = position.previous
+}
+
+// isPatternStart reports whether the next token opens an array or map destructuring pattern.
+func isPatternStart() bool {
+	return check(token.TOKEN_LEFT_BRACKET) || check(token.TOKEN_LEFT_BRACE)
+}
+
+// skipPattern advances past a bracketed pattern without compiling it, so it can be compiled later
+// from a saved position.
+func skipPattern() {
+	depth := 0
+	for !check(token.TOKEN_EOF) {
+		switch parser.current.Type {
+		case token.TOKEN_LEFT_BRACKET, token.TOKEN_LEFT_BRACE, token.TOKEN_LEFT_PAREN:
+			depth++
+		case token.TOKEN_RIGHT_BRACKET, token.TOKEN_RIGHT_BRACE, token.TOKEN_RIGHT_PAREN:
+			depth--
+		}
+		advance()
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// patternDeclaration compiles 'let <pattern> = value' and 'const <pattern> = value'.
+func patternDeclaration(isConst bool) {
+	pattern := savePosition()
+	skipPattern()
+	if !match(token.TOKEN_EQUAL) {
+		reportError("Destructuring declaration must include an initializer (e.g., 'let [a, b] = pair').")
+		return
+	}
+	expression()
+
+	resume := savePosition()
+	restorePosition(pattern)
+	destructureValue(isConst)
+	restorePosition(resume)
+	consumeOptionalSemicolon()
+}
+
+// destructureValue binds the pattern at the current token against the value on top of the stack.
+// Inside a scope the value stays behind as a hidden local; at global scope it is popped at the end.
+func destructureValue(isConst bool) {
+	if current.scopeDepth > 0 {
+		slot := declareTemporary()
+		destructurePattern(func() { emitBytes(byte(runtime.OP_GET_LOCAL), slot) }, isConst)
+		return
+	}
+	destructurePattern(func() { emitByte(byte(runtime.OP_DUP)) }, isConst)
+	emitByte(byte(runtime.OP_POP))
+}
+
+// destructurePattern compiles an array or map pattern. source emits code that pushes the value
+// being destructured; it is called once per element so each binding starts from a fresh copy.
+func destructurePattern(source func(), isConst bool) {
+	if match(token.TOKEN_LEFT_BRACKET) {
+		arrayPattern(source, isConst)
+	} else {
+		consume(token.TOKEN_LEFT_BRACE, "Expected '[' or '{' to start a destructuring pattern.")
+		mapPattern(source, isConst)
+	}
+}
+
+// arrayPattern compiles '[a, b, ...rest]'. The shape check is emitted first and its element count
+// is patched in once the whole pattern has been parsed.
+func arrayPattern(source func(), isConst bool) {
+	source()
+	emitByte(byte(runtime.OP_DESTRUCTURE_ARRAY))
+	shapeOperand := currentChunk().Count()
+	emitBytes(0, 0)
+
+	count := 0
+	hasRest := false
+	if !check(token.TOKEN_RIGHT_BRACKET) {
+		for {
+			if match(token.TOKEN_DOT_DOT_DOT) {
+				source()
+				emitBytes(byte(runtime.OP_DESTRUCTURE_REST), byte(count))
+				bindPatternTarget(isConst)
+				hasRest = true
+				if !check(token.TOKEN_RIGHT_BRACKET) {
+					errorAtCurrent("A rest element must be the last element of an array pattern.")
+				}
+				break
+			}
+			if count == 255 {
+				reportError("Array pattern cannot have more than 255 elements.")
+			}
+			source()
+			emitBytes(byte(runtime.OP_DESTRUCTURE_INDEX), byte(count))
+			bindPatternTarget(isConst)
+			count++
+			if !match(token.TOKEN_COMMA) {
+				break
+			}
+		}
+	}
+	consume(token.TOKEN_RIGHT_BRACKET, "Expected ']' to close array pattern.")
+
+	currentChunk().Code()[shapeOperand] = byte(count)
+	if hasRest {
+		currentChunk().Code()[shapeOperand+1] = 1
+	}
+}
+
+// mapPattern compiles '{name, age = 0, address: {city}, id: userId}' against a map or instance.
+func mapPattern(source func(), isConst bool) {
+	if !check(token.TOKEN_RIGHT_BRACE) {
+		for {
+			consume(token.TOKEN_IDENTIFIER, "Expected a key name in map pattern (e.g., 'let {name} = person').")
+			key := parser.previous
+			source()
+			emitBytes(byte(runtime.OP_DESTRUCTURE_KEY), identifierConstant(key))
+			// A zero offset tells the VM there is no default to fall back on.
+			defaultJump := currentChunk().Count()
+			emitBytes(0, 0)
+
+			if match(token.TOKEN_COLON) && isPatternStart() {
+				bindPatternTarget(isConst)
+			} else {
+				name := key
+				if parser.previous.Type == token.TOKEN_COLON {
+					consume(token.TOKEN_IDENTIFIER, "Expected a variable name or pattern after ':' in map pattern.")
+					name = parser.previous
+				}
+				if match(token.TOKEN_EQUAL) {
+					expression()
+					patchJump(defaultJump)
+				}
+				definePatternVariable(name, isConst)
+			}
+			if !match(token.TOKEN_COMMA) {
+				break
+			}
+		}
+	}
+	consume(token.TOKEN_RIGHT_BRACE, "Expected '}' to close map pattern.")
+}
+
+// bindPatternTarget binds the value on top of the stack to the next pattern element: either a
+// variable name or a nested pattern.
+func bindPatternTarget(isConst bool) {
+	if isPatternStart() {
+		destructureValue(isConst)
+		return
+	}
+	consume(token.TOKEN_IDENTIFIER, "Expected a variable name or nested pattern in destructuring pattern.")
+	definePatternVariable(parser.previous, isConst)
+}
+
+// definePatternVariable defines name from the value on top of the stack.
+func definePatternVariable(name token.Token, isConst bool) {
+	var global uint8
+	if current.scopeDepth > 0 {
+		declareLocal(name)
+	} else {
+		global = identifierConstant(name)
+	}
+	if isConst {
+		defineConstVariable(global)
+	} else {
+		defineVariable(global)
+	}
+}
@@ -1,8 +1,6 @@
 package compiler
 
 import (
-	"fmt"
-
 	"github.com/cryptrunner49/tulipscript/internal/runtime"
 	"github.com/cryptrunner49/tulipscript/internal/token"
 )
@@ -272,12 +270,30 @@ func returnStatement() {
 	if match(token.TOKEN_SEMICOLON) {
 		emitReturn()
 	} else {
+		if current.functionType == TYPE_GENERATOR {
+			reportError("Cannot return a value from a generator; use 'yield' to produce values.")
+		}
 		expression()
 		consumeOptionalSemicolon()
 		emitByte(byte(runtime.OP_RETURN))
 	}
 }
 
+// yieldStatement compiles 'yield value', which hands value to whoever resumed the generator and
+// suspends it until the next value is requested.
+func yieldStatement() {
+	if current.functionType != TYPE_GENERATOR {
+		reportError("Cannot use 'yield' outside a generator function (declare one with 'function*').")
+	}
+	if check(token.TOKEN_SEMICOLON) || check(token.TOKEN_RIGHT_BRACE) {
+		emitByte(byte(runtime.OP_NULL))
+	} else {
+		expression()
+	}
+	consumeOptionalSemicolon()
+	emitByte(byte(runtime.OP_YIELD))
+}
+
 // declareTemporary reserves a temporary local variable with a dummy name.
 // It returns the slot number of the temporary local.
 func declareTemporary() uint8 {
@@ -292,6 +308,8 @@ func iterVarDeclaration() {
 	consume(token.TOKEN_IDENTIFIER, "Expected iterator variable name.")
 	// Declare the variable in the current scope.
 	declareVariable()
+	// Give it a stack slot holding null until the first iteration assigns it.
+	emitByte(byte(runtime.OP_NULL))
 	// Mark it as initialized.
 	markInitialized()
 }
@@ -308,8 +326,19 @@ func iterStatement() {
 		reportError("Expected 'var' after '(' in iter statement.")
 	}
 
-	// Declare the iterator variable (e.g., 'item') and get its slot in the local scope.
-	iterVarDeclaration()
+	// Declare the iterator variable (e.g., 'item') and get its slot in the local scope. A
+	// destructuring pattern (e.g., '[k, v]') gets a hidden slot instead and is compiled at the
+	// start of each iteration.
+	var pattern *sourcePosition
+	if isPatternStart() {
+		position := savePosition()
+		pattern = &position
+		skipPattern()
+		emitByte(byte(runtime.OP_NULL))
+		declareTemporary()
+	} else {
+		iterVarDeclaration()
+	}
 	iterVarSlot := uint8(current.localCount - 1)
 
 	// Expect 'in' to separate the variable from the iterable expression.
@@ -321,58 +350,47 @@ func iterStatement() {
 	// Expect ')' to close the iterator declaration.
 	consume(token.TOKEN_RIGHT_PAREN, "Expected ')' after iterable expression.")
 
-	// Store the iterable in a temporary local variable to persist across iterations.
-	arraySlot := declareTemporary()                  // Slot for the array (typically slot 2).
-	emitBytes(byte(runtime.OP_SET_LOCAL), arraySlot) // Assign iterable to local slot.
-	emitByte(byte(runtime.OP_POP))                   // Remove iterable from stack.
-
-	// Generate a unique global name for the iterator 'it' (e.g., "__iter_5").
-	uniqueIteratorName := fmt.Sprintf("__iter_%d", parser.previous.Line)
-	iteratorNameToken := token.Token{Start: uniqueIteratorName, Length: len(uniqueIteratorName), Line: parser.previous.Line}
-	iteratorConstant := identifierConstant(iteratorNameToken)
+	// Turn the iterable into an iterator. An array pattern (e.g., '[k, v]') asks maps for
+	// [key, value] pairs instead of keys.
+	pairs := byte(0)
+	if pattern != nil && pattern.current.Type == token.TOKEN_LEFT_BRACKET {
+		pairs = 1
+	}
+	emitBytes(byte(runtime.OP_ITER), pairs)
 
-	// Initialize the iterator by calling array_iter(iterable) and store in global 'it'.
-	constantIndex := identifierConstant(token.Token{Start: "array_iter", Length: len("array_iter"), Line: parser.previous.Line})
-	emitBytes(byte(runtime.OP_GET_GLOBAL), constantIndex)       // Push array_iter function.
-	emitBytes(byte(runtime.OP_GET_LOCAL), arraySlot)            // Push the iterable.
-	emitBytes(byte(runtime.OP_CALL), 1)                         // Call array_iter, returns iterator.
-	emitBytes(byte(runtime.OP_DEFINE_GLOBAL), iteratorConstant) // Define global 'it'.
+	// Keep the iterator in a hidden local, so every call frame (recursive calls included) and
+	// every nested loop has its own iteration state.
+	iteratorSlot := declareTemporary()
 
 	// Mark the start of the iteration loop.
 	loopStart := currentChunk().Count()
 
-	// Condition: Check if the iterator is done using iter_done(it).
-	constantIndex = identifierConstant(token.Token{Start: "iter_done", Length: len("iter_done"), Line: parser.previous.Line})
-	emitBytes(byte(runtime.OP_GET_GLOBAL), constantIndex)    // Push iter_done function.
-	emitBytes(byte(runtime.OP_GET_GLOBAL), iteratorConstant) // Push global 'it'.
-	emitBytes(byte(runtime.OP_CALL), 1)                      // Call iter_done, returns bool.
-	exitJump := emitJump(byte(runtime.OP_JUMP_IF_TRUE))      // Jump to end if true (done).
-	emitByte(byte(runtime.OP_POP))                           // Pop false result.
-
-	// Get the current value from the iterator using iter_value(it).
-	constantIndex = identifierConstant(token.Token{Start: "iter_value", Length: len("iter_value"), Line: parser.previous.Line})
-	emitBytes(byte(runtime.OP_GET_GLOBAL), constantIndex)    // Push iter_value function.
-	emitBytes(byte(runtime.OP_GET_GLOBAL), iteratorConstant) // Push global 'it'.
-	emitBytes(byte(runtime.OP_CALL), 1)                      // Call iter_value, returns value.
-	emitBytes(byte(runtime.OP_SET_LOCAL), iterVarSlot)       // Assign value to 'item'.
-
-	// Compile the loop body (e.g., { print item; }).
-	statement()
-
-	// Advance the iterator to the next element using iter_next(it).
-	constantIndex = identifierConstant(token.Token{Start: "iter_next", Length: len("iter_next"), Line: parser.previous.Line})
-	emitBytes(byte(runtime.OP_GET_GLOBAL), constantIndex)
-	emitBytes(byte(runtime.OP_GET_GLOBAL), iteratorConstant)
-	emitBytes(byte(runtime.OP_CALL), 1)
-	emitByte(byte(runtime.OP_POP))
+	// Fetch the next value from the iterator, leaving the loop once it is exhausted.
+	emitBytes(byte(runtime.OP_GET_LOCAL), iteratorSlot) // Push the iterator.
+	exitJump := emitJump(byte(runtime.OP_ITER_NEXT))    // Push next value or exit.
+	emitBytes(byte(runtime.OP_SET_LOCAL), iterVarSlot)  // Assign value to 'item'.
+	emitByte(byte(runtime.OP_POP))                      // Remove value from stack.
+
+	// Compile the loop body (e.g., { print item; }). A destructured iterator variable binds its
+	// names in a scope wrapping the body, so they are popped at the end of every iteration.
+	if pattern != nil {
+		beginScope()
+		resume := savePosition()
+		restorePosition(*pattern)
+		destructurePattern(func() { emitBytes(byte(runtime.OP_GET_LOCAL), iterVarSlot) }, false)
+		restorePosition(resume)
+		statement()
+		endScope()
+	} else {
+		statement()
+	}
 
-	// Loop back to the condition check.
+	// Loop back to fetch the next value.
 	emitLoop(loopStart)
 
-	// Patch the exit jump to point here when iter_done returns true.
+	// Patch the exit jump to point here once the iterator is exhausted.
 	patchJump(exitJump)
 
-	// Cleanup: Adjust locals for scope exit.
-	current.localCount -= 2
+	// Cleanup: pop the iterator and the iterator variable.
 	endScope()
 }
@@ -3,6 +3,8 @@ package compiler
 import (
 	"fmt"
 	"os"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/cryptrunner49/tulipscript/internal/lexer"
 	"github.com/cryptrunner49/tulipscript/internal/runtime"
@@ -65,6 +67,22 @@ func consume(typ token.TokenType, message string) {
 	errorAtCurrent(message)
 }
 
+// consumePropertyName expects a property name and advances, or reports an error. Keywords are
+// accepted too, since a name after '.' cannot be mistaken for one (e.g., regex.match).
+func consumePropertyName(message string) {
+	if check(token.TOKEN_IDENTIFIER) || isKeyword(parser.current) {
+		advance()
+		return
+	}
+	errorAtCurrent(message)
+}
+
+// isKeyword reports whether t is a keyword, i.e. a word the lexer did not scan as an identifier.
+func isKeyword(t token.Token) bool {
+	r, _ := utf8.DecodeRuneInString(t.Start)
+	return t.Type != token.TOKEN_IDENTIFIER && t.Type != token.TOKEN_ERROR && t.Type != token.TOKEN_EOF && (unicode.IsLetter(r) || r == '_')
+}
+
 // check returns true if the current token is of the expected type.
 func check(typ token.TokenType) bool {
 	return parser.current.Type == typ
@@ -79,23 +97,50 @@ func match(typ token.TokenType) bool {
 	return true
 }
 
-// argumentList compiles the list of arguments in a function call and returns the count.
-func argumentList() uint8 {
-	var argCount uint8 = 0
-	if !check(token.TOKEN_RIGHT_PAREN) {
+// argumentList parses the arguments of a call, leaving them on the stack. It returns the argument
+// count, or spread=true when a '...' argument collected them into a single array instead.
+func argumentList() (argCount uint8, spread bool) {
+	count, spread := elementList(token.TOKEN_RIGHT_PAREN, "Function call cannot have more than 255 arguments.")
+	consume(token.TOKEN_RIGHT_PAREN, "Expected ')' to close argument list (e.g., 'func(a, b)').")
+	return count, spread
+}
+
+// elementList compiles comma-separated expressions up to (but not including) closing. Without a
+// '...' element the values are left on the stack and counted. Once a spread appears, every element
+// is gathered into one array on the stack instead: values written one by one are grouped with
+// OP_ARRAY and each group or spread operand is appended with OP_ARRAY_SPREAD.
+func elementList(closing token.TokenType, tooManyMessage string) (count uint8, spread bool) {
+	pending := 0
+	if !check(closing) {
 		for {
-			expression()
-			if argCount == 255 {
-				reportError("Function call cannot have more than 255 arguments.")
+			if match(token.TOKEN_DOT_DOT_DOT) {
+				if !spread {
+					emitBytes(byte(runtime.OP_ARRAY), byte(pending))
+				} else if pending > 0 {
+					emitBytes(byte(runtime.OP_ARRAY), byte(pending))
+					emitByte(byte(runtime.OP_ARRAY_SPREAD))
+				}
+				spread = true
+				pending = 0
+				expression()
+				emitByte(byte(runtime.OP_ARRAY_SPREAD))
+			} else {
+				expression()
+				if pending == 255 {
+					reportError(tooManyMessage)
+				}
+				pending++
 			}
-			argCount++
 			if !match(token.TOKEN_COMMA) {
 				break
 			}
 		}
 	}
-	consume(token.TOKEN_RIGHT_PAREN, "Expected ')' to close argument list (e.g., 'func(a, b)').")
-	return argCount
+	if spread && pending > 0 {
+		emitBytes(byte(runtime.OP_ARRAY), byte(pending))
+		emitByte(byte(runtime.OP_ARRAY_SPREAD))
+	}
+	return byte(pending), spread
 }
 
 // synchronize discards tokens until it reaches a statement boundary, helping recover from errors.
@@ -107,7 +152,7 @@ func synchronize() {
 		}
 		switch parser.current.Type {
 		case token.TOKEN_CLASS, token.TOKEN_FN, token.TOKEN_LET, token.TOKEN_FOR,
-			token.TOKEN_IF, token.TOKEN_WHILE, token.TOKEN_RETURN:
+			token.TOKEN_IF, token.TOKEN_WHILE, token.TOKEN_RETURN, token.TOKEN_YIELD:
 			return
 		}
 		advance()
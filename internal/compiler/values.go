@@ -13,19 +13,7 @@ func function(funcType FunctionType) {
 
 	beginScope()
 	consume(token.TOKEN_LEFT_PAREN, "Expected '(' after function name to start parameter list.")
-	if !check(token.TOKEN_RIGHT_PAREN) {
-		for {
-			current.function.Arity++
-			if current.function.Arity > 255 {
-				errorAtCurrent("Function cannot have more than 255 parameters.")
-			}
-			paramConstant := parseVariable("Expected a parameter name (e.g., 'x' in 'fn foo(x)').")
-			defineVariable(paramConstant)
-			if !match(token.TOKEN_COMMA) {
-				break
-			}
-		}
-	}
+	parameterList()
 	consume(token.TOKEN_RIGHT_PAREN, "Expected ')' to close parameter list (e.g., 'fn foo()').")
 	consume(token.TOKEN_LEFT_BRACE, "Expected '{' to start function body.")
 	block()
@@ -45,26 +33,59 @@ func function(funcType FunctionType) {
 	}
 }
 
-// arrayLiteral parses an array literal and emits the corresponding bytecode.
-// It collects the elements, enforces a maximum element count of 255, and then
-// emits an OP_ARRAY opcode with the element count.
-func arrayLiteral(canAssign bool) {
-	elementCount := 0
-	if !check(token.TOKEN_RIGHT_BRACKET) {
+// parameterPattern remembers a destructured parameter until the whole parameter list is known.
+type parameterPattern struct {
+	slot     uint8          // Hidden local slot holding the argument.
+	position sourcePosition // Position of the pattern in the source.
+}
+
+// parameterList compiles a function's parameters, stopping before the closing ')'. A destructured
+// parameter takes a hidden slot; its pattern is compiled once every parameter slot is allocated,
+// so the names it introduces are ordinary locals that follow the arguments on the stack.
+func parameterList() {
+	var patterns []parameterPattern
+	if !check(token.TOKEN_RIGHT_PAREN) {
 		for {
-			expression()
-			elementCount++
-			if elementCount == 255 {
-				reportError("Array literal cannot have more than 255 elements.")
+			current.function.Arity++
+			if current.function.Arity > 255 {
+				errorAtCurrent("Function cannot have more than 255 parameters.")
+			}
+			if isPatternStart() {
+				patterns = append(patterns, parameterPattern{slot: declareTemporary(), position: savePosition()})
+				skipPattern()
+			} else {
+				paramConstant := parseVariable("Expected a parameter name (e.g., 'x' in 'fn foo(x)').")
+				defineVariable(paramConstant)
 			}
 			if !match(token.TOKEN_COMMA) {
 				break
 			}
 		}
 	}
+	if len(patterns) == 0 {
+		return
+	}
+
+	resume := savePosition()
+	for _, pattern := range patterns {
+		restorePosition(pattern.position)
+		slot := pattern.slot
+		destructurePattern(func() { emitBytes(byte(runtime.OP_GET_LOCAL), slot) }, false)
+	}
+	restorePosition(resume)
+}
+
+// arrayLiteral parses an array literal and emits the corresponding bytecode.
+// It collects the elements, enforces a maximum element count of 255, and then
+// emits an OP_ARRAY opcode with the element count. Literals containing '...'
+// spreads are assembled by elementList instead.
+func arrayLiteral(canAssign bool) {
+	elementCount, spread := elementList(token.TOKEN_RIGHT_BRACKET, "Array literal cannot have more than 255 elements.")
 	consume(token.TOKEN_RIGHT_BRACKET, "Expected ']' after array elements.")
 
-	emitBytes(byte(runtime.OP_ARRAY), byte(elementCount))
+	if !spread {
+		emitBytes(byte(runtime.OP_ARRAY), elementCount)
+	}
 }
 
 // subscript parses array subscript expressions, handling both element access and slice syntax.
@@ -105,9 +126,42 @@ func subscript(canAssign bool) {
 	}
 }
 
+// setLiteral parses a set literal such as #{1, 2, 3}. The elements are gathered into an array
+// (spreads included, as in array literals) which OP_SET turns into a set, dropping duplicates.
+func setLiteral(canAssign bool) {
+	consume(token.TOKEN_LEFT_BRACE, "Expected '{' after '#' in set literal (e.g., '#{1, 2, 3}').")
+	elementCount, spread := elementList(token.TOKEN_RIGHT_BRACE, "Set literal cannot have more than 255 elements.")
+	consume(token.TOKEN_RIGHT_BRACE, "Expected '}' after set elements.")
+
+	if !spread {
+		emitBytes(byte(runtime.OP_ARRAY), elementCount)
+	}
+	emitByte(byte(runtime.OP_SET))
+}
+
+// mapLiteral parses a map literal. Pairs are emitted as key/value operands of OP_MAP; a '...'
+// spread closes the pending group and merges the spread map into it with OP_MAP_SPREAD, so later
+// entries override earlier ones.
 func mapLiteral(canAssign bool) {
 	pairs := 0
+	spread := false
 	for !check(token.TOKEN_RIGHT_BRACE) && !check(token.TOKEN_EOF) {
+		if match(token.TOKEN_DOT_DOT_DOT) {
+			if !spread {
+				emitBytes(byte(runtime.OP_MAP), byte(pairs))
+			} else if pairs > 0 {
+				emitBytes(byte(runtime.OP_MAP), byte(pairs))
+				emitByte(byte(runtime.OP_MAP_SPREAD))
+			}
+			spread = true
+			pairs = 0
+			expression()
+			emitByte(byte(runtime.OP_MAP_SPREAD))
+			if !match(token.TOKEN_COMMA) {
+				break
+			}
+			continue
+		}
 		// Parse key
 		if match(token.TOKEN_STRING) {
 			// Key is a string literal
@@ -117,20 +171,36 @@ func mapLiteral(canAssign bool) {
 			// Key is an identifier (treated as string)
 			key := parser.previous.Start
 			emitConstant(runtime.ObjVal(runtime.NewObjString(key)))
+		} else if match(token.TOKEN_NUMBER) {
+			number(false)
+		} else if match(token.TOKEN_TRUE) || match(token.TOKEN_FALSE) || match(token.TOKEN_NULL) {
+			literal(false)
+		} else if match(token.TOKEN_LEFT_BRACKET) {
+			// Computed key, e.g. {[-1]: "last"}; checked for hashability when the map is built.
+			expression()
+			consume(token.TOKEN_RIGHT_BRACKET, "Expected ']' after computed map key")
 		} else {
-			reportError("Map key must be a string or identifier")
+			reportError("Map key must be a string, identifier, number, true, false, null or [expression]")
 			return
 		}
 		consume(token.TOKEN_COLON, "Expected ':' after map key")
 		// Parse value
 		expression()
+		if pairs == 255 {
+			reportError("Map literal cannot have more than 255 entries.")
+		}
 		pairs++
 		if !match(token.TOKEN_COMMA) {
 			break
 		}
 	}
 	consume(token.TOKEN_RIGHT_BRACE, "Expected '}' after map literal")
-	emitBytes(byte(runtime.OP_MAP), byte(pairs))
+	if !spread {
+		emitBytes(byte(runtime.OP_MAP), byte(pairs))
+	} else if pairs > 0 {
+		emitBytes(byte(runtime.OP_MAP), byte(pairs))
+		emitByte(byte(runtime.OP_MAP_SPREAD))
+	}
 }
 
 // instance emits the OP_INSTANCE opcode with the number of arguments.
@@ -169,6 +169,44 @@ func DisassembleInstruction(ch *runtime.Chunk, offset int) int {
 		return simpleInstruction("OP_FLOOR", offset)
 	case uint8(runtime.OP_PERCENT):
 		return simpleInstruction("OP_PERCENT", offset)
+	case uint8(runtime.OP_BIT_AND):
+		return simpleInstruction("OP_BIT_AND", offset)
+	case uint8(runtime.OP_BIT_OR):
+		return simpleInstruction("OP_BIT_OR", offset)
+	case uint8(runtime.OP_BIT_XOR):
+		return simpleInstruction("OP_BIT_XOR", offset)
+	case uint8(runtime.OP_BIT_NOT):
+		return simpleInstruction("OP_BIT_NOT", offset)
+	case uint8(runtime.OP_SHIFT_LEFT):
+		return simpleInstruction("OP_SHIFT_LEFT", offset)
+	case uint8(runtime.OP_SHIFT_RIGHT):
+		return simpleInstruction("OP_SHIFT_RIGHT", offset)
+	case uint8(runtime.OP_DESTRUCTURE_ARRAY):
+		return destructureArrayInstruction(ch, offset)
+	case uint8(runtime.OP_DESTRUCTURE_INDEX):
+		return byteInstruction("OP_DESTRUCTURE_INDEX", ch, offset)
+	case uint8(runtime.OP_DESTRUCTURE_REST):
+		return byteInstruction("OP_DESTRUCTURE_REST", ch, offset)
+	case uint8(runtime.OP_DESTRUCTURE_KEY):
+		return destructureKeyInstruction(ch, offset)
+	case uint8(runtime.OP_ARRAY_SPREAD):
+		return simpleInstruction("OP_ARRAY_SPREAD", offset)
+	case uint8(runtime.OP_MAP_SPREAD):
+		return simpleInstruction("OP_MAP_SPREAD", offset)
+	case uint8(runtime.OP_CALL_SPREAD):
+		return simpleInstruction("OP_CALL_SPREAD", offset)
+	case uint8(runtime.OP_ITER):
+		return byteInstruction("OP_ITER", ch, offset)
+	case uint8(runtime.OP_ITER_NEXT):
+		return jumpInstruction("OP_ITER_NEXT", 1, ch, offset)
+	case uint8(runtime.OP_RANGE):
+		return byteInstruction("OP_RANGE", ch, offset)
+	case uint8(runtime.OP_IN):
+		return simpleInstruction("OP_IN", offset)
+	case uint8(runtime.OP_YIELD):
+		return simpleInstruction("OP_YIELD", offset)
+	case uint8(runtime.OP_SET):
+		return simpleInstruction("OP_SET", offset)
 	default:
 		fmt.Printf("Unknown opcode %d\n", instruction)
 		return offset + 1
@@ -208,6 +246,30 @@ func jumpInstruction(name string, sign int, ch *runtime.Chunk, offset int) int {
 	return offset + 3
 }
 
+// destructureArrayInstruction disassembles OP_DESTRUCTURE_ARRAY, printing the element count the
+// pattern expects and whether it ends with a rest element, and returning the next offset.
+func destructureArrayInstruction(ch *runtime.Chunk, offset int) int {
+	count := ch.Code()[offset+1]
+	hasRest := ch.Code()[offset+2] != 0
+	fmt.Printf("%-16s %4d rest=%t\n", "OP_DESTRUCTURE_ARRAY", count, hasRest)
+	return offset + 3
+}
+
+// destructureKeyInstruction disassembles OP_DESTRUCTURE_KEY, printing the key constant and the
+// offset that skips the default value expression (if any), and returning the next offset.
+func destructureKeyInstruction(ch *runtime.Chunk, offset int) int {
+	constant := ch.Code()[offset+1]
+	jump := int(ch.Code()[offset+2])<<8 | int(ch.Code()[offset+3])
+	fmt.Printf("%-16s %4d '", "OP_DESTRUCTURE_KEY", constant)
+	runtime.PrintValue(ch.Constants().Values()[constant])
+	if jump == 0 {
+		fmt.Println("' (no default)")
+	} else {
+		fmt.Printf("' default -> %d\n", offset+4+jump)
+	}
+	return offset + 4
+}
+
 // structInstruction disassembles the OP_STRUCT opcode, printing the struct name constant, field
 // count, and each field’s name and default value constants, and returning the next offset.
 func structInstruction(ch *runtime.Chunk, offset int) int {
@@ -8,20 +8,23 @@ import (
 )
 
 type Lexer struct {
-	source  string
-	start   int
-	current int
-	line    int
+	source       string
+	start        int
+	current      int
+	line         int
+	previous     token.TokenType // Type of the last token scanned; decides whether '/' starts a regex.
+	previousLine int             // Line of the last token scanned.
 }
 
 var lexer Lexer
 
 func InitLexer(source string) {
 	lexer = Lexer{
-		source:  source,
-		start:   0,
-		current: 0,
-		line:    1,
+		source:   source,
+		start:    0,
+		current:  0,
+		line:     1,
+		previous: token.TOKEN_EOF,
 	}
 
 	// Skip shebang line if present
@@ -42,6 +45,12 @@ func InitLexer(source string) {
 }
 
 func ScanToken() token.Token {
+	tok := scanToken()
+	lexer.previous, lexer.previousLine = tok.Type, tok.Line
+	return tok
+}
+
+func scanToken() token.Token {
 	lexer.skipWhitespace()
 
 	lexer.start = lexer.current
@@ -78,6 +87,14 @@ func ScanToken() token.Token {
 	case ',':
 		return lexer.makeToken(token.TOKEN_COMMA)
 	case '.':
+		if lexer.match('.') {
+			if lexer.match('.') {
+				return lexer.makeToken(token.TOKEN_DOT_DOT_DOT)
+			} else if lexer.match('=') {
+				return lexer.makeToken(token.TOKEN_DOT_DOT_EQUAL)
+			}
+			return lexer.makeToken(token.TOKEN_DOT_DOT)
+		}
 		return lexer.makeToken(token.TOKEN_DOT)
 	case '-':
 		if lexer.match('-') {
@@ -101,6 +118,9 @@ func ScanToken() token.Token {
 		}
 		return lexer.makeToken(token.TOKEN_STAR)
 	case '/':
+		if !lexer.previousEndsOperand() {
+			return lexer.regex()
+		}
 		if lexer.match('_') {
 			return lexer.makeToken(token.TOKEN_FLOOR)
 		} else if lexer.match('=') {
@@ -123,12 +143,16 @@ func ScanToken() token.Token {
 		}
 		return lexer.makeToken(token.TOKEN_EQUAL)
 	case '<':
-		if lexer.match('=') {
+		if lexer.match('<') {
+			return lexer.makeToken(token.TOKEN_LESS_LESS)
+		} else if lexer.match('=') {
 			return lexer.makeToken(token.TOKEN_LESS_EQUAL)
 		}
 		return lexer.makeToken(token.TOKEN_LESS)
 	case '>':
-		if lexer.match('=') {
+		if lexer.match('>') {
+			return lexer.makeToken(token.TOKEN_GREATER_GREATER)
+		} else if lexer.match('=') {
 			return lexer.makeToken(token.TOKEN_GREATER_EQUAL)
 		}
 		return lexer.makeToken(token.TOKEN_GREATER)
@@ -136,6 +160,15 @@ func ScanToken() token.Token {
 		return lexer.string()
 	case '\'':
 		return lexer.char()
+	case '&':
+		if lexer.match('&') {
+			return lexer.makeToken(token.TOKEN_AND)
+		}
+		return lexer.makeToken(token.TOKEN_AMPERSAND)
+	case '^':
+		return lexer.makeToken(token.TOKEN_CARET)
+	case '~':
+		return lexer.makeToken(token.TOKEN_TILDE)
 	case '|':
 		if lexer.match('|') {
 			return lexer.makeToken(token.TOKEN_OR)
@@ -276,6 +309,51 @@ func (l *Lexer) string() token.Token {
 	return l.makeToken(token.TOKEN_STRING)
 }
 
+// previousEndsOperand reports whether the last token can end an operand, in which case a '/'
+// that follows it is the division operator rather than the start of a regex literal. A '}' may
+// close a block instead of a literal, so a '/' on a later line than the '}' starts a regex.
+func (l *Lexer) previousEndsOperand() bool {
+	switch l.previous {
+	case token.TOKEN_RIGHT_BRACE:
+		return l.previousLine == l.line
+	case token.TOKEN_IDENTIFIER, token.TOKEN_NUMBER, token.TOKEN_STRING, token.TOKEN_CHAR,
+		token.TOKEN_RIGHT_PAREN, token.TOKEN_RIGHT_BRACKET,
+		token.TOKEN_TRUE, token.TOKEN_FALSE, token.TOKEN_NULL, token.TOKEN_THIS, token.TOKEN_SUPER,
+		token.TOKEN_PLUS_PLUS, token.TOKEN_MINUS_MINUS, token.TOKEN_REGEX:
+		return true
+	}
+	return false
+}
+
+// regex scans a regex literal such as /a+b/i after its opening slash. The pattern ends at the
+// first '/' that is neither escaped nor inside a character class, and any letters after it are
+// the flags. A literal cannot span lines.
+func (l *Lexer) regex() token.Token {
+	inClass := false
+	for {
+		if l.isAtEnd() || l.peek() == '\n' {
+			return l.errorToken("Unterminated regular expression literal.")
+		}
+		r := l.advance()
+		if r == '\\' {
+			if l.isAtEnd() || l.peek() == '\n' {
+				return l.errorToken("Unterminated regular expression literal.")
+			}
+			l.advance()
+		} else if r == '[' {
+			inClass = true
+		} else if r == ']' {
+			inClass = false
+		} else if r == '/' && !inClass {
+			break
+		}
+	}
+	for unicode.IsLetter(l.peek()) {
+		l.advance()
+	}
+	return l.makeToken(token.TOKEN_REGEX)
+}
+
 func (l *Lexer) char() token.Token {
 	// If we reached the end, we have an error.
 	if l.isAtEnd() {
@@ -321,19 +399,65 @@ func (l *Lexer) char() token.Token {
 	return l.makeToken(token.TOKEN_CHAR)
 }
 
+// number scans a numeric literal. Besides plain integers and decimals it accepts the
+// prefixed forms 0x (hexadecimal), 0o (octal) and 0b (binary), exponents such as 1e-9
+// and '_' digit separators. Any trailing letters or digits are swallowed into the token
+// so that malformed literals like 0xZZ or 1e reach the compiler as a single token and
+// are reported there with a precise message.
 func (l *Lexer) number() token.Token {
-	for unicode.IsDigit(l.peek()) {
-		l.advance()
+	first := l.source[l.start]
+	if first == '0' && isRadixPrefix(l.peek()) {
+		l.advance() // Consume the radix prefix
+		l.skipLiteralTail()
+		return l.makeToken(token.TOKEN_NUMBER)
 	}
+
+	l.skipDigits()
 	if l.peek() == '.' && unicode.IsDigit(l.peekNext()) {
 		l.advance() // Consume '.'
-		for unicode.IsDigit(l.peek()) {
-			l.advance()
+		l.skipDigits()
+	}
+	if r := l.peek(); r == 'e' || r == 'E' {
+		next := l.peekNext()
+		if unicode.IsDigit(next) || next == '+' || next == '-' {
+			l.advance() // Consume 'e'
+			if next == '+' || next == '-' {
+				l.advance() // Consume the sign
+			}
+			l.skipDigits()
 		}
 	}
+	l.skipLiteralTail()
 	return l.makeToken(token.TOKEN_NUMBER)
 }
 
+// skipDigits consumes decimal digits and '_' separators.
+func (l *Lexer) skipDigits() {
+	for r := l.peek(); isDigitRune(r) || r == '_'; r = l.peek() {
+		l.advance()
+	}
+}
+
+// skipLiteralTail consumes any ASCII letters, digits or '_' directly following a number.
+func (l *Lexer) skipLiteralTail() {
+	for r := l.peek(); isDigitRune(r) || r == '_' || (r < utf8.RuneSelf && unicode.IsLetter(r)); r = l.peek() {
+		l.advance()
+	}
+}
+
+func isDigitRune(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isRadixPrefix(r rune) bool {
+	switch r {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	default:
+		return false
+	}
+}
+
 func (l *Lexer) identifier() token.Token {
 	for {
 		r := l.peek()
@@ -347,7 +471,7 @@ func (l *Lexer) identifier() token.Token {
 
 func isOperatorRune(r rune) bool {
 	switch r {
-	case '(', ')', '{', '}', '[', ']', '|', ':', '?', ';', ',', '.', '-', '+', '/', '%', '@', '#', '$', '*', '!', '=', '<', '>', '"', '\'':
+	case '(', ')', '{', '}', '[', ']', '|', '&', '^', '~', ':', '?', ';', ',', '.', '-', '+', '/', '%', '@', '#', '$', '*', '!', '=', '<', '>', '"', '\'':
 		return true
 	default:
 		return false
@@ -357,8 +481,6 @@ func isOperatorRune(r rune) bool {
 func (l *Lexer) identifierType() token.TokenType {
 	startStr := l.source[l.start:l.current]
 	switch startStr {
-	case "&&":
-		return token.TOKEN_AND
 	case "else":
 		return token.TOKEN_ELSE
 	case "false":
@@ -411,7 +533,26 @@ func (l *Lexer) identifierType() token.TokenType {
 		return token.TOKEN_MOD
 	case "as":
 		return token.TOKEN_AS
+	case "yield":
+		return token.TOKEN_YIELD
 	default:
 		return token.TOKEN_IDENTIFIER
 	}
 }
+
+// State is a snapshot of the scanner position. The compiler uses it to compile a region of source
+// later than it was first scanned (e.g., destructured parameters, which are bound after the whole
+// parameter list is known).
+type State struct {
+	lexer Lexer
+}
+
+// SaveState returns the current scanner position.
+func SaveState() State {
+	return State{lexer: lexer}
+}
+
+// RestoreState rewinds (or fast-forwards) the scanner to a position returned by SaveState.
+func RestoreState(state State) {
+	lexer = state.lexer
+}
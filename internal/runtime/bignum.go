@@ -0,0 +1,301 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	stdstrings "strings" // "strings" is the package-level intern table
+)
+
+// ObjBigInt represents an arbitrary-precision integer (written as 123n in source).
+type ObjBigInt struct {
+	Obj
+	Value *big.Int
+}
+
+// NewBigInt creates a new BigInt object. The caller must not modify v afterwards.
+func NewBigInt(v *big.Int) *ObjBigInt {
+	return &ObjBigInt{
+		Obj:   Obj{Type: OBJ_BIGINT},
+		Value: v,
+	}
+}
+
+// ObjDecimal represents an exact decimal number: Unscaled * 10^-Scale. The scale is kept
+// as written, so decimal("20.00") prints as 20.00 rather than 20.
+type ObjDecimal struct {
+	Obj
+	Unscaled *big.Int
+	Scale    int32
+}
+
+// NewDecimal creates a new Decimal object. The caller must not modify unscaled afterwards.
+func NewDecimal(unscaled *big.Int, scale int32) *ObjDecimal {
+	return &ObjDecimal{
+		Obj:      Obj{Type: OBJ_DECIMAL},
+		Unscaled: unscaled,
+		Scale:    scale,
+	}
+}
+
+// RoundingMode selects how decimal results are rounded when digits must be dropped.
+type RoundingMode int
+
+const (
+	ROUND_HALF_EVEN RoundingMode = iota // Round to nearest, ties to the even neighbour (banker's rounding).
+	ROUND_HALF_UP                       // Round to nearest, ties away from zero.
+	ROUND_HALF_DOWN                     // Round to nearest, ties toward zero.
+	ROUND_UP                            // Round away from zero.
+	ROUND_DOWN                          // Round toward zero (truncate).
+	ROUND_CEILING                       // Round toward positive infinity.
+	ROUND_FLOOR                         // Round toward negative infinity.
+)
+
+var roundingModeNames = []string{"half_even", "half_up", "half_down", "up", "down", "ceiling", "floor"}
+
+// ParseRoundingMode looks up a rounding mode by its script name (e.g., "half_up").
+func ParseRoundingMode(name string) (RoundingMode, bool) {
+	for i, n := range roundingModeNames {
+		if n == name {
+			return RoundingMode(i), true
+		}
+	}
+	return ROUND_HALF_EVEN, false
+}
+
+func (m RoundingMode) String() string {
+	return roundingModeNames[m]
+}
+
+// RoundingModeNames lists the accepted rounding mode names, for error messages.
+func RoundingModeNames() string {
+	return stdstrings.Join(roundingModeNames, ", ")
+}
+
+var bigTen = big.NewInt(10)
+
+// pow10 returns 10^n as a new big.Int.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// roundQuotient divides num by den (den > 0 is not required) and rounds the quotient to an
+// integer using the given mode.
+func roundQuotient(num, den *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// The sign of the exact quotient decides which neighbour is "away from zero".
+	sign := num.Sign() * den.Sign()
+	twiceRem := new(big.Int).Abs(r)
+	twiceRem.Lsh(twiceRem, 1)
+	half := twiceRem.CmpAbs(den) // <0 below half, 0 exactly half, >0 above half
+
+	away := false
+	switch mode {
+	case ROUND_UP:
+		away = true
+	case ROUND_DOWN:
+		away = false
+	case ROUND_CEILING:
+		away = sign > 0
+	case ROUND_FLOOR:
+		away = sign < 0
+	case ROUND_HALF_UP:
+		away = half >= 0
+	case ROUND_HALF_DOWN:
+		away = half > 0
+	case ROUND_HALF_EVEN:
+		away = half > 0 || (half == 0 && q.Bit(0) == 1)
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q
+}
+
+// ParseDecimal parses text such as "19.99", "-0.5" or "1.25e3" into a Decimal.
+func ParseDecimal(text string) (*ObjDecimal, error) {
+	s := stdstrings.TrimSpace(text)
+	exponent := int64(0)
+	if i := stdstrings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exponent, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid decimal '%s'", text)
+		}
+		s = s[:i]
+	}
+	sign := ""
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		sign, s = s[:1], s[1:]
+	}
+	intPart, fracPart, _ := stdstrings.Cut(s, ".")
+	digits := intPart + fracPart
+	if digits == "" || stdstrings.Trim(digits, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid decimal '%s'", text)
+	}
+	unscaled, _ := new(big.Int).SetString(sign+digits, 10)
+	scale := int64(len(fracPart)) - exponent
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(int32(-scale)))
+		scale = 0
+	}
+	if scale > 1<<20 {
+		return nil, fmt.Errorf("decimal '%s' has too many fractional digits", text)
+	}
+	return NewDecimal(unscaled, int32(scale)), nil
+}
+
+// DecimalFromBigInt converts an integer into a Decimal with scale 0.
+func DecimalFromBigInt(v *big.Int) *ObjDecimal {
+	return NewDecimal(new(big.Int).Set(v), 0)
+}
+
+// String formats the decimal with exactly Scale fractional digits.
+func (d *ObjDecimal) String() string {
+	digits := new(big.Int).Abs(d.Unscaled).String()
+	sign := ""
+	if d.Unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if d.Scale == 0 {
+		return sign + digits
+	}
+	if pad := int(d.Scale) + 1 - len(digits); pad > 0 {
+		digits = stdstrings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.Scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Rat returns the exact value of the decimal as a rational number.
+func (d *ObjDecimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.Unscaled, pow10(d.Scale))
+}
+
+// Rescale returns d expressed with the given scale, rounding with mode if digits are dropped.
+func (d *ObjDecimal) Rescale(scale int32, mode RoundingMode) *ObjDecimal {
+	if scale >= d.Scale {
+		return NewDecimal(new(big.Int).Mul(d.Unscaled, pow10(scale-d.Scale)), scale)
+	}
+	return NewDecimal(roundQuotient(d.Unscaled, pow10(d.Scale-scale), mode), scale)
+}
+
+// align returns the unscaled values of a and b expressed with their common (largest) scale.
+func align(a, b *ObjDecimal) (*big.Int, *big.Int, int32) {
+	if a.Scale == b.Scale {
+		return a.Unscaled, b.Unscaled, a.Scale
+	}
+	if a.Scale > b.Scale {
+		return a.Unscaled, new(big.Int).Mul(b.Unscaled, pow10(a.Scale-b.Scale)), a.Scale
+	}
+	return new(big.Int).Mul(a.Unscaled, pow10(b.Scale-a.Scale)), b.Unscaled, b.Scale
+}
+
+// DecimalAdd returns a + b exactly.
+func DecimalAdd(a, b *ObjDecimal) *ObjDecimal {
+	x, y, scale := align(a, b)
+	return NewDecimal(new(big.Int).Add(x, y), scale)
+}
+
+// DecimalSub returns a - b exactly.
+func DecimalSub(a, b *ObjDecimal) *ObjDecimal {
+	x, y, scale := align(a, b)
+	return NewDecimal(new(big.Int).Sub(x, y), scale)
+}
+
+// DecimalMul returns a * b exactly.
+func DecimalMul(a, b *ObjDecimal) *ObjDecimal {
+	return NewDecimal(new(big.Int).Mul(a.Unscaled, b.Unscaled), a.Scale+b.Scale)
+}
+
+// DecimalQuo returns a / b rounded to scale fractional digits. b must not be zero.
+func DecimalQuo(a, b *ObjDecimal, scale int32, mode RoundingMode) *ObjDecimal {
+	// a/b * 10^scale = (aU * 10^(bS+scale)) / (bU * 10^aS)
+	num := new(big.Int).Mul(a.Unscaled, pow10(b.Scale+scale))
+	den := new(big.Int).Mul(b.Unscaled, pow10(a.Scale))
+	return NewDecimal(roundQuotient(num, den, mode), scale)
+}
+
+// DecimalRem returns the remainder of a / b truncated toward zero, matching '%' on integers.
+// b must not be zero.
+func DecimalRem(a, b *ObjDecimal) *ObjDecimal {
+	x, y, scale := align(a, b)
+	return NewDecimal(new(big.Int).Rem(x, y), scale)
+}
+
+// DecimalFloorQuo returns floor(a / b) as a decimal with scale 0. b must not be zero.
+func DecimalFloorQuo(a, b *ObjDecimal) *ObjDecimal {
+	return DecimalQuo(a, b, 0, ROUND_FLOOR)
+}
+
+// DecimalPow returns d raised to a non-negative integer power exactly. The result has d.Scale*n
+// fractional digits, so it fails when that scale does not fit in a Decimal.
+func DecimalPow(d *ObjDecimal, n int64) (*ObjDecimal, error) {
+	if d.Scale > 0 && n > math.MaxInt32/int64(d.Scale) {
+		return nil, fmt.Errorf("the result would have more than %d decimal places", math.MaxInt32)
+	}
+	unscaled := new(big.Int).Exp(d.Unscaled, big.NewInt(n), nil)
+	return NewDecimal(unscaled, d.Scale*int32(n)), nil
+}
+
+// AsBigInt returns the integer value of an int or BigInt value.
+func AsBigInt(v Value) (*big.Int, bool) {
+	switch {
+	case v.Type == VAL_INT:
+		return big.NewInt(v.Int), true
+	case v.Type == VAL_OBJ:
+		if b, ok := v.Obj.(*ObjBigInt); ok {
+			return b.Value, true
+		}
+	}
+	return nil, false
+}
+
+// AsDecimal returns the value of an int, BigInt or Decimal value as a Decimal.
+func AsDecimal(v Value) (*ObjDecimal, bool) {
+	if d, ok := v.Obj.(*ObjDecimal); ok && v.Type == VAL_OBJ {
+		return d, true
+	}
+	if b, ok := AsBigInt(v); ok {
+		return DecimalFromBigInt(b), true
+	}
+	return nil, false
+}
+
+// IsExactNumber reports whether v is a BigInt or Decimal object.
+func IsExactNumber(v Value) bool {
+	if v.Type != VAL_OBJ {
+		return false
+	}
+	switch v.Obj.(type) {
+	case *ObjBigInt, *ObjDecimal:
+		return true
+	}
+	return false
+}
+
+// AsRat returns the exact value of any numeric value (int, float, BigInt or Decimal) as a
+// rational number. ok is false for non-numbers and for NaN or infinite floats.
+func AsRat(v Value) (*big.Rat, bool) {
+	switch v.Type {
+	case VAL_INT:
+		return new(big.Rat).SetInt64(v.Int), true
+	case VAL_NUMBER:
+		r := new(big.Rat)
+		if r.SetFloat64(v.Number) == nil {
+			return nil, false
+		}
+		return r, true
+	case VAL_OBJ:
+		switch o := v.Obj.(type) {
+		case *ObjBigInt:
+			return new(big.Rat).SetInt(o.Value), true
+		case *ObjDecimal:
+			return o.Rat(), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,61 @@
+package runtime
+
+import (
+	"encoding/hex"
+)
+
+// ObjBytes is a mutable buffer of bytes, for binary data such as the contents of an image file
+// or a network protocol frame. Each element reads as an integer from 0 to 255.
+type ObjBytes struct {
+	Obj
+	Data []byte
+}
+
+// NewBytes creates a bytes object over data, which it takes ownership of.
+func NewBytes(data []byte) *ObjBytes {
+	return &ObjBytes{
+		Obj:  Obj{Type: OBJ_BYTES},
+		Data: data,
+	}
+}
+
+// String returns the printed form of the buffer, its bytes in hexadecimal, e.g. <bytes 00 ff 10>.
+func (b *ObjBytes) String() string {
+	if len(b.Data) == 0 {
+		return "<bytes>"
+	}
+	text := make([]byte, 0, len("<bytes>")+3*len(b.Data))
+	text = append(text, "<bytes"...)
+	for _, c := range b.Data {
+		text = append(text, ' ')
+		text = hex.AppendEncode(text, []byte{c})
+	}
+	return string(append(text, '>'))
+}
+
+// ObjBytesIterator walks a bytes object one byte at a time, producing integers.
+type ObjBytesIterator struct {
+	Obj
+	Bytes *ObjBytes
+	Index int
+}
+
+// NewBytesIterator creates an iterator over the bytes of b.
+func NewBytesIterator(b *ObjBytes) *ObjBytesIterator {
+	return &ObjBytesIterator{Obj: Obj{Type: OBJ_BYTES_ITERATOR}, Bytes: b}
+}
+
+// Done reports whether the bytes iterator has passed the last byte.
+func (it *ObjBytesIterator) Done() bool {
+	return it.Index >= len(it.Bytes.Data)
+}
+
+// Value returns the current byte as an integer.
+func (it *ObjBytesIterator) Value() Value {
+	return IntVal(int64(it.Bytes.Data[it.Index]))
+}
+
+// Advance moves to the next byte.
+func (it *ObjBytesIterator) Advance() {
+	it.Index++
+}
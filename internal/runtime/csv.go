@@ -0,0 +1,108 @@
+package runtime
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	goruntime "runtime"
+)
+
+// ObjCSVReader streams the records of a CSV file, one array (or map, when the columns are named)
+// per record. It reads one record ahead so Done can answer without consuming input, and closes
+// the file once the last record has been read or a read fails. Like an ObjFile, a reader that is
+// not read to the end has its file closed when it becomes unreachable or by CloseFiles.
+type ObjCSVReader struct {
+	Obj
+	Path    string
+	handle  *fileHandle
+	reader  *csv.Reader
+	columns []string // Column names; records are produced as maps when set.
+	current Value
+	done    bool
+	err     error
+}
+
+// OpenCSVReader opens the file at path for reading and creates a reader over it, using comma as
+// the field delimiter. When header is set the first record names the columns; otherwise columns,
+// if not nil, does.
+func OpenCSVReader(path string, comma rune, header bool, columns []string) (*ObjCSVReader, error) {
+	handle, err := openHandle(path, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	r := &ObjCSVReader{
+		Obj:     Obj{Type: OBJ_CSV_READER},
+		Path:    path,
+		handle:  handle,
+		reader:  csv.NewReader(handle.reader),
+		columns: columns,
+	}
+	goruntime.SetFinalizer(r, func(r *ObjCSVReader) { r.handle.close() })
+	r.reader.Comma = comma
+	if columns != nil {
+		r.reader.FieldsPerRecord = len(columns)
+	}
+	if header {
+		if r.columns, r.err = r.reader.Read(); r.err == io.EOF {
+			r.columns, r.err = []string{}, nil
+		}
+	}
+	r.Advance()
+	return r, nil
+}
+
+// Done reports whether every record has been produced or reading failed.
+func (r *ObjCSVReader) Done() bool {
+	return r.done
+}
+
+// Value returns the current record.
+func (r *ObjCSVReader) Value() Value {
+	return r.current
+}
+
+// Advance reads the next record.
+func (r *ObjCSVReader) Advance() {
+	if r.done {
+		return
+	}
+	if r.err == nil {
+		var record []string
+		if record, r.err = r.reader.Read(); r.err == nil {
+			r.current = CSVRecord(record, r.columns)
+			return
+		}
+	}
+	r.done = true
+	r.current = Value{Type: VAL_NULL}
+	r.handle.close()
+	if r.err == io.EOF {
+		r.err = nil
+	}
+}
+
+// Err returns the error that ended the iteration early, if any.
+func (r *ObjCSVReader) Err() error {
+	if r.err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", r.Path, r.err)
+}
+
+// CSVRecord converts a CSV record to an array of strings, or to a map from column names to
+// fields when columns is not nil.
+func CSVRecord(record []string, columns []string) Value {
+	if columns == nil {
+		fields := make([]Value, len(record))
+		for i, field := range record {
+			fields[i] = ObjVal(NewObjString(field))
+		}
+		return ObjVal(NewArray(fields))
+	}
+	row := NewMap()
+	for i, field := range record {
+		row.Set(ObjVal(NewObjString(columns[i])), ObjVal(NewObjString(field)))
+	}
+	return ObjVal(row)
+}
@@ -0,0 +1,96 @@
+package runtime
+
+import "bytes"
+
+// DeepEqual reports whether a and b have the same contents. Arrays match element by element,
+// maps by their set of keys and the values under them (in any order), sets by their elements,
+// bytes by their contents, instances by their struct and field values, and dates and times by
+// the moment they hold. Anything else falls back to Equal. Cyclic structures are handled: a
+// pair of objects that is already being compared further up is assumed equal.
+func DeepEqual(a, b Value) bool {
+	return deepEqual(a, b, make(map[[2]any]bool))
+}
+
+// deepEqual compares a and b, recording object pairs in seen before descending into them.
+func deepEqual(a, b Value, seen map[[2]any]bool) bool {
+	if a.Type != VAL_OBJ || b.Type != VAL_OBJ || a.Obj == b.Obj {
+		return Equal(a, b)
+	}
+	pair := [2]any{a.Obj, b.Obj}
+	if seen[pair] {
+		return true
+	}
+
+	switch x := a.Obj.(type) {
+	case *ObjArray:
+		y, ok := b.Obj.(*ObjArray)
+		if !ok || len(x.Elements) != len(y.Elements) {
+			return false
+		}
+		seen[pair] = true
+		for i := range x.Elements {
+			if !deepEqual(x.Elements[i], y.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *ObjMap:
+		y, ok := b.Obj.(*ObjMap)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		seen[pair] = true
+		for key, value := range x.All() {
+			other, found := y.Get(key)
+			if !found || !deepEqual(value, other, seen) {
+				return false
+			}
+		}
+		return true
+	case *ObjSet:
+		y, ok := b.Obj.(*ObjSet)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		for value := range x.All() {
+			if !y.Has(value) {
+				return false
+			}
+		}
+		return true
+	case *ObjInstance:
+		y, ok := b.Obj.(*ObjInstance)
+		if !ok || x.Structure != y.Structure || len(x.Fields) != len(y.Fields) {
+			return false
+		}
+		seen[pair] = true
+		for name, value := range x.Fields {
+			other, found := y.Fields[name]
+			if !found || !deepEqual(value, other, seen) {
+				return false
+			}
+		}
+		return true
+	case *ObjBytes:
+		y, ok := b.Obj.(*ObjBytes)
+		return ok && bytes.Equal(x.Data, y.Data)
+	case *ObjDate:
+		y, ok := b.Obj.(*ObjDate)
+		return ok && x.Time.Equal(y.Time)
+	case *ObjTime:
+		y, ok := b.Obj.(*ObjTime)
+		return ok && x.Time.Equal(y.Time)
+	case *ObjDateTime:
+		y, ok := b.Obj.(*ObjDateTime)
+		return ok && x.Time.Equal(y.Time)
+	case *ObjRange:
+		// Ranges are equal when they produce the same integers, e.g. 0..3 and 0..=2.
+		y, ok := b.Obj.(*ObjRange)
+		if !ok || x.Len() != y.Len() {
+			return false
+		}
+		n := x.Len()
+		return n == 0 || (x.At(0) == y.At(0) && (n == 1 || x.Step == y.Step))
+	}
+	return Equal(a, b)
+}
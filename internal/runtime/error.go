@@ -0,0 +1,26 @@
+package runtime
+
+// ObjError is an error returned as a value, so a script can inspect a failure and carry on
+// instead of stopping with a runtime error. The file system natives return one when the
+// operating system refuses an operation.
+type ObjError struct {
+	Obj
+	Kind    string // A stable category to test against, e.g. "not_found" or "permission".
+	Message string // The full description, including the path and operation involved.
+	Path    string // The path the failed operation was applied to, or "" if there is none.
+}
+
+// NewError creates an error value.
+func NewError(kind, message, path string) *ObjError {
+	return &ObjError{
+		Obj:     Obj{Type: OBJ_ERROR},
+		Kind:    kind,
+		Message: message,
+		Path:    path,
+	}
+}
+
+// String returns the printed form of the error, e.g. <error not_found: open x: no such file>.
+func (e *ObjError) String() string {
+	return "<error " + e.Kind + ": " + e.Message + ">"
+}
@@ -0,0 +1,282 @@
+package runtime
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	goruntime "runtime"
+	stdstrings "strings" // "strings" is the package-level intern table
+	"sync"
+)
+
+// Errors returned by the ObjFile methods for uses the file does not allow.
+var (
+	ErrFileClosed      = errors.New("file is closed")
+	ErrFileNotReadable = errors.New("file is not open for reading")
+	ErrFileNotWritable = errors.New("file is not open for writing")
+)
+
+// ObjFile is an open file read and written through buffers, so a large file can be processed a
+// line or a block at a time. Iterating over it produces its remaining lines, read lazily.
+//
+// A file is closed by its close method, when it becomes unreachable, or by CloseFiles when the
+// VM is freed, whichever comes first.
+type ObjFile struct {
+	Obj
+	Path   string
+	Mode   string // One of r, w, a, r+, w+ and a+.
+	handle *fileHandle
+	line   string // The line produced by Value, until Advance.
+	read   bool   // line holds the current line.
+	err    error  // The error that ended an iteration early.
+}
+
+// fileHandle holds the operating system file of an ObjFile. It is kept apart from the object so
+// the table of open files does not keep the object reachable and its finalizer can run.
+type fileHandle struct {
+	file    *os.File
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	closed  bool
+	closeMu sync.Mutex // Guards closed against the finalizer goroutine.
+}
+
+var (
+	openFiles   = make(map[*fileHandle]struct{}) // Files not yet closed, for CloseFiles.
+	openFilesMu sync.Mutex
+)
+
+// fileFlags maps the modes accepted by OpenFile to the flags of os.OpenFile.
+var fileFlags = map[string]int{
+	"r":  os.O_RDONLY,
+	"w":  os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"a":  os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+	"r+": os.O_RDWR,
+	"w+": os.O_RDWR | os.O_CREATE | os.O_TRUNC,
+	"a+": os.O_RDWR | os.O_CREATE | os.O_APPEND,
+}
+
+// ValidFileMode reports whether mode is one that OpenFile accepts.
+func ValidFileMode(mode string) bool {
+	_, ok := fileFlags[mode]
+	return ok
+}
+
+// OpenFile opens the file at path in the given mode: r reads, w truncates (or creates) and
+// writes, a appends, and a trailing + allows both reading and writing.
+func OpenFile(path, mode string) (*ObjFile, error) {
+	flags, ok := fileFlags[mode]
+	if !ok {
+		return nil, errors.New("unknown file mode '" + mode + "'")
+	}
+	handle, err := openHandle(path, flags)
+	if err != nil {
+		return nil, err
+	}
+	f := &ObjFile{Obj: Obj{Type: OBJ_FILE}, Path: path, Mode: mode, handle: handle}
+	goruntime.SetFinalizer(f, func(f *ObjFile) { f.handle.close() })
+	return f, nil
+}
+
+// openHandle opens the file at path with the flags of os.OpenFile and adds it to the open files,
+// so CloseFiles closes it even if its owner never does.
+func openHandle(path string, flags int) (*fileHandle, error) {
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	handle := &fileHandle{file: file, reader: bufio.NewReader(file), writer: bufio.NewWriter(file)}
+	openFilesMu.Lock()
+	openFiles[handle] = struct{}{}
+	openFilesMu.Unlock()
+	return handle, nil
+}
+
+// CloseFiles closes every file that is still open, flushing what was written to it. This includes
+// the files of CSV readers that were not read to the end.
+func CloseFiles() {
+	openFilesMu.Lock()
+	handles := make([]*fileHandle, 0, len(openFiles))
+	for handle := range openFiles {
+		handles = append(handles, handle)
+	}
+	openFilesMu.Unlock()
+	for _, handle := range handles {
+		handle.close()
+	}
+}
+
+// close flushes and closes the file, once, and removes it from the open files.
+func (h *fileHandle) close() error {
+	h.closeMu.Lock()
+	defer h.closeMu.Unlock()
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	openFilesMu.Lock()
+	delete(openFiles, h)
+	openFilesMu.Unlock()
+	err := h.writer.Flush()
+	if closeErr := h.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Closed reports whether the file has been closed.
+func (f *ObjFile) Closed() bool {
+	f.handle.closeMu.Lock()
+	defer f.handle.closeMu.Unlock()
+	return f.handle.closed
+}
+
+// Close flushes and closes the file. Closing a closed file does nothing.
+func (f *ObjFile) Close() error {
+	return f.handle.close()
+}
+
+// Readable reports whether the mode allows reading.
+func (f *ObjFile) Readable() bool {
+	return f.Mode == "r" || stdstrings.HasSuffix(f.Mode, "+")
+}
+
+// Writable reports whether the mode allows writing.
+func (f *ObjFile) Writable() bool {
+	return f.Mode != "r"
+}
+
+// startRead makes the file ready to read: anything written so far is flushed first.
+func (f *ObjFile) startRead() error {
+	if f.Closed() {
+		return ErrFileClosed
+	}
+	if !f.Readable() {
+		return ErrFileNotReadable
+	}
+	return f.handle.writer.Flush()
+}
+
+// startWrite makes the file ready to write at the position the script has read up to, giving
+// back what the read buffer holds beyond it.
+func (f *ObjFile) startWrite() error {
+	if f.Closed() {
+		return ErrFileClosed
+	}
+	if !f.Writable() {
+		return ErrFileNotWritable
+	}
+	if buffered := f.handle.reader.Buffered(); buffered > 0 {
+		if _, err := f.handle.file.Seek(int64(-buffered), io.SeekCurrent); err != nil {
+			return err
+		}
+		f.handle.reader.Reset(f.handle.file)
+	}
+	return nil
+}
+
+// ReadLine reads the next line, without its line ending. ok is false at the end of the file.
+func (f *ObjFile) ReadLine() (line string, ok bool, err error) {
+	if err := f.startRead(); err != nil {
+		return "", false, err
+	}
+	line, err = f.handle.reader.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+		err = nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	line = stdstrings.TrimSuffix(line, "\n")
+	return stdstrings.TrimSuffix(line, "\r"), true, nil
+}
+
+// ReadBytes reads up to n bytes; fewer are returned only at the end of the file. ok is false
+// when there is nothing left to read. The buffer grows with what is actually read, so a huge n
+// costs no more than the rest of the file.
+func (f *ObjFile) ReadBytes(n int64) (data []byte, ok bool, err error) {
+	if err := f.startRead(); err != nil {
+		return nil, false, err
+	}
+	data, err = io.ReadAll(io.LimitReader(f.handle.reader, n))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) == 0 && n > 0 {
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+// Write writes s to the file through its buffer.
+func (f *ObjFile) Write(s string) error {
+	if err := f.startWrite(); err != nil {
+		return err
+	}
+	_, err := f.handle.writer.WriteString(s)
+	return err
+}
+
+// Flush writes out what is buffered.
+func (f *ObjFile) Flush() error {
+	if f.Closed() {
+		return ErrFileClosed
+	}
+	return f.handle.writer.Flush()
+}
+
+// Seek moves to offset relative to whence (io.SeekStart, io.SeekCurrent or io.SeekEnd) and
+// returns the new position from the start of the file.
+func (f *ObjFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.Flush(); err != nil {
+		return 0, err
+	}
+	if whence == io.SeekCurrent {
+		// The operating system's position is ahead of the script's by what is buffered.
+		offset -= int64(f.handle.reader.Buffered())
+	}
+	position, err := f.handle.file.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	f.handle.reader.Reset(f.handle.file)
+	return position, nil
+}
+
+// Done reports whether every remaining line has been produced. It ends the iteration, with Err
+// set, if the file cannot be read.
+func (f *ObjFile) Done() bool {
+	if f.read {
+		return false
+	}
+	line, ok, err := f.ReadLine()
+	f.err = err
+	if err != nil {
+		return true
+	}
+	f.line, f.read = line, ok
+	return !ok
+}
+
+// Value returns the current line.
+func (f *ObjFile) Value() Value {
+	return ObjVal(NewObjString(f.line))
+}
+
+// Advance moves to the next line.
+func (f *ObjFile) Advance() {
+	f.read = false
+}
+
+// Err returns the error that ended the iteration early, if any.
+func (f *ObjFile) Err() error {
+	if f.err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", f.Path, f.err)
+}
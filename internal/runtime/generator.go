@@ -0,0 +1,32 @@
+package runtime
+
+// GeneratorState tracks where a generator is in its lifecycle.
+type GeneratorState int
+
+const (
+	GENERATOR_SUSPENDED GeneratorState = iota // Not started yet, or paused at a 'yield'.
+	GENERATOR_RUNNING                         // Executing on its own execution stack.
+	GENERATOR_DONE                            // Returned or failed; it produces no more values.
+)
+
+// ObjGenerator is the object returned by calling a 'function*'. The call does not run the body;
+// the generator keeps its own call frames and stack slots, which the VM swaps in whenever the
+// generator is resumed (by an 'iter' loop or next()) and sets aside again at each 'yield'.
+type ObjGenerator struct {
+	Obj
+	Function *ObjFunction
+	State    GeneratorState
+	Stack    any   // The saved execution stack; owned by the VM and released once done.
+	Buffered bool  // Value holds a yielded value that has not been consumed yet.
+	Value    Value // The most recent yielded value while Buffered is set.
+}
+
+// NewGenerator creates a suspended generator for function with the given saved execution stack.
+func NewGenerator(function *ObjFunction, stack any) *ObjGenerator {
+	return &ObjGenerator{
+		Obj:      Obj{Type: OBJ_GENERATOR},
+		Function: function,
+		State:    GENERATOR_SUSPENDED,
+		Stack:    stack,
+	}
+}
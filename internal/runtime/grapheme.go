@@ -0,0 +1,160 @@
+package runtime
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// Graphemes splits s into user-perceived characters (grapheme clusters). It follows the main rules
+// of Unicode text segmentation (UAX #29): CR LF stays together and other control characters stand
+// alone, combining and spacing marks, variation selectors, emoji modifiers and tags attach to the
+// preceding character, prepended concatenation marks attach to the following one, zero-width-joiner
+// sequences form a single emoji, regional indicators pair up into flags and Hangul jamo and
+// syllables combine as in rules GB6 to GB8. It is an approximation: Indic conjuncts (GB9c) are not
+// joined, a zero-width joiner glues on the next character whether or not it is an emoji, and the
+// few spacing marks the standard leaves out of SpacingMark still attach.
+func Graphemes(s string) []string {
+	clusters := make([]string, 0, len(s))
+	for len(s) > 0 {
+		size := graphemeLength(s)
+		clusters = append(clusters, s[:size])
+		s = s[size:]
+	}
+	return clusters
+}
+
+// GraphemeCount returns the number of grapheme clusters in s.
+func GraphemeCount(s string) int {
+	count := 0
+	for len(s) > 0 {
+		s = s[graphemeLength(s):]
+		count++
+	}
+	return count
+}
+
+// graphemeLength returns the byte length of the grapheme cluster at the start of s.
+func graphemeLength(s string) int {
+	first, size := utf8.DecodeRuneInString(s)
+	if first == '\r' && len(s) > size && s[size] == '\n' {
+		return size + 1
+	}
+	if isGraphemeControl(first) {
+		return size
+	}
+	prev := first
+	regionalPairDone := false
+	for size < len(s) {
+		r, n := utf8.DecodeRuneInString(s[size:])
+		switch {
+		case isGraphemeControl(r):
+			return size
+		case isGraphemeExtend(r):
+			// Combining and spacing marks, variation selectors, emoji modifiers and tags.
+		case isGraphemePrepend(prev):
+			// Concatenation marks such as the Arabic number sign attach to what follows them.
+		case prev == zeroWidthJoiner:
+			// The character after a zero-width joiner belongs to the same emoji sequence.
+		case isRegionalIndicator(prev) && isRegionalIndicator(r) && !regionalPairDone:
+			regionalPairDone = true
+		case isHangulJoin(prev, r):
+			// Leading consonant + vowel (+ trailing consonant), or a syllable + its trailing jamo.
+		default:
+			return size
+		}
+		prev = r
+		size += n
+	}
+	return size
+}
+
+// isGraphemeControl reports whether r is a control or line/paragraph separator, which always
+// forms a cluster of its own.
+func isGraphemeControl(r rune) bool {
+	return unicode.Is(unicode.Cc, r) || r == '\u2028' || r == '\u2029'
+}
+
+// isGraphemeExtend reports whether r never starts a cluster of its own.
+func isGraphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // Emoji skin tone modifiers.
+		(r >= 0xE0020 && r <= 0xE007F) // Tag characters used by subdivision flags.
+}
+
+// graphemePrepend lists the characters with Grapheme_Cluster_Break=Prepend as of Unicode 15.
+var graphemePrepend = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0600, Hi: 0x0605, Stride: 1},
+		{Lo: 0x06DD, Hi: 0x06DD, Stride: 1},
+		{Lo: 0x070F, Hi: 0x070F, Stride: 1},
+		{Lo: 0x0890, Hi: 0x0891, Stride: 1},
+		{Lo: 0x08E2, Hi: 0x08E2, Stride: 1},
+		{Lo: 0x0D4E, Hi: 0x0D4E, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x110BD, Hi: 0x110BD, Stride: 1},
+		{Lo: 0x110CD, Hi: 0x110CD, Stride: 1},
+		{Lo: 0x111C2, Hi: 0x111C3, Stride: 1},
+		{Lo: 0x1193F, Hi: 0x1193F, Stride: 1},
+		{Lo: 0x11941, Hi: 0x11941, Stride: 1},
+		{Lo: 0x11A3A, Hi: 0x11A3A, Stride: 1},
+		{Lo: 0x11A84, Hi: 0x11A89, Stride: 1},
+		{Lo: 0x11D46, Hi: 0x11D46, Stride: 1},
+		{Lo: 0x11F02, Hi: 0x11F02, Stride: 1},
+	},
+}
+
+// isGraphemePrepend reports whether r joins the character that follows it.
+func isGraphemePrepend(r rune) bool {
+	return unicode.Is(graphemePrepend, r)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// Hangul syllable types used by rules GB6 to GB8.
+const (
+	hangulNone     = iota
+	hangulLeading  // L: leading consonant jamo
+	hangulVowel    // V: vowel jamo
+	hangulTrailing // T: trailing consonant jamo
+	hangulLV       // LV: precomposed syllable without a trailing consonant
+	hangulLVT      // LVT: precomposed syllable with a trailing consonant
+)
+
+// hangulType returns the Hangul syllable type of r.
+func hangulType(r rune) int {
+	switch {
+	case (r >= 0x1100 && r <= 0x115F) || (r >= 0xA960 && r <= 0xA97C):
+		return hangulLeading
+	case (r >= 0x1160 && r <= 0x11A7) || (r >= 0xD7B0 && r <= 0xD7C6):
+		return hangulVowel
+	case (r >= 0x11A8 && r <= 0x11FF) || (r >= 0xD7CB && r <= 0xD7FB):
+		return hangulTrailing
+	case r >= 0xAC00 && r <= 0xD7A3:
+		// Every 28th syllable, starting with 가, has no trailing consonant.
+		if (r-0xAC00)%28 == 0 {
+			return hangulLV
+		}
+		return hangulLVT
+	}
+	return hangulNone
+}
+
+// isHangulJoin reports whether the jamo or syllable r continues the syllable ending in prev.
+func isHangulJoin(prev, r rune) bool {
+	next := hangulType(r)
+	switch hangulType(prev) {
+	case hangulLeading:
+		return next == hangulLeading || next == hangulVowel || next == hangulLV || next == hangulLVT
+	case hangulVowel, hangulLV:
+		return next == hangulVowel || next == hangulTrailing
+	case hangulTrailing, hangulLVT:
+		return next == hangulTrailing
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package runtime
+
+// Iterator is implemented by the native iterator objects that 'iter' loops and the iter_* natives
+// step through.
+type Iterator interface {
+	Done() bool   // Reports whether every value has been produced.
+	Value() Value // Returns the current value; only valid while Done is false.
+	Advance()     // Moves to the next value.
+}
+
+// FallibleIterator is implemented by iterators whose source can fail part way, such as a file
+// being read. Err returns the error that ended the iteration, once Done reports true.
+type FallibleIterator interface {
+	Iterator
+	Err() error
+}
+
+// Done reports whether the array iterator has passed the last element.
+func (it *ObjArrayIterator) Done() bool {
+	return it.Index >= len(it.Array.Elements)
+}
+
+// Value returns the current array element.
+func (it *ObjArrayIterator) Value() Value {
+	return it.Array.Elements[it.Index]
+}
+
+// Advance moves to the next array element.
+func (it *ObjArrayIterator) Advance() {
+	it.Index++
+}
+
+// ObjMapIterator walks the keys of a map, or its [key, value] pairs. The keys are captured when the
+// iterator is created, so entries added during the loop are not visited; removed entries are skipped.
+type ObjMapIterator struct {
+	Obj
+	Map   *ObjMap
+	Keys  []Value
+	Index int
+	Pairs bool // Yield [key, value] arrays instead of keys.
+}
+
+// NewMapIterator creates an iterator over the keys (or [key, value] pairs) of m in insertion order.
+func NewMapIterator(m *ObjMap, pairs bool) *ObjMapIterator {
+	it := &ObjMapIterator{Obj: Obj{Type: OBJ_MAP_ITERATOR}, Map: m, Keys: m.Keys(), Pairs: pairs}
+	it.skipRemoved()
+	return it
+}
+
+// skipRemoved moves past keys that are no longer in the map.
+func (it *ObjMapIterator) skipRemoved() {
+	for it.Index < len(it.Keys) {
+		if it.Map.Has(it.Keys[it.Index]) {
+			return
+		}
+		it.Index++
+	}
+}
+
+// Done reports whether every key has been visited.
+func (it *ObjMapIterator) Done() bool {
+	it.skipRemoved()
+	return it.Index >= len(it.Keys)
+}
+
+// Value returns the current key, or a [key, value] pair.
+func (it *ObjMapIterator) Value() Value {
+	key := it.Keys[it.Index]
+	if !it.Pairs {
+		return key
+	}
+	value, _ := it.Map.Get(key)
+	return ObjVal(NewArray([]Value{key, value}))
+}
+
+// Advance moves to the next key.
+func (it *ObjMapIterator) Advance() {
+	it.Index++
+}
+
+// ObjStringIterator walks a string one grapheme cluster at a time, so "e" followed by a combining
+// accent or a multi-codepoint emoji is produced as a single string.
+type ObjStringIterator struct {
+	Obj
+	Graphemes []string
+	Index     int
+}
+
+// NewStringIterator creates an iterator over the grapheme clusters of s.
+func NewStringIterator(s string) *ObjStringIterator {
+	return &ObjStringIterator{Obj: Obj{Type: OBJ_STRING_ITERATOR}, Graphemes: Graphemes(s)}
+}
+
+// Done reports whether every grapheme has been produced.
+func (it *ObjStringIterator) Done() bool {
+	return it.Index >= len(it.Graphemes)
+}
+
+// Value returns the current grapheme as a string.
+func (it *ObjStringIterator) Value() Value {
+	return ObjVal(NewObjString(it.Graphemes[it.Index]))
+}
+
+// Advance moves to the next grapheme.
+func (it *ObjStringIterator) Advance() {
+	it.Index++
+}
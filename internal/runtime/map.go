@@ -0,0 +1,179 @@
+package runtime
+
+import (
+	"iter"
+	"math"
+)
+
+// MapEntry is a key/value pair stored in an ObjMap.
+type MapEntry struct {
+	Key     Value
+	Value   Value
+	removed bool
+}
+
+// hashKeyKind tells apart the kinds of values that can be map keys.
+type hashKeyKind uint8
+
+const (
+	keyNull     hashKeyKind = iota // null
+	keyBool                        // true or false (n is 0 or 1)
+	keyInt                         // A number with an integral value that fits in an int64 (n).
+	keyRational                    // Any other finite number, as an exact fraction (s).
+	keyFloat                       // NaN or an infinity (n holds the float's bits).
+	keyString                      // A string (s).
+)
+
+// hashKey is the comparable form of a map key. Keys that are == in the language share a hashKey,
+// so 1, 1.0, 1n and 1.00 all name the same entry, as do two strings with the same characters.
+type hashKey struct {
+	kind hashKeyKind
+	n    int64
+	s    string
+}
+
+// IsHashable reports whether v can be used as a map key: strings, numbers, booleans and null.
+// Arrays, maps and instances are mutable, so their hash could change while they are stored. The
+// language has no enum or tuple types, so there is no key kind for them; adding one means a new
+// hashKeyKind whose s encodes the parts.
+func IsHashable(v Value) bool {
+	_, ok := makeHashKey(v)
+	return ok
+}
+
+// makeHashKey returns the hash key for v, or false if v cannot be a map key.
+func makeHashKey(v Value) (hashKey, bool) {
+	switch v.Type {
+	case VAL_NULL:
+		return hashKey{kind: keyNull}, true
+	case VAL_BOOL:
+		if v.Bool {
+			return hashKey{kind: keyBool, n: 1}, true
+		}
+		return hashKey{kind: keyBool}, true
+	case VAL_INT:
+		return hashKey{kind: keyInt, n: v.Int}, true
+	case VAL_NUMBER:
+		if v.Number == math.Trunc(v.Number) && v.Number >= math.MinInt64 && v.Number < math.MaxInt64 {
+			return hashKey{kind: keyInt, n: int64(v.Number)}, true
+		}
+		if math.IsNaN(v.Number) || math.IsInf(v.Number, 0) {
+			return hashKey{kind: keyFloat, n: int64(math.Float64bits(v.Number))}, true
+		}
+	case VAL_OBJ:
+		if s, ok := v.Obj.(*ObjString); ok {
+			return hashKey{kind: keyString, s: s.Chars}, true
+		}
+		if !IsExactNumber(v) {
+			return hashKey{}, false
+		}
+	default:
+		return hashKey{}, false
+	}
+	// Fractional floats, BigInts and Decimals: use the exact value so equal numbers of different
+	// types collide.
+	r, _ := AsRat(v)
+	if r.IsInt() && r.Num().IsInt64() {
+		return hashKey{kind: keyInt, n: r.Num().Int64()}, true
+	}
+	return hashKey{kind: keyRational, s: r.RatString()}, true
+}
+
+// Len returns the number of entries in the map.
+func (m *ObjMap) Len() int {
+	return len(m.index)
+}
+
+// Get returns the value stored under key.
+func (m *ObjMap) Get(key Value) (Value, bool) {
+	hash, ok := makeHashKey(key)
+	if !ok {
+		return Value{}, false
+	}
+	if i, ok := m.index[hash]; ok {
+		return m.entries[i].Value, true
+	}
+	return Value{}, false
+}
+
+// Has reports whether the map contains key.
+func (m *ObjMap) Has(key Value) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Set stores value under key and reports false if key is not hashable. A new key goes to the end;
+// an existing key keeps its position (and its original spelling, e.g. 1 stays 1 after m[1.0] = x).
+func (m *ObjMap) Set(key Value, value Value) bool {
+	hash, ok := makeHashKey(key)
+	if !ok {
+		return false
+	}
+	if i, ok := m.index[hash]; ok {
+		m.entries[i].Value = value
+		return true
+	}
+	m.index[hash] = len(m.entries)
+	m.entries = append(m.entries, MapEntry{Key: key, Value: value})
+	return true
+}
+
+// Delete removes key and reports whether it was present. The remaining entries keep their order.
+func (m *ObjMap) Delete(key Value) bool {
+	hash, ok := makeHashKey(key)
+	if !ok {
+		return false
+	}
+	i, ok := m.index[hash]
+	if !ok {
+		return false
+	}
+	delete(m.index, hash)
+	m.entries[i] = MapEntry{removed: true}
+	// Compact once holes make up most of the slice so iteration stays proportional to Len.
+	if len(m.entries) > 8 && len(m.index) < len(m.entries)/2 {
+		m.compact()
+	}
+	return true
+}
+
+// Clear removes every entry.
+func (m *ObjMap) Clear() {
+	m.entries = nil
+	m.index = make(map[hashKey]int)
+}
+
+// compact drops removed entries and rebuilds the index.
+func (m *ObjMap) compact() {
+	live := make([]MapEntry, 0, len(m.index))
+	for _, entry := range m.entries {
+		if !entry.removed {
+			hash, _ := makeHashKey(entry.Key)
+			m.index[hash] = len(live)
+			live = append(live, entry)
+		}
+	}
+	m.entries = live
+}
+
+// Keys returns the keys in insertion order.
+func (m *ObjMap) Keys() []Value {
+	keys := make([]Value, 0, len(m.index))
+	for key := range m.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// All iterates over the entries in insertion order. The map must not change during the loop; use
+// a map iterator (which works from a snapshot of the keys) when it might.
+func (m *ObjMap) All() iter.Seq2[Value, Value] {
+	return func(yield func(Value, Value) bool) {
+		for i := 0; i < len(m.entries); i++ {
+			entry := m.entries[i]
+			if !entry.removed && !yield(entry.Key, entry.Value) {
+				return
+			}
+		}
+	}
+}
@@ -12,20 +12,34 @@ type ObjType int
 
 // Enumeration of object types.
 const (
-	OBJ_UPVALUE        ObjType = iota // Upvalue: a variable captured from an outer scope.
-	OBJ_CLOSURE                       // Closure: a function plus its captured environment.
-	OBJ_FUNCTION                      // Function: a user-defined function.
-	OBJ_NATIVE                        // Native: a built-in (native) function.
-	OBJ_STRING                        // String: an immutable string.
-	OBJ_STRUCT                        // Struct: a user-defined struct type.
-	OBJ_INSTANCE                      // Instance: an instance of a struct.
-	OBJ_ARRAY                         // Array: a dynamic array.
-	OBJ_ARRAY_ITERATOR                // Array Iterator: iterator for arrays.
-	OBJ_MODULE                        // Module: a module containing functions, variables and other modules.
-	OBJ_MAP                           // Map: a key-value store.
-	OBJ_DATE                          // Date object (year, month, day)
-	OBJ_TIME                          // Time object (hour, minute, second)
-	OBJ_DATETIME                      // DateTime represents a combined date and time.
+	OBJ_UPVALUE         ObjType = iota // Upvalue: a variable captured from an outer scope.
+	OBJ_CLOSURE                        // Closure: a function plus its captured environment.
+	OBJ_FUNCTION                       // Function: a user-defined function.
+	OBJ_NATIVE                         // Native: a built-in (native) function.
+	OBJ_STRING                         // String: an immutable string.
+	OBJ_STRUCT                         // Struct: a user-defined struct type.
+	OBJ_INSTANCE                       // Instance: an instance of a struct.
+	OBJ_ARRAY                          // Array: a dynamic array.
+	OBJ_ARRAY_ITERATOR                 // Array Iterator: iterator for arrays.
+	OBJ_MODULE                         // Module: a module containing functions, variables and other modules.
+	OBJ_MAP                            // Map: a key-value store.
+	OBJ_DATE                           // Date object (year, month, day)
+	OBJ_TIME                           // Time object (hour, minute, second)
+	OBJ_DATETIME                       // DateTime represents a combined date and time.
+	OBJ_BIGINT                         // BigInt: an arbitrary-precision integer.
+	OBJ_DECIMAL                        // Decimal: an exact decimal number.
+	OBJ_MAP_ITERATOR                   // Map Iterator: iterator over map keys or entries.
+	OBJ_STRING_ITERATOR                // String Iterator: iterator over the graphemes of a string.
+	OBJ_RANGE                          // Range: a lazy sequence of integers.
+	OBJ_RANGE_ITERATOR                 // Range Iterator: iterator over a range.
+	OBJ_GENERATOR                      // Generator: a suspended 'function*' call.
+	OBJ_SET                            // Set: a collection of distinct hashable values.
+	OBJ_CSV_READER                     // CSV Reader: iterator over the records of a CSV file.
+	OBJ_REGEX                          // Regex: a compiled regular expression.
+	OBJ_ERROR                          // Error: a failure returned as a value.
+	OBJ_FILE                           // File: an open file read and written through buffers.
+	OBJ_BYTES                          // Bytes: a mutable buffer of bytes.
+	OBJ_BYTES_ITERATOR                 // Bytes Iterator: iterator over the bytes of a buffer.
 )
 
 // Obj is the header for all heap-allocated objects.
@@ -66,6 +80,7 @@ type ObjFunction struct {
 	UpvalueCount int        // Number of upvalues the function captures.
 	Chunk        Chunk      // Bytecode chunk containing the function's code.
 	Name         *ObjString // Optional function name.
+	IsGenerator  bool       // Calling the function creates a generator instead of running it.
 }
 
 // ObjString represents an immutable string.
@@ -236,17 +251,19 @@ func NewModule(name *ObjString) *ObjModule {
 	}
 }
 
-// ObjMap represents a hash map with key-value pairs.
+// ObjMap represents a hash map with key-value pairs. Entries keep the order in which their keys
+// were first inserted, while lookups go through a hash index.
 type ObjMap struct {
 	Obj
-	Entries map[*ObjString]Value // Map of keys (strings) to values.
+	entries []MapEntry      // Entries in insertion order; removed ones are left as holes.
+	index   map[hashKey]int // Position of each key in entries.
 }
 
 // NewMap creates a new empty hash map object.
 func NewMap() *ObjMap {
 	return &ObjMap{
-		Obj:     Obj{Type: OBJ_MAP},
-		Entries: make(map[*ObjString]Value),
+		Obj:   Obj{Type: OBJ_MAP},
+		index: make(map[hashKey]int),
 	}
 }
 
@@ -334,16 +351,50 @@ func PrintObject(obj interface{}) {
 		fmt.Print("]")
 	case *ObjArrayIterator:
 		fmt.Printf("<array iterator at %d>", o.Index)
+	case *ObjMapIterator:
+		fmt.Printf("<map iterator at %d>", o.Index)
+	case *ObjStringIterator:
+		fmt.Printf("<string iterator at %d>", o.Index)
+	case *ObjRange:
+		fmt.Print(o.String())
+	case *ObjRangeIterator:
+		fmt.Printf("<range iterator at %d>", o.Index)
+	case *ObjCSVReader:
+		fmt.Printf("<csv reader %s>", o.Path)
+	case *ObjRegex:
+		fmt.Print(o.String())
+	case *ObjError:
+		fmt.Print(o.String())
+	case *ObjFile:
+		fmt.Printf("<file %s>", o.Path)
+	case *ObjBytes:
+		fmt.Print(o.String())
+	case *ObjBytesIterator:
+		fmt.Printf("<bytes iterator at %d>", o.Index)
+	case *ObjGenerator:
+		fmt.Printf("<generator %s>", o.Function.Name.Chars)
 	case *ObjModule:
 		fmt.Printf("<mod %s>", o.Name.Chars)
 	case *ObjMap:
 		fmt.Print("{")
 		first := true
-		for key, value := range o.Entries {
+		for key, value := range o.All() {
+			if !first {
+				fmt.Print(", ")
+			}
+			PrintValue(key)
+			fmt.Print(": ")
+			PrintValue(value)
+			first = false
+		}
+		fmt.Print("}")
+	case *ObjSet:
+		fmt.Print("#{")
+		first := true
+		for value := range o.All() {
 			if !first {
 				fmt.Print(", ")
 			}
-			fmt.Printf("%s: ", key.Chars)
 			PrintValue(value)
 			first = false
 		}
@@ -354,6 +405,10 @@ func PrintObject(obj interface{}) {
 		fmt.Printf("<Time %s>", o.Time.Format("15:04:05"))
 	case *ObjDateTime:
 		fmt.Printf("<DateTime %s>", o.Time.Format("2006-01-02 15:04:05"))
+	case *ObjBigInt:
+		fmt.Print(o.Value.String())
+	case *ObjDecimal:
+		fmt.Print(o.String())
 	default:
 		fmt.Print("unknown object")
 	}
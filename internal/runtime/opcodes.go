@@ -56,4 +56,23 @@ const (
 	OP_EXPONENTIAL
 	OP_FLOOR
 	OP_PERCENT
+	OP_BIT_AND
+	OP_BIT_OR
+	OP_BIT_XOR
+	OP_BIT_NOT
+	OP_SHIFT_LEFT
+	OP_SHIFT_RIGHT
+	OP_DESTRUCTURE_ARRAY
+	OP_DESTRUCTURE_INDEX
+	OP_DESTRUCTURE_REST
+	OP_DESTRUCTURE_KEY
+	OP_ARRAY_SPREAD
+	OP_MAP_SPREAD
+	OP_CALL_SPREAD
+	OP_ITER
+	OP_ITER_NEXT
+	OP_RANGE
+	OP_IN
+	OP_YIELD
+	OP_SET
 )
@@ -0,0 +1,122 @@
+package runtime
+
+import (
+	"fmt"
+	"math"
+)
+
+// Flags in the operand of OP_RANGE.
+const (
+	RANGE_INCLUSIVE = 1 << iota // The end value is part of the range ('..=').
+	RANGE_HAS_STEP              // A step value is on the stack above the end value.
+)
+
+// ObjRange is a lazy sequence of integers written as start..end (end excluded), start..=end (end
+// included) and optionally 'step n'. Values are computed on demand, so 0..1_000_000 costs the same
+// as 0..3.
+type ObjRange struct {
+	Obj
+	Start     int64
+	End       int64
+	Step      int64 // Never zero.
+	Inclusive bool
+}
+
+// NewRange creates a range. step must not be zero.
+func NewRange(start, end, step int64, inclusive bool) *ObjRange {
+	return &ObjRange{
+		Obj:       Obj{Type: OBJ_RANGE},
+		Start:     start,
+		End:       end,
+		Step:      step,
+		Inclusive: inclusive,
+	}
+}
+
+// span returns how far End lies from Start in the direction of the step, and the size of the
+// step. Both are unsigned so that ranges covering most of int64 (e.g.,
+// -9223372036854775807..9223372036854775807) do not overflow. ok is false when End lies behind
+// Start, i.e. the range is empty.
+func (r *ObjRange) span() (distance, step uint64, ok bool) {
+	if r.Step > 0 {
+		return uint64(r.End) - uint64(r.Start), uint64(r.Step), r.End >= r.Start
+	}
+	return uint64(r.Start) - uint64(r.End), -uint64(r.Step), r.Start >= r.End
+}
+
+// Len returns the number of values in the range. A range with more than math.MaxInt64 values
+// reports math.MaxInt64.
+func (r *ObjRange) Len() int64 {
+	distance, step, ok := r.span()
+	if !ok || (distance == 0 && !r.Inclusive) {
+		return 0
+	}
+	if !r.Inclusive {
+		distance--
+	}
+	count := distance / step
+	if count >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(count) + 1
+}
+
+// At returns the i-th value of the range (0 <= i < Len()).
+func (r *ObjRange) At(i int64) int64 {
+	return r.Start + i*r.Step
+}
+
+// Contains reports whether n is one of the values of the range.
+func (r *ObjRange) Contains(n int64) bool {
+	distance, step, ok := r.span()
+	if !ok || (r.Step > 0 && n < r.Start) || (r.Step < 0 && n > r.Start) {
+		return false
+	}
+	offset := uint64(n) - uint64(r.Start)
+	if r.Step < 0 {
+		offset = uint64(r.Start) - uint64(n)
+	}
+	if offset%step != 0 {
+		return false
+	}
+	return offset < distance || (offset == distance && r.Inclusive)
+}
+
+// String formats the range the way it is written in source (e.g., "10..0 step -2").
+func (r *ObjRange) String() string {
+	op := ".."
+	if r.Inclusive {
+		op = "..="
+	}
+	if r.Step == 1 {
+		return fmt.Sprintf("%d%s%d", r.Start, op, r.End)
+	}
+	return fmt.Sprintf("%d%s%d step %d", r.Start, op, r.End, r.Step)
+}
+
+// ObjRangeIterator walks the values of a range.
+type ObjRangeIterator struct {
+	Obj
+	Range *ObjRange
+	Index int64
+}
+
+// NewRangeIterator creates an iterator positioned at the first value of r.
+func NewRangeIterator(r *ObjRange) *ObjRangeIterator {
+	return &ObjRangeIterator{Obj: Obj{Type: OBJ_RANGE_ITERATOR}, Range: r}
+}
+
+// Done reports whether every value has been produced.
+func (it *ObjRangeIterator) Done() bool {
+	return it.Index >= it.Range.Len()
+}
+
+// Value returns the current value as an int.
+func (it *ObjRangeIterator) Value() Value {
+	return IntVal(it.Range.At(it.Index))
+}
+
+// Advance moves to the next value.
+func (it *ObjRangeIterator) Advance() {
+	it.Index++
+}
@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ObjRegex is a compiled regular expression, created by a /.../ literal or the regex() native.
+// The pattern is compiled once, when the literal is compiled or the native is called, and can
+// then be matched any number of times.
+type ObjRegex struct {
+	Obj
+	Regexp *regexp.Regexp
+	Source string // The pattern as written, without the flags.
+	Flags  string // Any of i (case-insensitive), m (multi-line), s (dot matches newline) and U (ungreedy).
+}
+
+// NewRegex compiles source with the given flags, using Go's RE2 syntax.
+func NewRegex(source, flags string) (*ObjRegex, error) {
+	pattern := source
+	if flags != "" {
+		for _, flag := range flags {
+			if flag != 'i' && flag != 'm' && flag != 's' && flag != 'U' {
+				return nil, fmt.Errorf("unknown flag '%c'; the supported flags are i, m, s and U", flag)
+			}
+		}
+		pattern = "(?" + flags + ")" + source
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjRegex{
+		Obj:    Obj{Type: OBJ_REGEX},
+		Regexp: compiled,
+		Source: source,
+		Flags:  flags,
+	}, nil
+}
+
+// String returns the regex in literal form, e.g. /a+b/i.
+func (r *ObjRegex) String() string {
+	return "/" + r.Source + "/" + r.Flags
+}
@@ -0,0 +1,62 @@
+package runtime
+
+import "iter"
+
+// ObjSet is an unordered collection of distinct hashable values. It is backed by a map whose
+// values are unused, so elements follow the same key rules as maps (1 and 1.0 are one element)
+// and keep their insertion order for printing and iteration.
+type ObjSet struct {
+	Obj
+	items *ObjMap
+}
+
+// NewSet creates a new empty set.
+func NewSet() *ObjSet {
+	return &ObjSet{Obj: Obj{Type: OBJ_SET}, items: NewMap()}
+}
+
+// Len returns the number of elements in the set.
+func (s *ObjSet) Len() int {
+	return s.items.Len()
+}
+
+// Has reports whether value is an element of the set.
+func (s *ObjSet) Has(value Value) bool {
+	return s.items.Has(value)
+}
+
+// Add inserts value and reports false if it is not hashable. Adding an element that is already
+// present has no effect.
+func (s *ObjSet) Add(value Value) bool {
+	if s.items.Has(value) {
+		return true
+	}
+	return s.items.Set(value, Value{Type: VAL_NULL})
+}
+
+// Remove deletes value and reports whether it was present.
+func (s *ObjSet) Remove(value Value) bool {
+	return s.items.Delete(value)
+}
+
+// Values returns the elements in insertion order.
+func (s *ObjSet) Values() []Value {
+	return s.items.Keys()
+}
+
+// All iterates over the elements in insertion order. The set must not change during the loop.
+func (s *ObjSet) All() iter.Seq[Value] {
+	return func(yield func(Value) bool) {
+		for value := range s.items.All() {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// NewSetIterator creates an iterator over the elements of s. Like a map iterator, it works from a
+// snapshot, so elements added during the loop are not visited and removed ones are skipped.
+func NewSetIterator(s *ObjSet) *ObjMapIterator {
+	return NewMapIterator(s.items, false)
+}
@@ -2,6 +2,7 @@ package runtime
 
 import (
 	"fmt"
+	"math"
 )
 
 type ValueType int
@@ -10,6 +11,7 @@ const (
 	VAL_BOOL ValueType = iota
 	VAL_NULL
 	VAL_NUMBER
+	VAL_INT
 	VAL_OBJ
 )
 
@@ -17,9 +19,44 @@ type Value struct {
 	Type   ValueType
 	Bool   bool
 	Number float64
+	Int    int64
 	Obj    interface{}
 }
 
+// IntVal wraps a 64-bit integer in a Value.
+func IntVal(i int64) Value {
+	return Value{Type: VAL_INT, Int: i}
+}
+
+// IsNumber reports whether v is numeric, either a float (VAL_NUMBER) or an integer (VAL_INT).
+func IsNumber(v Value) bool {
+	return v.Type == VAL_NUMBER || v.Type == VAL_INT
+}
+
+// AsNumber returns the numeric value of v as a float64, promoting integers.
+func AsNumber(v Value) float64 {
+	if v.Type == VAL_INT {
+		return float64(v.Int)
+	}
+	return v.Number
+}
+
+// AsInt returns the value of v as an int64. Integers convert exactly and floats only when
+// they have no fractional part and fit in 64 bits; ok is false otherwise.
+func AsInt(v Value) (int64, bool) {
+	switch v.Type {
+	case VAL_INT:
+		return v.Int, true
+	case VAL_NUMBER:
+		if v.Number != math.Trunc(v.Number) || v.Number < math.MinInt64 || v.Number >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v.Number), true
+	default:
+		return 0, false
+	}
+}
+
 type ValueArray struct {
 	values   []Value
 	count    int
@@ -70,12 +107,27 @@ func PrintValue(v Value) {
 		fmt.Print("null")
 	case VAL_NUMBER:
 		fmt.Printf("%g", v.Number)
+	case VAL_INT:
+		fmt.Print(v.Int)
 	case VAL_OBJ:
 		PrintObject(v.Obj)
 	}
 }
 
+// Equal implements '=='. Numbers compare by value across representations and strings by their
+// characters; every other object compares by identity, so two arrays are equal only if they are
+// the same array. DeepEqual compares contents instead.
 func Equal(a, b Value) bool {
+	if IsExactNumber(a) || IsExactNumber(b) {
+		// BigInt and Decimal compare by exact value with every numeric type: 1n == 1 == 1.00.
+		x, okA := AsRat(a)
+		y, okB := AsRat(b)
+		return okA && okB && x.Cmp(y) == 0
+	}
+	if IsNumber(a) && IsNumber(b) && a.Type != b.Type {
+		// Mixed int/float comparison is numeric: 1 == 1.0.
+		return AsNumber(a) == AsNumber(b)
+	}
 	if a.Type != b.Type {
 		return false
 	}
@@ -86,13 +138,15 @@ func Equal(a, b Value) bool {
 		return true
 	case VAL_NUMBER:
 		return a.Number == b.Number
+	case VAL_INT:
+		return a.Int == b.Int
 	case VAL_OBJ:
 		aStr, okA := a.Obj.(*ObjString)
 		bStr, okB := b.Obj.(*ObjString)
 		if okA && okB {
 			return aStr.Chars == bStr.Chars
 		}
-		return false
+		return a.Obj == b.Obj
 	default:
 		return false
 	}
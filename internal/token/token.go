@@ -24,6 +24,9 @@ const (
 	TOKEN_HASH
 	TOKEN_DOLLAR
 	TOKEN_COLON
+	TOKEN_AMPERSAND
+	TOKEN_CARET
+	TOKEN_TILDE
 
 	// One or two character tokens
 	TOKEN_BANG
@@ -39,6 +42,11 @@ const (
 	TOKEN_STAR_STAR
 	TOKEN_FLOOR
 	TOKEN_PERCENT_PERCENT
+	TOKEN_LESS_LESS
+	TOKEN_GREATER_GREATER
+	TOKEN_DOT_DOT
+	TOKEN_DOT_DOT_EQUAL
+	TOKEN_DOT_DOT_DOT
 
 	// Literals
 	TOKEN_IDENTIFIER
@@ -46,6 +54,7 @@ const (
 	TOKEN_CHAR
 	TOKEN_STRING
 	TOKEN_NUMBER
+	TOKEN_REGEX
 
 	// Keywords
 	TOKEN_AND
@@ -79,6 +88,7 @@ const (
 	TOKEN_MOD
 	TOKEN_AS
 	TOKEN_USE
+	TOKEN_YIELD
 	TOKEN_ERROR
 	TOKEN_EOF
 )
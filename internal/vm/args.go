@@ -14,5 +14,5 @@ func defineArgs(args []string) {
 
 	// Define the "args" global as an array.
 	argsName := runtime.NewObjString("args")
-	vm.globals[argsName] = GlobalVar{Value: runtime.ObjVal(runtime.NewArray(elements)), IsConst: false}
+	vm.globals[argsName] = GlobalVar{Value: runtime.ObjVal(runtime.NewArray(elements)), IsConst: true, IsBuiltin: coreBuiltins["args"]}
 }
@@ -0,0 +1,368 @@
+package vm
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Native Functions: Bytes
+// ============================================================================
+
+// maxBytesSize bounds the size of a buffer created from a number, so a bad size is a runtime error
+// instead of a crash or an attempt to allocate all memory.
+const maxBytesSize = 1 << 30
+
+// bytesIndex converts index to a position in b, reporting a runtime error if it is not an
+// integer within the buffer.
+func bytesIndex(b *runtime.ObjBytes, index runtime.Value) (int, bool) {
+	idx, ok := runtime.AsInt(index)
+	if !ok {
+		runtimeError("Bytes index must be an integer.")
+		return 0, false
+	}
+	if idx < 0 || idx >= int64(len(b.Data)) {
+		runtimeError("Bytes index out of bounds.")
+		return 0, false
+	}
+	return int(idx), true
+}
+
+// bytesArg returns args[index] of the named native, which must be bytes.
+func bytesArg(name string, args []runtime.Value, index int) (*runtime.ObjBytes, bool) {
+	b, ok := args[index].Obj.(*runtime.ObjBytes)
+	if args[index].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' expects bytes as argument %d (got %s).", name, index+1, typeName(args[index]))
+		return nil, false
+	}
+	return b, true
+}
+
+// bytesNative creates a bytes object: with no argument an empty one, from an integer n a buffer
+// of n zero bytes, from a string its UTF-8 encoding, from an array of integers from 0 to 255
+// those bytes, and from bytes a copy.
+func bytesNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount > 1 {
+		runtimeError("'bytes' expects 0 or 1 arguments (a size, string, array or bytes).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if argCount == 0 {
+		return runtime.ObjVal(runtime.NewBytes([]byte{}))
+	}
+	if size, ok := runtime.AsInt(args[0]); ok {
+		if size < 0 || size > maxBytesSize {
+			runtimeError("'bytes' size must be from 0 to %d (got %d).", maxBytesSize, size)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		return runtime.ObjVal(runtime.NewBytes(make([]byte, size)))
+	}
+	if args[0].Type == runtime.VAL_OBJ {
+		switch obj := args[0].Obj.(type) {
+		case *runtime.ObjString:
+			return runtime.ObjVal(runtime.NewBytes([]byte(obj.Chars)))
+		case *runtime.ObjBytes:
+			return runtime.ObjVal(runtime.NewBytes(append([]byte{}, obj.Data...)))
+		case *runtime.ObjArray:
+			data := make([]byte, len(obj.Elements))
+			for i, element := range obj.Elements {
+				b, ok := runtime.AsInt(element)
+				if !ok || b < 0 || b > 255 {
+					runtimeError("'bytes' array elements must be integers from 0 to 255 (element %d is %s).", i, toStr(1, []runtime.Value{element}).Obj.(*runtime.ObjString).Chars)
+					return runtime.Value{Type: runtime.VAL_NULL}
+				}
+				data[i] = byte(b)
+			}
+			return runtime.ObjVal(runtime.NewBytes(data))
+		}
+	}
+	runtimeError("'bytes' expects a size, string, array or bytes (got %s).", typeName(args[0]))
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// bytesToStringNative decodes bytes as UTF-8 text. Bytes that are not valid UTF-8 are a runtime
+// error.
+func bytesToStringNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'bytes_to_string' expects 1 argument (bytes).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	b, ok := bytesArg("bytes_to_string", args, 0)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if !utf8.Valid(b.Data) {
+		runtimeError("'bytes_to_string' expects UTF-8 encoded bytes.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(runtime.NewObjString(string(b.Data)))
+}
+
+// bytesDecoder builds a native that decodes text, such as hexadecimal, to bytes. Malformed text
+// is a runtime error.
+func bytesDecoder(name string, decode func(string) ([]byte, error)) runtime.NativeFn {
+	return func(argCount int, args []runtime.Value) runtime.Value {
+		if argCount != 1 {
+			runtimeError("'%s' expects 1 argument (string).", name)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		text, ok := stringArg(name, args, 0, "encoded text")
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		data, err := decode(text)
+		if err != nil {
+			runtimeError("'%s' failed: %v", name, err)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		return runtime.ObjVal(runtime.NewBytes(data))
+	}
+}
+
+// bytesConcatNative joins any number of bytes objects into a new one.
+func bytesConcatNative(argCount int, args []runtime.Value) runtime.Value {
+	data := []byte{}
+	for i := 0; i < argCount; i++ {
+		b, ok := bytesArg("bytes_concat", args, i)
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		data = append(data, b.Data...)
+	}
+	return runtime.ObjVal(runtime.NewBytes(data))
+}
+
+// readFileBytesNative reads a whole file into bytes, returning an error value if it cannot be
+// read.
+func readFileBytesNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'read_file_bytes' expects 1 argument (file path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("read_file_bytes", args, 0, "file path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fsError(err)
+	}
+	return runtime.ObjVal(runtime.NewBytes(data))
+}
+
+// writeFileBytesNative writes bytes to a file, replacing its contents.
+func writeFileBytesNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'write_file_bytes' expects 2 arguments (file path, bytes).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("write_file_bytes", args, 0, "file path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	b, ok := bytesArg("write_file_bytes", args, 1)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return fsResult(os.WriteFile(path, b.Data, 0644))
+}
+
+// ============================================================================
+// Native Functions: Binary Packing
+// ============================================================================
+
+// A pack format is a string of field codes, optionally starting with '<' (little-endian, the
+// default) or '>' (big-endian). The codes are b/B for signed/unsigned 8-bit integers, h/H for
+// 16-bit, i/I for 32-bit and q/Q for 64-bit ones, f for a 32-bit float and d for a 64-bit one.
+// Spaces are ignored.
+
+// packByteOrder reads and appends the integers of a pack format in its byte order.
+type packByteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// packField is one field of a pack format.
+type packField struct {
+	code byte
+	size int
+}
+
+// packFieldSizes maps each field code to its size in bytes.
+var packFieldSizes = map[byte]int{'b': 1, 'B': 1, 'h': 2, 'H': 2, 'i': 4, 'I': 4, 'q': 8, 'Q': 8, 'f': 4, 'd': 8}
+
+// parsePackFormat splits a pack format into its byte order and fields.
+func parsePackFormat(name, format string) (packByteOrder, []packField, bool) {
+	var order packByteOrder = binary.LittleEndian
+	if strings.HasPrefix(format, ">") {
+		order, format = binary.BigEndian, format[1:]
+	} else if strings.HasPrefix(format, "<") {
+		format = format[1:]
+	}
+	var fields []packField
+	for i := 0; i < len(format); i++ {
+		if format[i] == ' ' {
+			continue
+		}
+		size, ok := packFieldSizes[format[i]]
+		if !ok {
+			runtimeError("'%s' format has an unknown code '%c'; expected one of b, B, h, H, i, I, q, Q, f and d.", name, format[i])
+			return nil, nil, false
+		}
+		fields = append(fields, packField{format[i], size})
+	}
+	return order, fields, true
+}
+
+// packNative encodes values as binary data laid out by a pack format, one value per field.
+func packNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount < 1 {
+		runtimeError("'bytes_pack' expects a format followed by one value per field.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	format, ok := stringArg("bytes_pack", args, 0, "format")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	order, fields, ok := parsePackFormat("bytes_pack", format)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if argCount-1 != len(fields) {
+		runtimeError("'bytes_pack' format '%s' has %d fields but %d values were given.", format, len(fields), argCount-1)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	var data []byte
+	for i, field := range fields {
+		value := args[i+1]
+		if field.code == 'f' || field.code == 'd' {
+			if !runtime.IsNumber(value) {
+				runtimeError("'bytes_pack' field %d ('%c') expects a number (got %s).", i+1, field.code, typeName(value))
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			if field.code == 'f' {
+				data = order.AppendUint32(data, math.Float32bits(float32(runtime.AsNumber(value))))
+			} else {
+				data = order.AppendUint64(data, math.Float64bits(runtime.AsNumber(value)))
+			}
+			continue
+		}
+		bits, ok := packInt(field, value)
+		if !ok {
+			runtimeError("'bytes_pack' field %d ('%c') expects an integer that fits in %d bits (got %s).", i+1, field.code, 8*field.size, toStr(1, []runtime.Value{value}).Obj.(*runtime.ObjString).Chars)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		switch field.size {
+		case 1:
+			data = append(data, byte(bits))
+		case 2:
+			data = order.AppendUint16(data, uint16(bits))
+		case 4:
+			data = order.AppendUint32(data, uint32(bits))
+		case 8:
+			data = order.AppendUint64(data, bits)
+		}
+	}
+	return runtime.ObjVal(runtime.NewBytes(data))
+}
+
+// packInt returns the bits of an integer value for an integer field, checking that it fits. A
+// Q field also takes a bigint up to 2^64-1.
+func packInt(field packField, value runtime.Value) (uint64, bool) {
+	bitCount := uint(8 * field.size)
+	signed := field.code >= 'a'
+	if bigInt, ok := value.Obj.(*runtime.ObjBigInt); ok && value.Type == runtime.VAL_OBJ {
+		if field.code == 'Q' && bigInt.Value.IsUint64() {
+			return bigInt.Value.Uint64(), true
+		}
+		return 0, false
+	}
+	n, ok := runtime.AsInt(value)
+	if !ok {
+		return 0, false
+	}
+	if signed {
+		if bitCount < 64 && (n < -(1<<(bitCount-1)) || n >= 1<<(bitCount-1)) {
+			return 0, false
+		}
+	} else if n < 0 || (bitCount < 64 && n >= 1<<bitCount) {
+		return 0, false
+	}
+	return uint64(n), true
+}
+
+// unpackNative decodes the fields of a pack format from bytes, starting at an optional offset,
+// and returns them as an array. A Q field above the integer range is returned as a bigint.
+func unpackNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 && argCount != 3 {
+		runtimeError("'bytes_unpack' expects 2 or 3 arguments (format, bytes, [offset]).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	format, ok := stringArg("bytes_unpack", args, 0, "format")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	b, ok := bytesArg("bytes_unpack", args, 1)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	offset := int64(0)
+	if argCount == 3 {
+		if offset, ok = runtime.AsInt(args[2]); !ok || offset < 0 {
+			runtimeError("'bytes_unpack' offset must be a non-negative integer.")
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+	}
+	order, fields, ok := parsePackFormat("bytes_unpack", format)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	size := int64(0)
+	for _, field := range fields {
+		size += int64(field.size)
+	}
+	if offset+size > int64(len(b.Data)) {
+		runtimeError("'bytes_unpack' format '%s' needs %d bytes at offset %d, but there are only %d bytes.", format, size, offset, len(b.Data))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+
+	data := b.Data[offset:]
+	values := make([]runtime.Value, len(fields))
+	for i, field := range fields {
+		var value runtime.Value
+		switch field.code {
+		case 'b':
+			value = runtime.IntVal(int64(int8(data[0])))
+		case 'B':
+			value = runtime.IntVal(int64(data[0]))
+		case 'h':
+			value = runtime.IntVal(int64(int16(order.Uint16(data))))
+		case 'H':
+			value = runtime.IntVal(int64(order.Uint16(data)))
+		case 'i':
+			value = runtime.IntVal(int64(int32(order.Uint32(data))))
+		case 'I':
+			value = runtime.IntVal(int64(order.Uint32(data)))
+		case 'q':
+			value = runtime.IntVal(int64(order.Uint64(data)))
+		case 'Q':
+			if n := order.Uint64(data); n <= math.MaxInt64 {
+				value = runtime.IntVal(int64(n))
+			} else {
+				value = runtime.ObjVal(runtime.NewBigInt(new(big.Int).SetUint64(n)))
+			}
+		case 'f':
+			value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(math.Float32frombits(order.Uint32(data)))}
+		case 'd':
+			value = runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Float64frombits(order.Uint64(data))}
+		}
+		values[i] = value
+		data = data[field.size:]
+	}
+	return runtime.ObjVal(runtime.NewArray(values))
+}
@@ -0,0 +1,281 @@
+package vm
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Native Functions: Hashing
+// ============================================================================
+
+// hashFunctions maps the algorithm names accepted by hmac to their constructors.
+var hashFunctions = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// dataArg returns args[index] of the named native as raw data: the UTF-8 encoding of a string,
+// or the contents of bytes.
+func dataArg(name string, args []runtime.Value, index int) ([]byte, bool) {
+	if args[index].Type == runtime.VAL_OBJ {
+		switch obj := args[index].Obj.(type) {
+		case *runtime.ObjString:
+			return []byte(obj.Chars), true
+		case *runtime.ObjBytes:
+			return obj.Data, true
+		}
+	}
+	runtimeError("'%s' expects a string or bytes as argument %d (got %s).", name, index+1, typeName(args[index]))
+	return nil, false
+}
+
+// digestValue returns a digest as a lowercase hex string, or as bytes when the optional format
+// argument at args[index] is "bytes".
+func digestValue(name string, digest []byte, args []runtime.Value, index int) runtime.Value {
+	format := "hex"
+	if len(args) > index {
+		var ok bool
+		if format, ok = stringArg(name, args, index, "format"); !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+	}
+	switch format {
+	case "hex":
+		return runtime.ObjVal(runtime.NewObjString(hex.EncodeToString(digest)))
+	case "bytes":
+		return runtime.ObjVal(runtime.NewBytes(digest))
+	}
+	runtimeError("'%s' format must be \"hex\" or \"bytes\" (got '%s').", name, format)
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// hashNative builds a native that hashes a string or bytes with newHash, returning the digest
+// as hex or, with a "bytes" format argument, as bytes.
+func hashNative(name string, newHash func() hash.Hash) runtime.NativeFn {
+	return func(argCount int, args []runtime.Value) runtime.Value {
+		if argCount != 1 && argCount != 2 {
+			runtimeError("'%s' expects 1 or 2 arguments (data, [format]).", name)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		data, ok := dataArg(name, args, 0)
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		h := newHash()
+		h.Write(data)
+		return digestValue(name, h.Sum(nil), args[:argCount], 1)
+	}
+}
+
+// newCRC32 returns an IEEE CRC-32 hash, as used by zip and PNG.
+func newCRC32() hash.Hash {
+	return crc32.NewIEEE()
+}
+
+// hmacNative computes the HMAC of data with a key, using one of the algorithms md5, sha1,
+// sha256 and sha512.
+func hmacNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 3 && argCount != 4 {
+		runtimeError("'hmac' expects 3 or 4 arguments (algorithm, key, data, [format]).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	algorithm, ok := stringArg("hmac", args, 0, "algorithm")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	newHash, found := hashFunctions[algorithm]
+	if !found {
+		runtimeError("'hmac' algorithm must be one of md5, sha1, sha256 and sha512 (got '%s').", algorithm)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	key, ok := dataArg("hmac", args, 1)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	data, ok := dataArg("hmac", args, 2)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	mac := hmac.New(newHash, key)
+	mac.Write(data)
+	return digestValue("hmac", mac.Sum(nil), args[:argCount], 3)
+}
+
+// ============================================================================
+// Native Functions: Encoding
+// ============================================================================
+
+// encodeNative builds a native that encodes a string or bytes as text, such as hexadecimal.
+func encodeNative(name string, encode func([]byte) string) runtime.NativeFn {
+	return func(argCount int, args []runtime.Value) runtime.Value {
+		if argCount != 1 {
+			runtimeError("'%s' expects 1 argument (a string or bytes).", name)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		data, ok := dataArg(name, args, 0)
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		return runtime.ObjVal(runtime.NewObjString(encode(data)))
+	}
+}
+
+// base64URLDecode decodes URL-safe base64, with or without padding.
+func base64URLDecode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// urlEncodeNative escapes a string for use in a URL query, e.g. "a b&c" becomes "a+b%26c".
+func urlEncodeNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'url_encode' expects 1 argument (string).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	s, ok := stringArg("url_encode", args, 0, "text")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(runtime.NewObjString(url.QueryEscape(s)))
+}
+
+// urlDecodeNative reverses url_encode. A malformed escape is a runtime error.
+func urlDecodeNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'url_decode' expects 1 argument (string).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	s, ok := stringArg("url_decode", args, 0, "encoded text")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	decoded, err := url.QueryUnescape(s)
+	if err != nil {
+		runtimeError("'url_decode' failed: %v", err)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(runtime.NewObjString(decoded))
+}
+
+// ============================================================================
+// Native Functions: Secure Random
+// ============================================================================
+
+// The natives below draw from the operating system's cryptographically secure generator, so
+// their results are suitable for tokens, keys and identifiers.
+
+// randomBytesNative returns n random bytes.
+func randomBytesNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'random_bytes' expects 1 argument (size).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	size, ok := runtime.AsInt(args[0])
+	if !ok || size < 0 || size > maxBytesSize {
+		runtimeError("'random_bytes' expects an integer size from 0 to %d.", maxBytesSize)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	data := make([]byte, size)
+	if !secureRead("random_bytes", data) {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(runtime.NewBytes(data))
+}
+
+// secureRead fills data from the secure generator, reporting a runtime error for the named native
+// if the operating system cannot provide randomness.
+func secureRead(name string, data []byte) bool {
+	if _, err := rand.Read(data); err != nil {
+		runtimeError("'%s' could not read secure random data: %v", name, err)
+		return false
+	}
+	return true
+}
+
+// secureIntn returns a uniformly distributed random integer in [0, n), reporting a runtime error
+// for the named native if the operating system cannot provide randomness.
+func secureIntn(name string, n int) (int, bool) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		runtimeError("'%s' could not read secure random data: %v", name, err)
+		return 0, false
+	}
+	return int(i.Int64()), true
+}
+
+// uuidV4Native returns a random (version 4) UUID, e.g. "0f8fad5b-d9cb-469f-a165-70867728950e".
+func uuidV4Native(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 0 {
+		runtimeError("'uuid_v4' expects 0 arguments.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	var uuid [16]byte
+	if !secureRead("uuid_v4", uuid[:]) {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(runtime.NewObjString(formatUUID(uuid, 4)))
+}
+
+// uuidV7State holds the millisecond and counter of the last version 7 UUID, so the next one can
+// be made to sort after it.
+var uuidV7State struct {
+	millis  uint64
+	counter uint16
+}
+
+// uuidV7Native returns a time-ordered (version 7) UUID: the first 48 bits hold the Unix time in
+// milliseconds, the next 12 a counter (RFC 9562, method 1) and the rest is random. The counter
+// starts at a random value each millisecond and goes up for every UUID made within it, so UUIDs
+// from one VM always sort in the order they were created, even when the clock steps back. UUIDs
+// from different processes are only ordered across milliseconds.
+func uuidV7Native(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 0 {
+		runtimeError("'uuid_v7' expects 0 arguments.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	var uuid [16]byte
+	if !secureRead("uuid_v7", uuid[6:]) {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	state := &uuidV7State
+	if millis := uint64(time.Now().UnixMilli()); millis > state.millis {
+		// Leave the top counter bit clear, so at least 2048 more UUIDs fit into this millisecond.
+		state.millis = millis
+		state.counter = binary.BigEndian.Uint16(uuid[6:8]) & 0x07ff
+	} else if state.counter++; state.counter > 0x0fff {
+		// The counter ran out: borrow the next millisecond rather than go backwards.
+		state.millis++
+		state.counter = 0
+	}
+	var millis [8]byte
+	binary.BigEndian.PutUint64(millis[:], state.millis)
+	copy(uuid[:6], millis[2:])
+	binary.BigEndian.PutUint16(uuid[6:8], state.counter)
+	return runtime.ObjVal(runtime.NewObjString(formatUUID(uuid, 7)))
+}
+
+// formatUUID sets the version and RFC 9562 variant bits of uuid and formats it in the usual
+// 8-4-4-4-12 hex groups.
+func formatUUID(uuid [16]byte, version byte) string {
+	uuid[6] = uuid[6]&0x0f | version<<4
+	uuid[8] = uuid[8]&0x3f | 0x80
+	text := hex.EncodeToString(uuid[:])
+	return text[0:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:32]
+}
@@ -0,0 +1,256 @@
+package vm
+
+import (
+	"encoding/csv"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Native Functions: CSV
+// ============================================================================
+
+// csvOptions holds the options map accepted by the CSV natives.
+type csvOptions struct {
+	delimiter rune     // Field delimiter, ',' by default.
+	header    bool     // The first line holds (when reading) or gets (when writing) the column names.
+	columns   []string // Column names given explicitly as the 'header' option.
+}
+
+// csvOptionsArg reads the optional options map of the named native. 'delimiter' is a
+// one-character string; 'header' is a boolean or an array of column names, and defaults to
+// defaultHeader.
+func csvOptionsArg(name string, args []runtime.Value, index int, defaultHeader bool) (csvOptions, bool) {
+	options := csvOptions{delimiter: ',', header: defaultHeader}
+	if len(args) <= index {
+		return options, true
+	}
+	optionsMap, ok := args[index].Obj.(*runtime.ObjMap)
+	if args[index].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' options must be a map (got %s).", name, typeName(args[index]))
+		return options, false
+	}
+	for key, value := range optionsMap.All() {
+		option, _ := key.Obj.(*runtime.ObjString)
+		switch {
+		case option != nil && option.Chars == "delimiter":
+			text, isString := value.Obj.(*runtime.ObjString)
+			if value.Type != runtime.VAL_OBJ || !isString || utf8.RuneCountInString(text.Chars) != 1 || strings.ContainsAny(text.Chars, "\"\r\n") {
+				runtimeError("'%s' option 'delimiter' must be a single character other than a quote or a newline.", name)
+				return options, false
+			}
+			options.delimiter, _ = utf8.DecodeRuneInString(text.Chars)
+		case option != nil && option.Chars == "header":
+			if value.Type == runtime.VAL_BOOL {
+				options.header = value.Bool
+				continue
+			}
+			columns, ok := csvColumns(value)
+			if !ok {
+				runtimeError("'%s' option 'header' must be a boolean or an array of column names.", name)
+				return options, false
+			}
+			options.columns = columns
+			options.header = defaultHeader
+		default:
+			runtimeError("'%s' does not have an option %s.", name, toStr(1, []runtime.Value{key}).Obj.(*runtime.ObjString).Chars)
+			return options, false
+		}
+	}
+	return options, true
+}
+
+// csvColumns converts an array of strings to column names.
+func csvColumns(value runtime.Value) ([]string, bool) {
+	array, ok := value.Obj.(*runtime.ObjArray)
+	if value.Type != runtime.VAL_OBJ || !ok {
+		return nil, false
+	}
+	columns := make([]string, len(array.Elements))
+	for i, element := range array.Elements {
+		name, ok := element.Obj.(*runtime.ObjString)
+		if element.Type != runtime.VAL_OBJ || !ok {
+			return nil, false
+		}
+		columns[i] = name.Chars
+	}
+	return columns, true
+}
+
+// csvParseNative parses CSV text into an array of records. Each record is an array of strings,
+// or a map from column names to fields when 'header' is true (the first line names the columns)
+// or an array of names.
+func csvParseNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'csv_parse' expects 1 or 2 arguments: CSV text and an optional options map.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	text, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'csv_parse' expects a string (got %s).", typeName(args[0]))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	options, ok := csvOptionsArg("csv_parse", args, 1, false)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+
+	reader := csv.NewReader(strings.NewReader(text.Chars))
+	reader.Comma = options.delimiter
+	if options.columns != nil {
+		reader.FieldsPerRecord = len(options.columns)
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		runtimeError("'csv_parse' failed: %v", err)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+
+	columns := options.columns
+	if options.header {
+		columns = []string{}
+		if len(records) > 0 {
+			columns, records = records[0], records[1:]
+		}
+	}
+	rows := make([]runtime.Value, len(records))
+	for i, record := range records {
+		rows[i] = runtime.CSVRecord(record, columns)
+	}
+	return runtime.ObjVal(runtime.NewArray(rows))
+}
+
+// csvReaderNative opens a CSV file for reading one record at a time, e.g. in an 'iter' loop. It
+// takes the same options as csv_parse.
+func csvReaderNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'csv_reader' expects 1 or 2 arguments: a file path and an optional options map.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	pathObj, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'csv_reader' expects a string (file path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	options, ok := csvOptionsArg("csv_reader", args, 1, false)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	reader, err := runtime.OpenCSVReader(pathObj.Chars, options.delimiter, options.header, options.columns)
+	if err != nil {
+		runtimeError("Error reading file: %v", err)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(reader)
+}
+
+// csvFormatNative formats an array of records as CSV text, quoting fields where needed.
+func csvFormatNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'csv_format' expects 1 or 2 arguments: an array of records and an optional options map.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	text, ok := formatCSV("csv_format", args[0], args[1:])
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(runtime.NewObjString(text))
+}
+
+// csvWriteNative writes an array of records to a CSV file, replacing its contents.
+func csvWriteNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 && argCount != 3 {
+		runtimeError("'csv_write' expects 2 or 3 arguments: a file path, an array of records and an optional options map.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	pathObj, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'csv_write' first argument must be a string (file path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	text, ok := formatCSV("csv_write", args[1], args[2:])
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if err := os.WriteFile(pathObj.Chars, []byte(text), 0644); err != nil {
+		runtimeError("Error writing file: %v", err)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// formatCSV formats the records for csv_format and csv_write. Records are arrays of fields, or
+// maps whose values are written in column order: the 'header' names if given, otherwise the keys
+// of the first record. A header line is written for maps and explicit names unless 'header' is
+// false. Strings are written as they are, null as an empty field and other values as they print.
+func formatCSV(name string, rowsVal runtime.Value, optionArgs []runtime.Value) (string, bool) {
+	rows, ok := rowsVal.Obj.(*runtime.ObjArray)
+	if rowsVal.Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' expects an array of records (got %s).", name, typeName(rowsVal))
+		return "", false
+	}
+	options, ok := csvOptionsArg(name, optionArgs, 0, true)
+	if !ok {
+		return "", false
+	}
+
+	// The keys map records are read with, in column order.
+	columns := options.columns
+	var keys []runtime.Value
+	for _, column := range columns {
+		keys = append(keys, runtime.ObjVal(runtime.NewObjString(column)))
+	}
+	if columns == nil && len(rows.Elements) > 0 {
+		if first, isMap := rows.Elements[0].Obj.(*runtime.ObjMap); isMap && rows.Elements[0].Type == runtime.VAL_OBJ {
+			keys = first.Keys()
+			for _, key := range keys {
+				columns = append(columns, csvField(key))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	writer := csv.NewWriter(&sb)
+	writer.Comma = options.delimiter
+	if options.header && columns != nil {
+		writer.Write(columns)
+	}
+	for i, row := range rows.Elements {
+		var record []string
+		switch obj := row.Obj.(type) {
+		case *runtime.ObjArray:
+			record = make([]string, len(obj.Elements))
+			for j, field := range obj.Elements {
+				record[j] = csvField(field)
+			}
+		case *runtime.ObjMap:
+			if columns == nil {
+				runtimeError("'%s' needs column names to write map records; pass them as the 'header' option.", name)
+				return "", false
+			}
+			record = make([]string, len(keys))
+			for j, key := range keys {
+				if field, found := obj.Get(key); found {
+					record[j] = csvField(field)
+				}
+			}
+		default:
+			runtimeError("'%s' records must be arrays or maps (record %d is %s).", name, i, typeName(row))
+			return "", false
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return sb.String(), true
+}
+
+// csvField converts a value to the text of a CSV field.
+func csvField(value runtime.Value) string {
+	if value.Type == runtime.VAL_NULL {
+		return ""
+	}
+	return toStr(1, []runtime.Value{value}).Obj.(*runtime.ObjString).Chars
+}
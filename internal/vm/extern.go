@@ -194,6 +194,8 @@ ReturnValue call_function(void* func, TypeCode return_type, int arg_count, Argum
 import "C"
 
 import (
+	"math"
+	"math/big"
 	"strings"
 	"unsafe"
 
@@ -259,81 +261,93 @@ func createNativeFunc(funcName string, cFunc unsafe.Pointer, returnType string,
 			// Convert TulipScript arguments to C arguments
 			cArgs := make([]C.Argument, argCount)
 			var cStrings []unsafe.Pointer // Track allocated C strings
+			var cBuffers []bytesBuffer    // Track C copies of Bytes arguments
+			// Free them once the call returns, or when a later argument is rejected before it. The
+			// Bytes copies are written back first, so the script sees what the function stored.
+			defer func() {
+				for _, cStr := range cStrings {
+					C.free(cStr)
+				}
+				for _, buffer := range cBuffers {
+					buffer.release()
+				}
+			}()
 			for i, pt := range paramTypes {
 				cArgs[i].argType = cParamTypes[i]
 				switch pt {
 				case "int8_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*int8)(unsafe.Pointer(&cArgs[i].value[0])) = int8(args[i].Number)
+					*(*int8)(unsafe.Pointer(&cArgs[i].value[0])) = int8(ffiInt(args[i]))
 				case "uint8_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*uint8)(unsafe.Pointer(&cArgs[i].value[0])) = uint8(args[i].Number)
+					*(*uint8)(unsafe.Pointer(&cArgs[i].value[0])) = uint8(ffiInt(args[i]))
 				case "int16_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*int16)(unsafe.Pointer(&cArgs[i].value[0])) = int16(args[i].Number)
+					*(*int16)(unsafe.Pointer(&cArgs[i].value[0])) = int16(ffiInt(args[i]))
 				case "uint16_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*uint16)(unsafe.Pointer(&cArgs[i].value[0])) = uint16(args[i].Number)
+					*(*uint16)(unsafe.Pointer(&cArgs[i].value[0])) = uint16(ffiInt(args[i]))
 				case "int32_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*int32)(unsafe.Pointer(&cArgs[i].value[0])) = int32(args[i].Number)
+					*(*int32)(unsafe.Pointer(&cArgs[i].value[0])) = int32(ffiInt(args[i]))
 				case "uint32_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*uint32)(unsafe.Pointer(&cArgs[i].value[0])) = uint32(args[i].Number)
+					*(*uint32)(unsafe.Pointer(&cArgs[i].value[0])) = uint32(ffiInt(args[i]))
 				case "int64_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*int64)(unsafe.Pointer(&cArgs[i].value[0])) = int64(args[i].Number)
+					*(*int64)(unsafe.Pointer(&cArgs[i].value[0])) = ffiInt(args[i])
 				case "uint64_t":
-					if args[i].Type != runtime.VAL_NUMBER {
-						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
+					u, ok := ffiUint(args[i])
+					if !ok {
+						runtimeError("Argument %d of '%s' must be a number from 0 to 2^64-1.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*uint64)(unsafe.Pointer(&cArgs[i].value[0])) = uint64(args[i].Number)
+					*(*uint64)(unsafe.Pointer(&cArgs[i].value[0])) = u
 				case "float":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*float32)(unsafe.Pointer(&cArgs[i].value[0])) = float32(args[i].Number)
+					*(*float32)(unsafe.Pointer(&cArgs[i].value[0])) = float32(runtime.AsNumber(args[i]))
 				case "double":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*float64)(unsafe.Pointer(&cArgs[i].value[0])) = args[i].Number
+					*(*float64)(unsafe.Pointer(&cArgs[i].value[0])) = runtime.AsNumber(args[i])
 				case "float _Complex":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number (for real part).", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*float32)(unsafe.Pointer(&cArgs[i].value[0])) = float32(args[i].Number) // Real part only
+					*(*float32)(unsafe.Pointer(&cArgs[i].value[0])) = float32(runtime.AsNumber(args[i])) // Real part only
 				case "double _Complex":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number (for real part).", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*float64)(unsafe.Pointer(&cArgs[i].value[0])) = args[i].Number // Real part only
+					*(*float64)(unsafe.Pointer(&cArgs[i].value[0])) = runtime.AsNumber(args[i]) // Real part only
 				case "bool":
 					if args[i].Type != runtime.VAL_BOOL {
 						runtimeError("Argument %d of '%s' must be a boolean.", i+1, funcName)
@@ -389,55 +403,68 @@ func createNativeFunc(funcName string, cFunc unsafe.Pointer, returnType string,
 					}
 					*(*int8)(unsafe.Pointer(&cArgs[i].value[0])) = int8(s[0])
 				case "intptr_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*int)(unsafe.Pointer(&cArgs[i].value[0])) = int(args[i].Number)
+					*(*int)(unsafe.Pointer(&cArgs[i].value[0])) = int(ffiInt(args[i]))
 				case "uintptr_t":
-					if args[i].Type != runtime.VAL_NUMBER {
-						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
+					u, ok := ffiUint(args[i])
+					if !ok {
+						runtimeError("Argument %d of '%s' must be a number from 0 to 2^64-1.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*uint)(unsafe.Pointer(&cArgs[i].value[0])) = uint(args[i].Number)
+					*(*uint)(unsafe.Pointer(&cArgs[i].value[0])) = uint(u)
 				case "intmax_t":
-					if args[i].Type != runtime.VAL_NUMBER {
+					if !runtime.IsNumber(args[i]) {
 						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*int64)(unsafe.Pointer(&cArgs[i].value[0])) = int64(args[i].Number)
+					*(*int64)(unsafe.Pointer(&cArgs[i].value[0])) = ffiInt(args[i])
 				case "uintmax_t":
-					if args[i].Type != runtime.VAL_NUMBER {
-						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
+					u, ok := ffiUint(args[i])
+					if !ok {
+						runtimeError("Argument %d of '%s' must be a number from 0 to 2^64-1.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*uint64)(unsafe.Pointer(&cArgs[i].value[0])) = uint64(args[i].Number)
+					*(*uint64)(unsafe.Pointer(&cArgs[i].value[0])) = u
 				case "size_t":
-					if args[i].Type != runtime.VAL_NUMBER {
-						runtimeError("Argument %d of '%s' must be a number.", i+1, funcName)
+					u, ok := ffiUint(args[i])
+					if !ok {
+						runtimeError("Argument %d of '%s' must be a number from 0 to 2^64-1.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
-					*(*uint64)(unsafe.Pointer(&cArgs[i].value[0])) = uint64(args[i].Number)
+					*(*uint64)(unsafe.Pointer(&cArgs[i].value[0])) = u
 				case "char*":
 					if args[i].Type == runtime.VAL_NULL {
 						*(*unsafe.Pointer)(unsafe.Pointer(&cArgs[i].value[0])) = nil
+					} else if b, ok := args[i].Obj.(*runtime.ObjBytes); ok && args[i].Type == runtime.VAL_OBJ {
+						buffer := newBytesBuffer(b)
+						*(*unsafe.Pointer)(unsafe.Pointer(&cArgs[i].value[0])) = buffer.ptr
+						cBuffers = append(cBuffers, buffer)
 					} else if args[i].Type == runtime.VAL_OBJ {
 						objString, ok := args[i].Obj.(*runtime.ObjString)
 						if !ok {
-							runtimeError("Argument %d of '%s' must be null or a string for 'char*'.", i+1, funcName)
+							runtimeError("Argument %d of '%s' must be null, a string or bytes for 'char*'.", i+1, funcName)
 							return runtime.Value{Type: runtime.VAL_NULL}
 						}
 						cStr := C.CString(objString.Chars)
 						*(*unsafe.Pointer)(unsafe.Pointer(&cArgs[i].value[0])) = unsafe.Pointer(cStr)
 						cStrings = append(cStrings, unsafe.Pointer(cStr))
 					} else {
-						runtimeError("Argument %d of '%s' must be null or a string for 'char*'.", i+1, funcName)
+						runtimeError("Argument %d of '%s' must be null, a string or bytes for 'char*'.", i+1, funcName)
 						return runtime.Value{Type: runtime.VAL_NULL}
 					}
 				default:
 					if strings.HasSuffix(pt, "*") {
+						if b, ok := args[i].Obj.(*runtime.ObjBytes); ok && args[i].Type == runtime.VAL_OBJ {
+							buffer := newBytesBuffer(b)
+							*(*unsafe.Pointer)(unsafe.Pointer(&cArgs[i].value[0])) = buffer.ptr
+							cBuffers = append(cBuffers, buffer)
+							continue
+						}
 						if args[i].Type != runtime.VAL_NULL {
-							runtimeError("Argument %d of '%s' must be null for pointer type '%s'.", i+1, funcName, pt)
+							runtimeError("Argument %d of '%s' must be null or bytes for pointer type '%s'.", i+1, funcName, pt)
 							return runtime.Value{Type: runtime.VAL_NULL}
 						}
 						*(*unsafe.Pointer)(unsafe.Pointer(&cArgs[i].value[0])) = nil
@@ -456,31 +483,26 @@ func createNativeFunc(funcName string, cFunc unsafe.Pointer, returnType string,
 				ret = C.call_function(cFunc, cReturnType, 0, nil)
 			}
 
-			// Free allocated C strings
-			for _, cStr := range cStrings {
-				C.free(cStr)
-			}
-
 			// Convert return value back to TulipScript
 			switch cReturnType {
 			case C.TYPE_VOID:
 				return runtime.Value{Type: runtime.VAL_NULL}
 			case C.TYPE_INT8:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*int8)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*int8)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_UINT8:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*uint8)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*uint8)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_INT16:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*int16)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*int16)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_UINT16:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*uint16)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*uint16)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_INT32:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*int32)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*int32)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_UINT32:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*uint32)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*uint32)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_INT64:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*int64)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*int64)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_UINT64:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*uint64)(unsafe.Pointer(&ret[0])))}
+				return ffiUintVal(*(*uint64)(unsafe.Pointer(&ret[0])))
 			case C.TYPE_FLOAT:
 				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*float32)(unsafe.Pointer(&ret[0])))}
 			case C.TYPE_DOUBLE:
@@ -498,15 +520,15 @@ func createNativeFunc(funcName string, cFunc unsafe.Pointer, returnType string,
 			case C.TYPE_SCHAR:
 				return runtime.Value{Type: runtime.VAL_OBJ, Obj: runtime.NewObjString(string(rune(*(*int8)(unsafe.Pointer(&ret[0])))))}
 			case C.TYPE_INTPTR:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*int)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*int)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_UINTPTR:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*uint)(unsafe.Pointer(&ret[0])))}
+				return ffiUintVal(uint64(*(*uint)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_INTMAX:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*int64)(unsafe.Pointer(&ret[0])))}
+				return runtime.IntVal(int64(*(*int64)(unsafe.Pointer(&ret[0]))))
 			case C.TYPE_UINTMAX:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*uint64)(unsafe.Pointer(&ret[0])))}
+				return ffiUintVal(*(*uint64)(unsafe.Pointer(&ret[0])))
 			case C.TYPE_SIZE:
-				return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(*(*uint64)(unsafe.Pointer(&ret[0])))}
+				return ffiUintVal(*(*uint64)(unsafe.Pointer(&ret[0])))
 			case C.TYPE_PTR:
 				ptr := *(*unsafe.Pointer)(unsafe.Pointer(&ret[0]))
 				if ptr == nil {
@@ -525,3 +547,62 @@ func createNativeFunc(funcName string, cFunc unsafe.Pointer, returnType string,
 		},
 	}
 }
+
+// ffiInt converts a numeric argument for an integer C parameter. Integers pass through
+// exactly (uint64_t values above 2^63-1 travel as their two's complement bit pattern);
+// floats are truncated.
+func ffiInt(v runtime.Value) int64 {
+	if v.Type == runtime.VAL_INT {
+		return v.Int
+	}
+	if v.Number >= math.MaxInt64 {
+		return int64(uint64(v.Number))
+	}
+	return int64(v.Number)
+}
+
+// ffiUint converts a numeric argument for an unsigned 64-bit C parameter. Besides what ffiInt
+// accepts, a BigInt from 0 to 2^64-1 passes exactly, so a value such a function returned can be
+// passed back to it.
+func ffiUint(v runtime.Value) (uint64, bool) {
+	if runtime.IsNumber(v) {
+		return uint64(ffiInt(v)), true
+	}
+	if b, ok := v.Obj.(*runtime.ObjBigInt); ok && v.Type == runtime.VAL_OBJ {
+		if b.Value.Sign() >= 0 && b.Value.IsUint64() {
+			return b.Value.Uint64(), true
+		}
+	}
+	return 0, false
+}
+
+// ffiUintVal converts the result of an unsigned 64-bit C function: an int when it fits, and a
+// BigInt from 2^63 up, so large values do not turn negative.
+func ffiUintVal(u uint64) runtime.Value {
+	if u <= math.MaxInt64 {
+		return runtime.IntVal(int64(u))
+	}
+	return runtime.ObjVal(runtime.NewBigInt(new(big.Int).SetUint64(u)))
+}
+
+// bytesBuffer is a C copy of a Bytes argument passed for a pointer parameter. Go memory cannot
+// be handed to C, so the bytes are copied into C memory before the call and back afterwards,
+// letting a C function fill a buffer the script allocated.
+type bytesBuffer struct {
+	bytes *runtime.ObjBytes
+	ptr   unsafe.Pointer
+}
+
+// newBytesBuffer copies b into newly allocated C memory. One zero byte is added after the data,
+// so a C function reading a 'char*' finds the end of the text.
+func newBytesBuffer(b *runtime.ObjBytes) bytesBuffer {
+	ptr := C.calloc(C.size_t(len(b.Data)+1), 1)
+	copy(unsafe.Slice((*byte)(ptr), len(b.Data)), b.Data)
+	return bytesBuffer{bytes: b, ptr: ptr}
+}
+
+// release copies the C memory back into the Bytes argument and frees it.
+func (buffer bytesBuffer) release() {
+	copy(buffer.bytes.Data, unsafe.Slice((*byte)(buffer.ptr), len(buffer.bytes.Data)))
+	C.free(buffer.ptr)
+}
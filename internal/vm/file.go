@@ -0,0 +1,259 @@
+package vm
+
+import (
+	"io"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Native Functions: File Handles
+// ============================================================================
+
+// Like the file system natives, opening, reading and writing a file return an error value when
+// the operating system refuses the operation. Using a file after closing it, or in a way its
+// mode does not allow, is a runtime error.
+
+// openFileNative opens a file for reading and writing a piece at a time. The optional mode is r
+// (read, the default), w (truncate or create, then write), a (append), or one of them followed
+// by + to allow both reading and writing.
+func openFileNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'open_file' expects 1 or 2 arguments (file path, [mode]).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	file, ok := openFileArgs("open_file", args[:argCount])
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return file
+}
+
+// withFileNative opens a file, calls a function with it and closes it again once the function
+// returns, even if it ends in a runtime error. It returns what the function returned, or the
+// error value if the file could not be opened.
+func withFileNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 && argCount != 3 {
+		runtimeError("'with_file' expects 2 or 3 arguments (file path, [mode], function).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	callback := args[argCount-1]
+	if !isCallable(callback) {
+		runtimeError("'with_file' expects a function as the last argument (got %s).", typeName(callback))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	fileVal, ok := openFileArgs("with_file", args[:argCount-1])
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	file, isFile := fileVal.Obj.(*runtime.ObjFile)
+	if !isFile {
+		return fileVal
+	}
+	result, ok := callClosureFromNative(callback, fileVal)
+	closeErr := file.Close()
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if closeErr != nil {
+		return fsError(closeErr)
+	}
+	return result
+}
+
+// openFileArgs opens the file named by the (path, [mode]) arguments of open_file and with_file,
+// returning the file or an error value.
+func openFileArgs(name string, args []runtime.Value) (runtime.Value, bool) {
+	path, ok := stringArg(name, args, 0, "file path")
+	if !ok {
+		return runtime.Value{}, false
+	}
+	mode := "r"
+	if len(args) == 2 {
+		if mode, ok = stringArg(name, args, 1, "mode"); !ok {
+			return runtime.Value{}, false
+		}
+		if !runtime.ValidFileMode(mode) {
+			runtimeError("'%s' mode must be one of r, w, a, r+, w+ and a+ (got '%s').", name, mode)
+			return runtime.Value{}, false
+		}
+	}
+	file, err := runtime.OpenFile(path, mode)
+	if err != nil {
+		return fsError(err), true
+	}
+	return runtime.ObjVal(file), true
+}
+
+// fileMember returns the property name of file: 'path' and 'mode' are strings, 'closed' is a
+// boolean and the remaining names are methods, returned as natives bound to file.
+func fileMember(file *runtime.ObjFile, name string) (runtime.Value, bool) {
+	var method runtime.NativeFn
+	switch name {
+	case "path":
+		return runtime.ObjVal(runtime.NewObjString(file.Path)), true
+	case "mode":
+		return runtime.ObjVal(runtime.NewObjString(file.Mode)), true
+	case "closed":
+		return runtime.Value{Type: runtime.VAL_BOOL, Bool: file.Closed()}, true
+	case "read_line":
+		// read_line returns the next line without its line ending, or null at the end of the file.
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			if !fileMethodArgs(file, "read_line", argCount, 0) {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			line, found, err := file.ReadLine()
+			if err != nil {
+				return fileError(file, err)
+			}
+			if !found {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return runtime.ObjVal(runtime.NewObjString(line))
+		}
+	case "read_bytes":
+		// read_bytes(n) returns the next n bytes, fewer at the end of the file, or null once there
+		// is nothing left.
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			if !fileMethodArgs(file, "read_bytes", argCount, 1) {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			n, ok := runtime.AsInt(args[0])
+			if !ok || n < 0 {
+				runtimeError("'read_bytes' expects a non-negative integer (got %s).", typeName(args[0]))
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			data, found, err := file.ReadBytes(n)
+			if err != nil {
+				return fileError(file, err)
+			}
+			if !found {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return runtime.ObjVal(runtime.NewBytes(data))
+		}
+	case "write", "write_line":
+		// write(s) writes a string or bytes, or any other value as it prints, as write_file does;
+		// write_line(s) adds a line break after it.
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			if !fileMethodArgs(file, name, argCount, 1) {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			var text string
+			if b, isBytes := args[0].Obj.(*runtime.ObjBytes); isBytes && args[0].Type == runtime.VAL_OBJ {
+				text = string(b.Data)
+			} else {
+				text = toStr(1, args).Obj.(*runtime.ObjString).Chars
+			}
+			if name == "write_line" {
+				text += "\n"
+			}
+			return fileResult(file, file.Write(text))
+		}
+	case "flush":
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			if !fileMethodArgs(file, "flush", argCount, 0) {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return fileResult(file, file.Flush())
+		}
+	case "seek":
+		// seek(offset, [whence]) moves to offset bytes from the start of the file, or from the
+		// current position or the end when whence is "current" or "end", and returns the new
+		// position.
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			if argCount != 1 && argCount != 2 {
+				runtimeError("'seek' expects 1 or 2 arguments (offset, [whence]).")
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			if !fileIsOpen(file) {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			offset, ok := runtime.AsInt(args[0])
+			if !ok {
+				runtimeError("'seek' expects an integer offset (got %s).", typeName(args[0]))
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			whence := io.SeekStart
+			if argCount == 2 {
+				whenceObj, isString := args[1].Obj.(*runtime.ObjString)
+				switch {
+				case args[1].Type != runtime.VAL_OBJ || !isString:
+					whence = -1
+				case whenceObj.Chars == "current":
+					whence = io.SeekCurrent
+				case whenceObj.Chars == "end":
+					whence = io.SeekEnd
+				case whenceObj.Chars != "start":
+					whence = -1
+				}
+				if whence < 0 {
+					runtimeError("'seek' whence must be \"start\", \"current\" or \"end\".")
+					return runtime.Value{Type: runtime.VAL_NULL}
+				}
+			}
+			position, err := file.Seek(offset, whence)
+			if err != nil {
+				return fileError(file, err)
+			}
+			return runtime.IntVal(position)
+		}
+	case "close":
+		// close flushes and closes the file. Closing it again does nothing.
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			if argCount != 0 {
+				runtimeError("'close' expects 0 arguments.")
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return fileResult(file, file.Close())
+		}
+	default:
+		return runtime.Value{}, false
+	}
+	return runtime.ObjVal(runtime.NewNative(method)), true
+}
+
+// fileMethodArgs checks the argument count of the named file method and that the file is still
+// open.
+func fileMethodArgs(file *runtime.ObjFile, name string, argCount, expected int) bool {
+	if argCount != expected {
+		if expected == 1 {
+			runtimeError("'%s' expects 1 argument.", name)
+		} else {
+			runtimeError("'%s' expects %d arguments.", name, expected)
+		}
+		return false
+	}
+	return fileIsOpen(file)
+}
+
+// fileIsOpen reports whether file is open, raising a runtime error if it has been closed.
+func fileIsOpen(file *runtime.ObjFile) bool {
+	if file.Closed() {
+		runtimeError("Cannot use file '%s' after it has been closed.", file.Path)
+		return false
+	}
+	return true
+}
+
+// fileError converts a failed read, write or seek to an error value. Reading a file opened only
+// for writing, or the other way round, is a runtime error instead.
+func fileError(file *runtime.ObjFile, err error) runtime.Value {
+	if err == runtime.ErrFileClosed {
+		runtimeError("Cannot use file '%s' after it has been closed.", file.Path)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if err == runtime.ErrFileNotReadable || err == runtime.ErrFileNotWritable {
+		runtimeError("Cannot use file '%s' opened with mode '%s': %v.", file.Path, file.Mode, err)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return fsError(err)
+}
+
+// fileResult returns null for a successful file operation and an error value otherwise.
+func fileResult(file *runtime.ObjFile, err error) runtime.Value {
+	if err != nil {
+		return fileError(file, err)
+	}
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
@@ -0,0 +1,258 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Format Strings
+// ============================================================================
+
+// maxFormatWidth bounds widths and precisions so a typo cannot ask for a gigabyte of padding.
+const maxFormatWidth = 1_000_000
+
+// formatVerb is one parsed %-directive of a format string: %[flags][width][.precision]verb.
+type formatVerb struct {
+	flags     string // Any of '-', '+', ' ', '0' and '#'.
+	width     int    // Minimum field width, or -1 if not given.
+	precision int    // Precision, or -1 if not given.
+	verb      rune
+}
+
+// goFormat returns the directive as a Go format string with the given verb, so Go's fmt can do
+// the padding, signs and precision once the argument has been converted to a Go value.
+func (f formatVerb) goFormat(verb rune) string {
+	var sb strings.Builder
+	sb.WriteByte('%')
+	sb.WriteString(f.flags)
+	if f.width >= 0 {
+		sb.WriteString(strconv.Itoa(f.width))
+	}
+	if f.precision >= 0 {
+		sb.WriteByte('.')
+		sb.WriteString(strconv.Itoa(f.precision))
+	}
+	sb.WriteRune(verb)
+	return sb.String()
+}
+
+// formatValues formats args according to format for printf, sprintf and errorf. It follows the
+// syntax of C and Go format strings:
+//
+//	%v %s  the value as print shows it (precision truncates)
+//	%q     the value as print shows it, quoted
+//	%d     an integer (int, bigint or whole float)
+//	%x %X %o %b  an integer in base 16, 8 or 2; %x and %X also take strings
+//	%c     an integer as a Unicode character
+//	%f %F %e %E %g %G  a number; a decimal keeps its exact digits with %f
+//	%t     a boolean
+//	%T     the type name of the value
+//	%%     a literal percent sign
+//
+// Flags ('-', '+', ' ', '0', '#'), a width and a precision may come between the '%' and the verb,
+// and '*' takes the width or precision from the next argument. name is the native reported in
+// errors; a verb that does not match its argument, a missing argument and an unused argument are
+// all runtime errors.
+func formatValues(name string, format string, args []runtime.Value) (string, bool) {
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			sb.WriteByte('%')
+			continue
+		}
+
+		directive := formatVerb{width: -1, precision: -1}
+		for i < len(format) && strings.IndexByte("-+ 0#", format[i]) >= 0 {
+			if strings.IndexByte(directive.flags, format[i]) < 0 {
+				directive.flags += string(format[i])
+			}
+			i++
+		}
+		var width int
+		var given, ok bool
+		if width, given, i, ok = formatNumber(name, format, i, args, &next); !ok {
+			return "", false
+		}
+		if given {
+			if width < 0 {
+				// A negative '*' width left-justifies, as in C.
+				width = -width
+				directive.flags += "-"
+			}
+			directive.width = width
+		}
+		if i < len(format) && format[i] == '.' {
+			var precision int
+			if precision, given, i, ok = formatNumber(name, format, i+1, args, &next); !ok {
+				return "", false
+			}
+			// A '.' alone means a precision of zero; a negative '*' precision counts as none, as in C.
+			switch {
+			case !given:
+				directive.precision = 0
+			case precision >= 0:
+				directive.precision = precision
+			}
+		}
+		if i >= len(format) {
+			runtimeError("'%s' format ends with an incomplete verb.", name)
+			return "", false
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		i += size - 1
+		directive.verb = verb
+
+		if next >= len(args) {
+			runtimeError("'%s' format has more verbs than arguments (%d given).", name, len(args))
+			return "", false
+		}
+		text, ok := formatValue(name, directive, args[next])
+		if !ok {
+			return "", false
+		}
+		sb.WriteString(text)
+		next++
+	}
+	if next < len(args) {
+		runtimeError("'%s' got %d arguments but the format only uses %d.", name, len(args), next)
+		return "", false
+	}
+	return sb.String(), true
+}
+
+// formatNumber reads the width or precision that starts at format[i]: digits or '*' (the next
+// argument, which must be an integer). given is false if there is neither. It returns the index
+// after the number.
+func formatNumber(name string, format string, i int, args []runtime.Value, next *int) (n int, given bool, end int, ok bool) {
+	if i < len(format) && format[i] == '*' {
+		if *next >= len(args) {
+			runtimeError("'%s' format has more verbs than arguments (%d given).", name, len(args))
+			return 0, false, i, false
+		}
+		value, ok := runtime.AsInt(args[*next])
+		if !ok || value < -maxFormatWidth || value > maxFormatWidth {
+			runtimeError("'%s' expects an integer up to %d for '*' (got %s).", name, maxFormatWidth, typeName(args[*next]))
+			return 0, false, i, false
+		}
+		*next++
+		return int(value), true, i + 1, true
+	}
+	start := i
+	for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+		i++
+	}
+	if start == i {
+		return 0, false, i, true
+	}
+	value, err := strconv.Atoi(format[start:i])
+	if err != nil || value > maxFormatWidth {
+		runtimeError("'%s' format has a width or precision above %d.", name, maxFormatWidth)
+		return 0, false, i, false
+	}
+	return value, true, i, true
+}
+
+// formatValue formats a single argument for the directive f.
+func formatValue(name string, f formatVerb, arg runtime.Value) (string, bool) {
+	switch f.verb {
+	case 'v', 's':
+		return fmt.Sprintf(f.goFormat('s'), unescapeString(toStr(1, []runtime.Value{arg}).Obj.(*runtime.ObjString).Chars)), true
+	case 'q':
+		return fmt.Sprintf(f.goFormat('q'), toStr(1, []runtime.Value{arg}).Obj.(*runtime.ObjString).Chars), true
+	case 'T':
+		return fmt.Sprintf(f.goFormat('s'), typeName(arg)), true
+	case 't':
+		if arg.Type != runtime.VAL_BOOL {
+			break
+		}
+		return fmt.Sprintf(f.goFormat('t'), arg.Bool), true
+	case 'd', 'o', 'O', 'b', 'x', 'X':
+		if s, ok := arg.Obj.(*runtime.ObjString); ok && arg.Type == runtime.VAL_OBJ && (f.verb == 'x' || f.verb == 'X') {
+			return fmt.Sprintf(f.goFormat(f.verb), s.Chars), true
+		}
+		if n, ok := formatInteger(arg); ok {
+			return fmt.Sprintf(f.goFormat(f.verb), n), true
+		}
+	case 'c':
+		if n, ok := runtime.AsInt(arg); ok && n >= 0 && n <= utf8.MaxRune {
+			return fmt.Sprintf(f.goFormat('c'), rune(n)), true
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		switch {
+		case runtime.IsNumber(arg):
+			return fmt.Sprintf(f.goFormat(f.verb), runtime.AsNumber(arg)), true
+		case arg.Type != runtime.VAL_OBJ:
+		default:
+			switch obj := arg.Obj.(type) {
+			case *runtime.ObjBigInt:
+				return fmt.Sprintf(f.goFormat(f.verb), new(big.Float).SetInt(obj.Value)), true
+			case *runtime.ObjDecimal:
+				if f.verb == 'f' || f.verb == 'F' {
+					return formatDecimal(f, obj), true
+				}
+				value, _ := obj.Rat().Float64()
+				return fmt.Sprintf(f.goFormat(f.verb), value), true
+			}
+		}
+	default:
+		runtimeError("'%s' format has an unknown verb '%%%c'.", name, f.verb)
+		return "", false
+	}
+	runtimeError("'%s' verb '%%%c' cannot format %s.", name, f.verb, typeName(arg))
+	return "", false
+}
+
+// formatInteger converts an integer argument (an int, a bigint or a float without a fractional
+// part) to a Go value that fmt formats as an integer.
+func formatInteger(arg runtime.Value) (any, bool) {
+	if n, ok := runtime.AsInt(arg); ok {
+		return n, true
+	}
+	if bigint, ok := arg.Obj.(*runtime.ObjBigInt); ok && arg.Type == runtime.VAL_OBJ {
+		return bigint.Value, true
+	}
+	return nil, false
+}
+
+// formatDecimal formats a decimal for %f without going through a float: the precision, or the
+// decimal's own scale if none is given, sets the number of fractional digits, rounding with the
+// VM's decimal rounding mode.
+func formatDecimal(f formatVerb, d *runtime.ObjDecimal) string {
+	scale := d.Scale
+	if f.precision >= 0 {
+		scale = int32(f.precision)
+	}
+	digits := d.Rescale(scale, vm.decimalRounding).String()
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	} else if strings.IndexByte(f.flags, '+') >= 0 {
+		sign = "+"
+	} else if strings.IndexByte(f.flags, ' ') >= 0 {
+		sign = " "
+	}
+	pad := f.width - len(sign) - len(digits)
+	switch {
+	case pad <= 0:
+		return sign + digits
+	case strings.IndexByte(f.flags, '-') >= 0:
+		return sign + digits + strings.Repeat(" ", pad)
+	case strings.IndexByte(f.flags, '0') >= 0:
+		return sign + strings.Repeat("0", pad) + digits
+	default:
+		return strings.Repeat(" ", pad) + sign + digits
+	}
+}
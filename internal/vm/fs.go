@@ -0,0 +1,361 @@
+package vm
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Native Functions: File System
+// ============================================================================
+
+// The natives below return an error value (see runtime.ObjError) when the operating system
+// refuses an operation, so a script can test for it with is_error and carry on. Passing the
+// wrong number or type of arguments is still a runtime error.
+
+// fsError converts a failed file system operation to an error value. The kind is one of
+// not_found, exists, permission, not_dir, is_dir, not_empty and io.
+func fsError(err error) runtime.Value {
+	kind := "io"
+	switch {
+	case errors.Is(err, syscall.ENOTEMPTY):
+		// Checked first: Go also reports ENOTEMPTY as fs.ErrExist.
+		kind = "not_empty"
+	case errors.Is(err, fs.ErrNotExist):
+		kind = "not_found"
+	case errors.Is(err, fs.ErrExist):
+		kind = "exists"
+	case errors.Is(err, fs.ErrPermission):
+		kind = "permission"
+	case errors.Is(err, syscall.ENOTDIR):
+		kind = "not_dir"
+	case errors.Is(err, syscall.EISDIR):
+		kind = "is_dir"
+	}
+	path := ""
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	if errors.As(err, &pathErr) {
+		path = pathErr.Path
+	} else if errors.As(err, &linkErr) {
+		path = linkErr.Old
+	}
+	return runtime.ObjVal(runtime.NewError(kind, err.Error(), path))
+}
+
+// fsResult returns null for a successful operation and an error value otherwise.
+func fsResult(err error) runtime.Value {
+	if err != nil {
+		return fsError(err)
+	}
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// appendFileNative appends a string to a file, creating the file if it does not exist.
+func appendFileNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'append_file' expects 2 arguments (file path, content).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("append_file", args, 0, "file path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	content, ok := stringArg("append_file", args, 1, "content")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fsError(err)
+	}
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return fsResult(err)
+}
+
+// fileExistsNative reports whether a file or directory exists at a path.
+func fileExistsNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'file_exists' expects 1 argument (path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("file_exists", args, 0, "path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	_, err := os.Stat(path)
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: err == nil}
+}
+
+// fileStatNative describes a file as a map with its name, size in bytes, permission bits
+// (mode), whether it is a directory (is_dir) and its modification time as a DateTime (mtime).
+func fileStatNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'file_stat' expects 1 argument (path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("file_stat", args, 0, "path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fsError(err)
+	}
+	mtime := info.ModTime()
+	stat := runtime.NewMap()
+	set := func(key string, value runtime.Value) {
+		stat.Set(runtime.ObjVal(runtime.NewObjString(key)), value)
+	}
+	set("name", runtime.ObjVal(runtime.NewObjString(info.Name())))
+	set("size", runtime.IntVal(info.Size()))
+	set("mode", runtime.IntVal(int64(info.Mode().Perm())))
+	set("is_dir", runtime.Value{Type: runtime.VAL_BOOL, Bool: info.IsDir()})
+	set("mtime", runtime.ObjVal(runtime.NewDateTime(mtime.Year(), mtime.Month(), mtime.Day(), mtime.Hour(), mtime.Minute(), mtime.Second())))
+	return runtime.ObjVal(stat)
+}
+
+// listDirNative returns the names of the entries of a directory, sorted.
+func listDirNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'list_dir' expects 1 argument (directory path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("list_dir", args, 0, "directory path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fsError(err)
+	}
+	names := make([]runtime.Value, len(entries))
+	for i, entry := range entries {
+		names[i] = runtime.ObjVal(runtime.NewObjString(entry.Name()))
+	}
+	return runtime.ObjVal(runtime.NewArray(names))
+}
+
+// globNative returns the paths matching a shell pattern such as "logs/*.txt", sorted. A
+// malformed pattern is a runtime error.
+func globNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'glob' expects 1 argument (pattern).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	pattern, ok := stringArg("glob", args, 0, "pattern")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		runtimeError("'glob' pattern '%s' is malformed.", pattern)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	paths := make([]runtime.Value, len(matches))
+	for i, match := range matches {
+		paths[i] = runtime.ObjVal(runtime.NewObjString(match))
+	}
+	return runtime.ObjVal(runtime.NewArray(paths))
+}
+
+// makeDirNative creates a directory along with any missing parents, like 'mkdir -p'. It is not
+// an error if the directory already exists.
+func makeDirNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'make_dir' expects 1 argument (directory path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("make_dir", args, 0, "directory path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return fsResult(os.MkdirAll(path, 0755))
+}
+
+// removePathNative removes a file or an empty directory. With a second argument of true it
+// removes a directory and everything in it, and a path that does not exist is not an error.
+func removePathNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'remove_path' expects 1 or 2 arguments (path, [recursive]).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("remove_path", args, 0, "path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if argCount == 2 {
+		if args[1].Type != runtime.VAL_BOOL {
+			runtimeError("'remove_path' expects a boolean (recursive) as argument 2 (got %s).", typeName(args[1]))
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		if args[1].Bool {
+			return fsResult(os.RemoveAll(path))
+		}
+	}
+	return fsResult(os.Remove(path))
+}
+
+// renamePathNative moves a file or directory to a new path, replacing an existing file there.
+func renamePathNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'rename_path' expects 2 arguments (old path, new path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	from, ok := stringArg("rename_path", args, 0, "old path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	to, ok := stringArg("rename_path", args, 1, "new path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return fsResult(os.Rename(from, to))
+}
+
+// copyFileNative copies the contents and permission bits of a file to a new path, replacing an
+// existing file there. Directories cannot be copied.
+func copyFileNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'copy_file' expects 2 arguments (source path, destination path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	from, ok := stringArg("copy_file", args, 0, "source path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	to, ok := stringArg("copy_file", args, 1, "destination path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return fsResult(copyFile(from, to))
+}
+
+// copyFile copies the file at from to to.
+func copyFile(from, to string) error {
+	source, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return &fs.PathError{Op: "copy", Path: from, Err: syscall.EISDIR}
+	}
+	destination, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(destination, source)
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// tempFileNative creates a new empty file in the system's temporary directory and returns its
+// path. An optional pattern names the file, with a '*' replaced by a random string (e.g.,
+// "report-*.csv").
+func tempFileNative(argCount int, args []runtime.Value) runtime.Value {
+	pattern, ok := tempPatternArg("temp_file", argCount, args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return fsError(err)
+	}
+	file.Close()
+	return runtime.ObjVal(runtime.NewObjString(file.Name()))
+}
+
+// tempDirNative creates a new empty directory in the system's temporary directory and returns
+// its path. It takes the same optional pattern as temp_file.
+func tempDirNative(argCount int, args []runtime.Value) runtime.Value {
+	pattern, ok := tempPatternArg("temp_dir", argCount, args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return fsError(err)
+	}
+	return runtime.ObjVal(runtime.NewObjString(path))
+}
+
+// tempPatternArg returns the optional name pattern of temp_file and temp_dir.
+func tempPatternArg(name string, argCount int, args []runtime.Value) (string, bool) {
+	if argCount > 1 {
+		runtimeError("'%s' expects 0 or 1 arguments ([pattern]).", name)
+		return "", false
+	}
+	if argCount == 0 {
+		return "", true
+	}
+	return stringArg(name, args, 0, "pattern")
+}
+
+// ============================================================================
+// Native Functions: Paths
+// ============================================================================
+
+// pathJoinNative joins path elements with the system's separator and cleans the result.
+func pathJoinNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount < 1 {
+		runtimeError("'path_join' expects at least 1 argument (path elements).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	elements := make([]string, argCount)
+	for i := range elements {
+		element, ok := stringArg("path_join", args, i, "path element")
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		elements[i] = element
+	}
+	return runtime.ObjVal(runtime.NewObjString(filepath.Join(elements...)))
+}
+
+// pathNative builds a native that applies a function of one path, such as filepath.Base.
+func pathNative(name string, fn func(string) string) runtime.NativeFn {
+	return func(argCount int, args []runtime.Value) runtime.Value {
+		if argCount != 1 {
+			runtimeError("'%s' expects 1 argument (path).", name)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		path, ok := stringArg(name, args, 0, "path")
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		return runtime.ObjVal(runtime.NewObjString(fn(path)))
+	}
+}
+
+// pathAbsNative returns the absolute form of a path, resolved against the working directory.
+func pathAbsNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'path_abs' expects 1 argument (path).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	path, ok := stringArg("path_abs", args, 0, "path")
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fsError(err)
+	}
+	return runtime.ObjVal(runtime.NewObjString(abs))
+}
@@ -0,0 +1,139 @@
+package vm
+
+import (
+	"unsafe"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// reserveStack makes sure the current execution stack has FRAME_SLOTS_RESERVE free values above
+// slots (capped at STACK_MAX) before a frame starts there. The main stack is allocated at full
+// size; generator stacks grow here. Open upvalues are the only pointers into a stack, so they are
+// moved to the new slots along with the values.
+func reserveStack(slots int) {
+	needed := min(slots+FRAME_SLOTS_RESERVE, STACK_MAX)
+	if needed <= len(vm.stack) {
+		return
+	}
+	size := len(vm.stack)
+	for size < needed {
+		size *= 2
+	}
+	grown := make([]runtime.Value, min(size, STACK_MAX))
+	copy(grown, vm.stack)
+	base := uintptr(unsafe.Pointer(&vm.stack[0]))
+	for upvalue := vm.openUpvalues; upvalue != nil; upvalue = upvalue.Next {
+		index := (uintptr(unsafe.Pointer(upvalue.Location)) - base) / unsafe.Sizeof(runtime.Value{})
+		upvalue.Location = &grown[index]
+	}
+	vm.stack = grown
+}
+
+// startGenerator replaces a call to a generator function (the callee and its arguments on top of
+// the stack) with a suspended generator. The callee and arguments move to the generator's own
+// stack, where they become the slots of its first frame once it is resumed.
+func startGenerator(closure *runtime.ObjClosure, argCount int) {
+	stack := newExecutionStack(FRAME_SLOTS_RESERVE)
+	base := vm.stackTop - argCount - 1
+	stack.stackTop = copy(stack.stack, vm.stack[base:vm.stackTop])
+	stack.frames[0] = CallFrame{closure: closure, ip: 0, slots: 0}
+	stack.frameCount = 1
+	vm.stackTop = base
+	Push(runtime.ObjVal(runtime.NewGenerator(closure.Function, &stack)))
+}
+
+// resumeGenerator runs gen on its own execution stack until it yields or returns. A yielded value
+// is buffered in the generator; a generator that returns or fails is marked done and its stack is
+// released. A failure also unwinds the stack of the code that resumed it.
+func resumeGenerator(gen *runtime.ObjGenerator) InterpretResult {
+	switch gen.State {
+	case runtime.GENERATOR_DONE:
+		return INTERPRET_OK
+	case runtime.GENERATOR_RUNNING:
+		return runtimeError("Generator '%s' is already running; it cannot resume itself.", gen.Function.Name.Chars)
+	}
+
+	stack := gen.Stack.(*executionStack)
+	caller := vm.executionStack
+	previousBase := vm.baseFrame
+	vm.executionStack = *stack
+	vm.baseFrame = 0
+	gen.State = runtime.GENERATOR_RUNNING
+
+	result := run()
+	yielded := vm.yielded
+	*stack = vm.executionStack
+	vm.executionStack = caller
+	vm.baseFrame = previousBase
+	vm.yielded = false
+
+	if result != INTERPRET_OK || vm.failed {
+		// Some errors unwind the stack without returning an error from run(), so check the flag too.
+		gen.State = runtime.GENERATOR_DONE
+		gen.Stack = nil
+		resetStack()
+		return INTERPRET_RUNTIME_ERROR
+	}
+	if !yielded {
+		gen.State = runtime.GENERATOR_DONE
+		gen.Stack = nil
+		return INTERPRET_OK
+	}
+	gen.State = runtime.GENERATOR_SUSPENDED
+	gen.Buffered = true
+	gen.Value = vm.yieldValue
+	return INTERPRET_OK
+}
+
+// generatorNext returns the next value of gen, resuming it unless done() already ran it ahead.
+func generatorNext(gen *runtime.ObjGenerator) (value runtime.Value, done bool, err InterpretResult) {
+	if !gen.Buffered {
+		if err = resumeGenerator(gen); err != INTERPRET_OK {
+			return value, true, err
+		}
+		if !gen.Buffered {
+			return runtime.Value{Type: runtime.VAL_NULL}, true, INTERPRET_OK
+		}
+	}
+	value = gen.Value
+	gen.Buffered = false
+	gen.Value = runtime.Value{Type: runtime.VAL_NULL}
+	return value, false, INTERPRET_OK
+}
+
+// generatorDone reports whether gen has no more values. Answering may run the generator up to its
+// next 'yield'; that value is kept for the following next().
+func generatorDone(gen *runtime.ObjGenerator) (bool, InterpretResult) {
+	if !gen.Buffered {
+		if err := resumeGenerator(gen); err != INTERPRET_OK {
+			return true, err
+		}
+	}
+	return !gen.Buffered, INTERPRET_OK
+}
+
+// generatorMethod returns the next() or done() method of gen as a native bound to it, so
+// generators follow the same next()/done() protocol as iterable instances.
+func generatorMethod(gen *runtime.ObjGenerator, name string) (runtime.Value, bool) {
+	switch name {
+	case "next":
+		return runtime.ObjVal(runtime.NewNative(func(argCount int, args []runtime.Value) runtime.Value {
+			if argCount != 0 {
+				runtimeError("'next' expects no arguments.")
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			value, _, _ := generatorNext(gen)
+			return value
+		})), true
+	case "done":
+		return runtime.ObjVal(runtime.NewNative(func(argCount int, args []runtime.Value) runtime.Value {
+			if argCount != 0 {
+				runtimeError("'done' expects no arguments.")
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			done, _ := generatorDone(gen)
+			return runtime.Value{Type: runtime.VAL_BOOL, Bool: done}
+		})), true
+	}
+	return runtime.Value{}, false
+}
@@ -0,0 +1,300 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Native Functions: JSON
+// ============================================================================
+
+// jsonEncodeNative converts a value to a JSON string. An optional map of options accepts
+// 'indent' (a number of spaces or an indent string) and 'sort_keys' (write map keys in sorted
+// order instead of insertion order).
+func jsonEncodeNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'json_encode' expects 1 or 2 arguments: a value and an optional options map.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	encoder := jsonEncoder{visiting: make(map[any]bool)}
+	indent := ""
+	if argCount == 2 {
+		var ok bool
+		if indent, encoder.sortKeys, ok = jsonEncodeOptions(args[1]); !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+	}
+
+	if err := encoder.encode(args[0]); err != nil {
+		runtimeError("'json_encode' failed: %v", err)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if indent == "" {
+		return runtime.ObjVal(runtime.NewObjString(encoder.buf.String()))
+	}
+	var out bytes.Buffer
+	json.Indent(&out, encoder.buf.Bytes(), "", indent)
+	return runtime.ObjVal(runtime.NewObjString(out.String()))
+}
+
+// jsonEncodeOptions reads the options map of json_encode.
+func jsonEncodeOptions(arg runtime.Value) (indent string, sortKeys bool, ok bool) {
+	options, isMap := arg.Obj.(*runtime.ObjMap)
+	if arg.Type != runtime.VAL_OBJ || !isMap {
+		runtimeError("'json_encode' options must be a map (got %s).", typeName(arg))
+		return "", false, false
+	}
+	for key, value := range options.All() {
+		name, _ := key.Obj.(*runtime.ObjString)
+		text, isString := value.Obj.(*runtime.ObjString)
+		switch {
+		case name != nil && name.Chars == "indent":
+			switch {
+			case value.Type == runtime.VAL_INT && value.Int >= 0:
+				indent = strings.Repeat(" ", int(value.Int))
+			case value.Type == runtime.VAL_OBJ && isString:
+				indent = text.Chars
+			default:
+				runtimeError("'json_encode' option 'indent' must be a non-negative integer or a string.")
+				return "", false, false
+			}
+		case name != nil && name.Chars == "sort_keys":
+			if value.Type != runtime.VAL_BOOL {
+				runtimeError("'json_encode' option 'sort_keys' must be a boolean.")
+				return "", false, false
+			}
+			sortKeys = value.Bool
+		default:
+			runtimeError("'json_encode' does not have an option %s.", jsonKey(key))
+			return "", false, false
+		}
+	}
+	return indent, sortKeys, true
+}
+
+// jsonEncoder writes compact JSON. Objects on the path from the root to the value being written
+// are kept in visiting so that cycles are reported instead of recursing forever.
+type jsonEncoder struct {
+	buf      bytes.Buffer
+	sortKeys bool
+	visiting map[any]bool
+}
+
+// jsonMember is a key and value of a JSON object.
+type jsonMember struct {
+	key   string
+	value runtime.Value
+}
+
+// encode writes value as JSON. Sets are written as arrays, struct instances as objects of their
+// fields in name order, and Date, Time and DateTime values as ISO-8601 strings.
+func (e *jsonEncoder) encode(value runtime.Value) error {
+	switch value.Type {
+	case runtime.VAL_NULL:
+		e.buf.WriteString("null")
+	case runtime.VAL_BOOL:
+		e.buf.WriteString(strconv.FormatBool(value.Bool))
+	case runtime.VAL_INT:
+		e.buf.WriteString(strconv.FormatInt(value.Int, 10))
+	case runtime.VAL_NUMBER:
+		if math.IsNaN(value.Number) || math.IsInf(value.Number, 0) {
+			return fmt.Errorf("cannot encode %v", value.Number)
+		}
+		encoded, _ := json.Marshal(value.Number)
+		e.buf.Write(encoded)
+	case runtime.VAL_OBJ:
+		return e.encodeObject(value)
+	}
+	return nil
+}
+
+func (e *jsonEncoder) encodeObject(value runtime.Value) error {
+	switch obj := value.Obj.(type) {
+	case *runtime.ObjString:
+		e.writeString(obj.Chars)
+		return nil
+	case *runtime.ObjBigInt:
+		e.buf.WriteString(obj.Value.String())
+		return nil
+	case *runtime.ObjDecimal:
+		e.buf.WriteString(obj.String())
+		return nil
+	case *runtime.ObjDate:
+		e.writeString(obj.Time.Format(time.DateOnly))
+		return nil
+	case *runtime.ObjTime:
+		e.writeString(obj.Time.Format(time.TimeOnly))
+		return nil
+	case *runtime.ObjDateTime:
+		e.writeString(obj.Time.Format(time.RFC3339))
+		return nil
+	}
+
+	if e.visiting[value.Obj] {
+		return fmt.Errorf("cycle detected in %s", typeName(value))
+	}
+	e.visiting[value.Obj] = true
+	defer delete(e.visiting, value.Obj)
+
+	switch obj := value.Obj.(type) {
+	case *runtime.ObjArray:
+		return e.encodeArray(obj.Elements)
+	case *runtime.ObjSet:
+		return e.encodeArray(obj.Values())
+	case *runtime.ObjMap:
+		members := make([]jsonMember, 0, obj.Len())
+		for key, val := range obj.All() {
+			members = append(members, jsonMember{jsonKey(key), val})
+		}
+		if e.sortKeys {
+			slices.SortStableFunc(members, func(a, b jsonMember) int { return strings.Compare(a.key, b.key) })
+		}
+		return e.encodeMembers(members)
+	case *runtime.ObjInstance:
+		members := make([]jsonMember, 0, len(obj.Fields))
+		for _, name := range sortedFieldNames(obj) {
+			members = append(members, jsonMember{name.Chars, obj.Fields[name]})
+		}
+		return e.encodeMembers(members)
+	}
+	return fmt.Errorf("cannot encode a value of type %s", typeName(value))
+}
+
+func (e *jsonEncoder) encodeArray(elements []runtime.Value) error {
+	e.buf.WriteByte('[')
+	for i, element := range elements {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		if err := e.encode(element); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(']')
+	return nil
+}
+
+func (e *jsonEncoder) encodeMembers(members []jsonMember) error {
+	e.buf.WriteByte('{')
+	for i, member := range members {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		e.writeString(member.key)
+		e.buf.WriteByte(':')
+		if err := e.encode(member.value); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte('}')
+	return nil
+}
+
+// jsonKey returns the object key for a map key; numbers, booleans and null are written the way
+// they print, as JSON only has string keys.
+func jsonKey(key runtime.Value) string {
+	return toStr(1, []runtime.Value{key}).Obj.(*runtime.ObjString).Chars
+}
+
+// writeString writes s as a JSON string literal, leaving '<', '>' and '&' unescaped.
+func (e *jsonEncoder) writeString(s string) {
+	encoder := json.NewEncoder(&e.buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	e.buf.Truncate(e.buf.Len() - 1) // Drop the newline Encode appends.
+}
+
+// jsonDecodeNative parses a JSON string. Objects become maps keeping the order of their keys,
+// integers that fit in 64 bits become ints and other numbers become floats. Malformed input is a
+// runtime error reporting the line and column of the problem.
+func jsonDecodeNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'json_decode' expects 1 argument: a JSON string.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	sourceObj, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'json_decode' expects a string (got %s).", typeName(args[0]))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	source := sourceObj.Chars
+
+	// Validate first: the syntax errors of Unmarshal carry exact offsets, while the token stream
+	// used to build the values (and keep the order of object keys) reports them less precisely.
+	var raw json.RawMessage
+	if err := json.Unmarshal([]byte(source), &raw); err != nil {
+		offset := len(source)
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) && syntaxErr.Offset > 0 && !strings.HasSuffix(syntaxErr.Error(), "end of JSON input") {
+			offset = int(syntaxErr.Offset) - 1 // Offset counts the offending byte itself.
+		}
+		line, column := jsonPosition(source, offset)
+		runtimeError("'json_decode' failed at line %d, column %d: %s", line, column, strings.TrimPrefix(err.Error(), "json: "))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return jsonValue(decoder)
+}
+
+// jsonValue reads the next value from the token stream of valid JSON. Numbers too large for a
+// float become infinities.
+func jsonValue(decoder *json.Decoder) runtime.Value {
+	token, _ := decoder.Token()
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '[' {
+			elements := []runtime.Value{}
+			for decoder.More() {
+				elements = append(elements, jsonValue(decoder))
+			}
+			decoder.Token() // ']'
+			return runtime.ObjVal(runtime.NewArray(elements))
+		}
+		object := runtime.NewMap()
+		for decoder.More() {
+			key, _ := decoder.Token()
+			object.Set(runtime.ObjVal(runtime.NewObjString(key.(string))), jsonValue(decoder))
+		}
+		decoder.Token() // '}'
+		return runtime.ObjVal(object)
+	case string:
+		return runtime.ObjVal(runtime.NewObjString(token))
+	case json.Number:
+		if n, err := token.Int64(); err == nil {
+			return runtime.IntVal(n)
+		}
+		// An integer too large for an int is kept exact, as json_encode writes BigInts.
+		if !strings.ContainsAny(string(token), ".eE") {
+			if n, ok := new(big.Int).SetString(string(token), 10); ok {
+				return runtime.ObjVal(runtime.NewBigInt(n))
+			}
+		}
+		n, _ := strconv.ParseFloat(string(token), 64)
+		return runtime.Value{Type: runtime.VAL_NUMBER, Number: n}
+	case bool:
+		return runtime.Value{Type: runtime.VAL_BOOL, Bool: token}
+	}
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// jsonPosition returns the 1-based line and column (counted in code points) of a byte offset.
+func jsonPosition(source string, offset int) (line, column int) {
+	prefix := source[:min(offset, len(source))]
+	line = strings.Count(prefix, "\n") + 1
+	column = utf8.RuneCountInString(prefix[strings.LastIndexByte(prefix, '\n')+1:]) + 1
+	return line, column
+}
@@ -0,0 +1,389 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// newMathModule builds std.math, a module holding numeric constants and functions (e.g.,
+// math.pi, math.sqrt(2)). Functions of one number also accept an array of numbers and
+// return a new array with the function applied to each element, like unary '-' does.
+func newMathModule() *runtime.ObjModule {
+	module := runtime.NewModule(runtime.NewObjString("math"))
+	set := func(name string, value runtime.Value) {
+		module.Fields[runtime.NewObjString(name)] = value
+	}
+	number := func(n float64) runtime.Value {
+		return runtime.Value{Type: runtime.VAL_NUMBER, Number: n}
+	}
+
+	// Constants
+	set("pi", number(math.Pi))
+	set("tau", number(2*math.Pi))
+	set("e", number(math.E))
+	set("inf", number(math.Inf(1)))
+	set("nan", number(math.NaN()))
+	set("epsilon", number(math.Nextafter(1, 2)-1))
+	set("max_int", runtime.IntVal(math.MaxInt64))
+	set("min_int", runtime.IntVal(math.MinInt64))
+
+	// Rounding and sign; integers pass through unchanged.
+	set("abs", mathElementWise("abs", mathAbs))
+	set("sign", mathElementWise("sign", mathSign))
+	set("floor", mathElementWise("floor", mathRounding(math.Floor)))
+	set("ceil", mathElementWise("ceil", mathRounding(math.Ceil)))
+	set("trunc", mathElementWise("trunc", mathRounding(math.Trunc)))
+	set("round", runtime.ObjVal(runtime.NewNative(mathRoundNative)))
+
+	// Powers, roots and logarithms
+	set("sqrt", mathElementWise("sqrt", mathFloat(math.Sqrt)))
+	set("cbrt", mathElementWise("cbrt", mathFloat(math.Cbrt)))
+	set("exp", mathElementWise("exp", mathFloat(math.Exp)))
+	set("log2", mathElementWise("log2", mathFloat(math.Log2)))
+	set("log10", mathElementWise("log10", mathFloat(math.Log10)))
+	set("log", runtime.ObjVal(runtime.NewNative(mathLogNative)))
+	set("pow", runtime.ObjVal(runtime.NewNative(mathPowNative)))
+	set("hypot", runtime.ObjVal(runtime.NewNative(mathHypotNative)))
+
+	// Trigonometry (angles in radians)
+	set("sin", mathElementWise("sin", mathFloat(math.Sin)))
+	set("cos", mathElementWise("cos", mathFloat(math.Cos)))
+	set("tan", mathElementWise("tan", mathFloat(math.Tan)))
+	set("asin", mathElementWise("asin", mathFloat(math.Asin)))
+	set("acos", mathElementWise("acos", mathFloat(math.Acos)))
+	set("atan", mathElementWise("atan", mathFloat(math.Atan)))
+	set("sinh", mathElementWise("sinh", mathFloat(math.Sinh)))
+	set("cosh", mathElementWise("cosh", mathFloat(math.Cosh)))
+	set("tanh", mathElementWise("tanh", mathFloat(math.Tanh)))
+	set("atan2", runtime.ObjVal(runtime.NewNative(mathAtan2Native)))
+	set("degrees", mathElementWise("degrees", mathFloat(func(x float64) float64 { return x * 180 / math.Pi })))
+	set("radians", mathElementWise("radians", mathFloat(func(x float64) float64 { return x * math.Pi / 180 })))
+
+	// Comparison and integer functions
+	set("min", runtime.ObjVal(runtime.NewNative(mathExtremeNative("min", -1))))
+	set("max", runtime.ObjVal(runtime.NewNative(mathExtremeNative("max", 1))))
+	set("clamp", runtime.ObjVal(runtime.NewNative(mathClampNative)))
+	set("gcd", runtime.ObjVal(runtime.NewNative(mathGcdNative)))
+	set("lcm", runtime.ObjVal(runtime.NewNative(mathLcmNative)))
+
+	// Classification
+	set("is_nan", mathElementWise("is_nan", func(v runtime.Value) runtime.Value {
+		return runtime.Value{Type: runtime.VAL_BOOL, Bool: v.Type == runtime.VAL_NUMBER && math.IsNaN(v.Number)}
+	}))
+	set("is_finite", mathElementWise("is_finite", func(v runtime.Value) runtime.Value {
+		finite := v.Type == runtime.VAL_INT || !(math.IsNaN(v.Number) || math.IsInf(v.Number, 0))
+		return runtime.Value{Type: runtime.VAL_BOOL, Bool: finite}
+	}))
+	set("is_inf", mathElementWise("is_inf", func(v runtime.Value) runtime.Value {
+		return runtime.Value{Type: runtime.VAL_BOOL, Bool: v.Type == runtime.VAL_NUMBER && math.IsInf(v.Number, 0)}
+	}))
+
+	return module
+}
+
+// mathElementWise wraps a function of one number as a native that also accepts arrays (see
+// mathApply).
+func mathElementWise(name string, fn func(runtime.Value) runtime.Value) runtime.Value {
+	return runtime.ObjVal(runtime.NewNative(func(argCount int, args []runtime.Value) runtime.Value {
+		if argCount != 1 {
+			runtimeError("'math.%s' expects 1 argument: a number or an array of numbers.", name)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		return mathApply(name, args[0], fn)
+	}))
+}
+
+// mathApply applies fn to a number, or to every element of an array of numbers, returning a new
+// array in that case.
+func mathApply(name string, arg runtime.Value, fn func(runtime.Value) runtime.Value) runtime.Value {
+	if array, ok := arg.Obj.(*runtime.ObjArray); ok && arg.Type == runtime.VAL_OBJ {
+		results := make([]runtime.Value, len(array.Elements))
+		for i, elem := range array.Elements {
+			if !runtime.IsNumber(elem) {
+				runtimeError("'math.%s' requires an array of numbers (got %s element).", name, typeName(elem))
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			results[i] = fn(elem)
+		}
+		return runtime.ObjVal(runtime.NewArray(results))
+	}
+	if !runtime.IsNumber(arg) {
+		runtimeError("'math.%s' requires a number or an array of numbers (got %s).", name, typeName(arg))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return fn(arg)
+}
+
+// mathFloat adapts a float function to mathElementWise; integers are converted first.
+func mathFloat(fn func(float64) float64) func(runtime.Value) runtime.Value {
+	return func(v runtime.Value) runtime.Value {
+		return runtime.Value{Type: runtime.VAL_NUMBER, Number: fn(runtime.AsNumber(v))}
+	}
+}
+
+// mathRounding adapts a rounding function to mathElementWise. The result is an integer when it
+// fits in one, so math.floor(2.5) can be used as an array index; NaN and infinities stay floats.
+func mathRounding(fn func(float64) float64) func(runtime.Value) runtime.Value {
+	return func(v runtime.Value) runtime.Value {
+		if v.Type == runtime.VAL_INT {
+			return v
+		}
+		return floatToIntIfExact(fn(v.Number))
+	}
+}
+
+// floatToIntIfExact returns f as an integer value when it is integral and fits in an int64.
+func floatToIntIfExact(f float64) runtime.Value {
+	if n, ok := runtime.AsInt(runtime.Value{Type: runtime.VAL_NUMBER, Number: f}); ok {
+		return runtime.IntVal(n)
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: f}
+}
+
+// mathAbs returns the absolute value of v. The absolute value of math.min_int does not fit in an
+// int and is returned as a BigInt.
+func mathAbs(v runtime.Value) runtime.Value {
+	if v.Type == runtime.VAL_INT {
+		if v.Int == math.MinInt64 {
+			return runtime.ObjVal(runtime.NewBigInt(new(big.Int).Neg(big.NewInt(v.Int))))
+		}
+		if v.Int < 0 {
+			return runtime.IntVal(-v.Int)
+		}
+		return v
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Abs(v.Number)}
+}
+
+// mathSign returns -1, 0 or 1 (NaN for NaN), keeping the integer or float type of v.
+func mathSign(v runtime.Value) runtime.Value {
+	if v.Type == runtime.VAL_INT {
+		switch {
+		case v.Int > 0:
+			return runtime.IntVal(1)
+		case v.Int < 0:
+			return runtime.IntVal(-1)
+		}
+		return runtime.IntVal(0)
+	}
+	switch {
+	case v.Number > 0:
+		return runtime.Value{Type: runtime.VAL_NUMBER, Number: 1}
+	case v.Number < 0:
+		return runtime.Value{Type: runtime.VAL_NUMBER, Number: -1}
+	}
+	return v // 0, -0 or NaN
+}
+
+// mathNumberArgs checks that every argument of the named native is a number.
+func mathNumberArgs(name string, args []runtime.Value) bool {
+	for _, arg := range args {
+		if !runtime.IsNumber(arg) {
+			runtimeError("'math.%s' requires numbers (got %s).", name, typeName(arg))
+			return false
+		}
+	}
+	return true
+}
+
+// mathRoundNative rounds half away from zero: math.round(x) gives an integer, and
+// math.round(x, digits) keeps that many decimal places. Arrays are rounded element-wise.
+func mathRoundNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'math.round' expects 1 or 2 arguments: a number (or array of numbers) and [digits].")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if argCount == 1 {
+		return mathApply("round", args[0], mathRounding(math.Round))
+	}
+	digits, ok := runtime.AsInt(args[1])
+	if !ok {
+		runtimeError("'math.round' digits must be an integer (got %s).", typeName(args[1]))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	scale := math.Pow(10, float64(digits))
+	return mathApply("round", args[0], func(v runtime.Value) runtime.Value {
+		if v.Type == runtime.VAL_INT && digits >= 0 {
+			return v
+		}
+		return runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Round(runtime.AsNumber(v)*scale) / scale}
+	})
+}
+
+// mathLogNative returns the natural logarithm of x, or its logarithm in the given base.
+func mathLogNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'math.log' expects 1 or 2 arguments: a number (or array of numbers) and [base].")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	divisor := 1.0
+	if argCount == 2 {
+		if !mathNumberArgs("log", args[1:]) {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		divisor = math.Log(runtime.AsNumber(args[1]))
+	}
+	return mathApply("log", args[0], mathFloat(func(x float64) float64 { return math.Log(x) / divisor }))
+}
+
+// mathPowNative raises a number (or each number in an array) to a power, following the rules of
+// the '**' operator.
+func mathPowNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'math.pow' expects 2 arguments: a base (or array of bases) and an exponent.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if !mathNumberArgs("pow", args[1:]) {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	exponent := args[1]
+	return mathApply("pow", args[0], func(v runtime.Value) runtime.Value {
+		result, _ := powNumbers(v, exponent)
+		return result
+	})
+}
+
+// mathHypotNative returns the Euclidean length of its arguments, sqrt(a*a + b*b + ...).
+func mathHypotNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount < 1 {
+		runtimeError("'math.hypot' expects at least 1 argument.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if !mathNumberArgs("hypot", args[:argCount]) {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	length := 0.0
+	for _, arg := range args[:argCount] {
+		length = math.Hypot(length, runtime.AsNumber(arg))
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: length}
+}
+
+func mathAtan2Native(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'math.atan2' expects 2 arguments: y and x.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if !mathNumberArgs("atan2", args[:2]) {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Atan2(runtime.AsNumber(args[0]), runtime.AsNumber(args[1]))}
+}
+
+// mathExtremeNative builds math.min (direction -1) and math.max (direction 1). They take several
+// numbers or a single array and return the chosen value unchanged, so integers stay integers.
+func mathExtremeNative(name string, direction int) runtime.NativeFn {
+	return func(argCount int, args []runtime.Value) runtime.Value {
+		values := args[:argCount]
+		if argCount == 1 {
+			if array, ok := args[0].Obj.(*runtime.ObjArray); ok && args[0].Type == runtime.VAL_OBJ {
+				values = array.Elements
+			}
+		}
+		if len(values) == 0 {
+			runtimeError("'math.%s' expects at least one number.", name)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		if !mathNumberArgs(name, values) {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		best := values[0]
+		for _, v := range values[1:] {
+			if compareNumbers(v, best) == direction {
+				best = v
+			}
+		}
+		return best
+	}
+}
+
+// mathClampNative limits a number (or each number in an array) to the range [low, high].
+func mathClampNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 3 {
+		runtimeError("'math.clamp' expects 3 arguments: a number (or array of numbers), low and high.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	if !mathNumberArgs("clamp", args[1:3]) {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	low, high := args[1], args[2]
+	if compareNumbers(low, high) > 0 {
+		runtimeError("'math.clamp' low bound must not be greater than the high bound.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return mathApply("clamp", args[0], func(v runtime.Value) runtime.Value {
+		switch {
+		case compareNumbers(v, low) < 0:
+			return low
+		case compareNumbers(v, high) > 0:
+			return high
+		}
+		return v
+	})
+}
+
+// mathIntArgs converts the arguments of the named native to integers.
+func mathIntArgs(name string, args []runtime.Value) ([]int64, bool) {
+	ints := make([]int64, len(args))
+	for i, arg := range args {
+		n, ok := runtime.AsInt(arg)
+		if !ok {
+			runtimeError("'math.%s' requires integers (got %s).", name, typeName(arg))
+			return nil, false
+		}
+		ints[i] = n
+	}
+	return ints, true
+}
+
+// intOrBigInt returns n as an int when it fits in 64 bits and as a BigInt otherwise.
+func intOrBigInt(n *big.Int) runtime.Value {
+	if n.IsInt64() {
+		return runtime.IntVal(n.Int64())
+	}
+	return runtime.ObjVal(runtime.NewBigInt(n))
+}
+
+// mathGcdNative returns the greatest common divisor of its integer arguments (never negative).
+// Results that do not fit in an int, such as math.gcd(math.min_int, 0), are BigInts.
+func mathGcdNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount < 2 {
+		runtimeError("'math.gcd' expects at least 2 integers.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	ints, ok := mathIntArgs("gcd", args[:argCount])
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	result := new(big.Int)
+	for _, n := range ints {
+		result.GCD(nil, nil, result, big.NewInt(n))
+	}
+	return intOrBigInt(result)
+}
+
+// mathLcmNative returns the least common multiple of its integer arguments (0 if any is 0).
+// Results that do not fit in an int are BigInts.
+func mathLcmNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount < 2 {
+		runtimeError("'math.lcm' expects at least 2 integers.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	ints, ok := mathIntArgs("lcm", args[:argCount])
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	result := new(big.Int).Abs(big.NewInt(ints[0]))
+	divisor := new(big.Int)
+	for _, n := range ints[1:] {
+		if result.Sign() == 0 || n == 0 {
+			result.SetInt64(0)
+			continue
+		}
+		next := new(big.Int).Abs(big.NewInt(n))
+		result.Mul(result.Quo(result, divisor.GCD(nil, nil, result, next)), next)
+	}
+	return intOrBigInt(result)
+}
@@ -4,12 +4,14 @@ import (
 	"bufio"
 	"fmt"
 	"math"
+	"math/big"
 	"math/rand"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/cryptrunner49/tulipscript/internal/common"
 	"github.com/cryptrunner49/tulipscript/internal/runtime"
@@ -29,7 +31,8 @@ func unescapeString(s string) string {
 	return s
 }
 
-// defineAllNatives registers all native functions (built-in functions) to the VM.
+// defineAllNatives registers the native functions that live only in the global scope. The rest
+// are grouped into the std modules by defineStdModules.
 func defineAllNatives() {
 	// Debug
 	defineNative("enable_debug", enableDebugPrint)
@@ -37,118 +40,36 @@ func defineAllNatives() {
 	defineNative("disable_debug", disableDebugPrint)
 	defineNative("disable_trace", disableTraceExecution)
 
-	// String
+	// Conversion
 	defineNative("to_str", toStr)
-	defineNative("to_chars", toCharsNative)
-	defineNative("char_at", charAtNative)
-	defineNative("substring", substringNative)
-	defineNative("str_index_of", strIndexOfNative)
-	defineNative("str_last_index_of", strLastIndexOfNative)
-	defineNative("str_contains", strContainsNative)
-	defineNative("starts_with", startsWithNative)
-	defineNative("ends_with", endsWithNative)
-	defineNative("to_upper", toUpperNative)
-	defineNative("to_lower", toLowerNative)
-	defineNative("trim", trimNative)
-	defineNative("split", splitNative)
-	defineNative("replace", replaceNative)
-	defineNative("str_length", strLengthNative)
-
-	// Array
-	defineNative("len", arrayLenNative)
-	defineNative("push", arrayPushNative)
-	defineNative("pop", arrayPopNative)
-	defineNative("array_sort", arraySortNative)
-	defineNative("array_split", arraySplitNative)
-	defineNative("array_join", arrayJoinNative)
-	defineNative("array_sorted_push", arraySortedPushNative)
-	defineNative("array_linear_search", arrayLinearSearchNative)
-	defineNative("array_binary_search", arrayBinarySearchNative)
-	defineNative("index_of", arrayIndexOfNative)
-	defineNative("last_index_of", arrayLastIndexOfNative)
-	defineNative("array_contains", arrayContainsNative)
-	defineNative("array_clear", arrayClearNative)
-	defineNative("array_reverse", arrayReverseNative)
-	defineNative("array_to_string", arrayToStringNative)
-	defineNative("array_remove", arrayRemoveNative)
 
 	// Iterator
-	defineNative("array_iter", arrayIterNative)
 	defineNative("iter_next", iterNextNative)
 	defineNative("iter_value", iterValueNative)
 	defineNative("iter_done", iterDoneNative)
 
-	// Map
-	defineNative("map_remove", mapRemoveNative)
-	defineNative("map_contains_key", mapContainsKeyNative)
-	defineNative("map_contains_value", mapContainsValueNative)
-	defineNative("map_size", mapSizeNative)
-	defineNative("map_clear", mapClearNative)
-	defineNative("map_keys", mapKeysNative)
-	defineNative("map_values", mapValuesNative)
-
-	// Date
-	defineNative("Date", dateNew)
-	defineNative("date_now", dateNow)
-	defineNative("date_parse_datetime", dateParseDateTime)
-	defineNative("date_format_datetime", dateFormatDateTime)
-	defineNative("date_add_datetime", dateAddDateTime)
-	defineNative("date_subtract_datetime", dateSubtractDateTime)
-	defineNative("date_get_component", dateGetDateTimeComponent)
-	defineNative("date_set_component", dateSetDateTimeComponent)
-	defineNative("date_add_days", dateAddDays)
-	defineNative("date_subtract_days", dateSubtractDays)
-
-	// Time
-	defineNative("Time", timeNew)
-	defineNative("time_now", timeNow)
-	defineNative("time_parse", timeParseTime)
-	defineNative("time_format", timeFormatTime)
-	defineNative("time_add", timeAddTime)
-	defineNative("time_subtract", timeSubtractTime)
-	defineNative("time_get_timezone", timeGetTimeZone)
-	defineNative("time_convert_timezone", timeConvertTimeZone)
-
-	// DateTime
-	defineNative("DateTime", dateTimeNew)
-	defineNative("datetime_now", dateTimeNow)
-	defineNative("datetime_parse", dateTimeParseDateTime)
-	defineNative("datetime_format", dateTimeFormatDateTime)
-	defineNative("datetime_add", dateTimeAddDateTime)
-	defineNative("datetime_subtract", dateTimeSubtractDateTime)
-	defineNative("datetime_get_component", dateTimeGetDateTimeComponent)
-	defineNative("datetime_set_component", dateTimeSetDateTimeComponent)
-	defineNative("datetime_add_days", dateTimeAddDays)
-	defineNative("datetime_subtract_days", dateTimeSubtractDays)
-
 	// Random Functions
 	defineNative("shuffle", shuffleNative)
 	defineNative("random_between", randomBetweenNative)
 	defineNative("random_string", randomStringNative)
 
-	// Output Functions
-	defineNative("print", printNative)
-	defineNative("println", printlnNative)
-	defineNative("printf", printfNative)
-
-	// Input Functions
-	defineNative("scan", scanNative)
-	defineNative("scanln", scanlnNative)
-	defineNative("scanf", scanfNative)
-
-	// Formatting Functions
-	defineNative("sprintf", sprintfNative)
-	defineNative("errorf", errorfNative)
-
-	// File Operations
-	defineNative("read_file", readFileNative)
-	defineNative("write_file", writeFileNative)
+	// Numeric Functions
+	defineNative("bigint", bigintNative)
+	defineNative("decimal", decimalNative)
+	defineNative("decimal_round", decimalRoundNative)
+	defineNative("decimal_set_scale", decimalSetScaleNative)
+	defineNative("decimal_set_rounding", decimalSetRoundingNative)
 
 	// Utility Functions
 	defineNative("parse_int", parseIntNative)
+	defineNative("to_int", toIntNative)
+	defineNative("to_float", toFloatNative)
+	defineNative("equals", equalsNative)
 
 	// Types
 	defineNative("get_runtype", getRunTypeNative)
+	defineNative("is_int", isIntNative)
+	defineNative("is_error", isErrorNative)
 
 	// Others
 	defineNative("clock", clockNative)
@@ -156,13 +77,13 @@ func defineAllNatives() {
 
 // defineNative registers a single native function in the VM's global table.
 // It creates a string object for the function name, wraps the native function in an ObjNative,
-// and then stores it in the globals map.
+// and then stores it in the globals map as a constant.
 func defineNative(name string, function runtime.NativeFn) {
 	nameObj := runtime.NewObjString(name)
 	Push(runtime.Value{Type: runtime.VAL_OBJ, Obj: nameObj})
 	nativeObj := &runtime.ObjNative{Function: function}
 	Push(runtime.Value{Type: runtime.VAL_OBJ, Obj: nativeObj})
-	vm.globals[nameObj] = GlobalVar{Value: runtime.Value{Type: runtime.VAL_OBJ, Obj: nativeObj}, IsConst: false}
+	vm.globals[nameObj] = GlobalVar{Value: runtime.Value{Type: runtime.VAL_OBJ, Obj: nativeObj}, IsConst: true, IsBuiltin: coreBuiltins[name]}
 	Pop()
 	Pop()
 }
@@ -228,6 +149,8 @@ func toStr(argCount int, args []runtime.Value) runtime.Value {
 		str = "null"
 	case runtime.VAL_NUMBER:
 		str = fmt.Sprintf("%g", value.Number)
+	case runtime.VAL_INT:
+		str = strconv.FormatInt(value.Int, 10)
 	case runtime.VAL_OBJ:
 		switch obj := value.Obj.(type) {
 		case *runtime.ObjString:
@@ -236,6 +159,8 @@ func toStr(argCount int, args []runtime.Value) runtime.Value {
 			str = arrayToString(obj)
 		case *runtime.ObjMap:
 			str = mapToString(obj)
+		case *runtime.ObjSet:
+			str = setToString(obj)
 		case *runtime.ObjInstance:
 			str = instanceToString(obj)
 		case *runtime.ObjDate:
@@ -244,6 +169,24 @@ func toStr(argCount int, args []runtime.Value) runtime.Value {
 			str = timeToString(obj)
 		case *runtime.ObjDateTime:
 			str = dateTimeToString(obj)
+		case *runtime.ObjBigInt:
+			str = obj.Value.String()
+		case *runtime.ObjDecimal:
+			str = obj.String()
+		case *runtime.ObjRange:
+			str = obj.String()
+		case *runtime.ObjGenerator:
+			str = "<generator " + obj.Function.Name.Chars + ">"
+		case *runtime.ObjCSVReader:
+			str = "<csv reader " + obj.Path + ">"
+		case *runtime.ObjRegex:
+			str = obj.String()
+		case *runtime.ObjError:
+			str = obj.String()
+		case *runtime.ObjFile:
+			str = "<file " + obj.Path + ">"
+		case *runtime.ObjBytes:
+			str = obj.String()
 		case *runtime.ObjFunction:
 			if obj.Name != nil {
 				str = "<fn " + obj.Name.Chars + ">"
@@ -296,11 +239,11 @@ func mapToString(mapObj *runtime.ObjMap) string {
 	var sb strings.Builder
 	sb.WriteString("{")
 	first := true
-	for key, value := range mapObj.Entries {
+	for key, value := range mapObj.All() {
 		if !first {
 			sb.WriteString(", ")
 		}
-		sb.WriteString(key.Chars)
+		sb.WriteString(toStr(1, []runtime.Value{key}).Obj.(*runtime.ObjString).Chars)
 		sb.WriteString(": ")
 		strVal := toStr(1, []runtime.Value{value})
 		if strObj, ok := strVal.Obj.(*runtime.ObjString); ok {
@@ -314,6 +257,21 @@ func mapToString(mapObj *runtime.ObjMap) string {
 	return sb.String()
 }
 
+func setToString(set *runtime.ObjSet) string {
+	var sb strings.Builder
+	sb.WriteString("#{")
+	first := true
+	for value := range set.All() {
+		if !first {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(toStr(1, []runtime.Value{value}).Obj.(*runtime.ObjString).Chars)
+		first = false
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
 func instanceToString(instance *runtime.ObjInstance) string {
 	var sb strings.Builder
 	sb.WriteString("<")
@@ -365,13 +323,23 @@ func toCharsNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("to_chars() expects a string argument")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	chars := make([]runtime.Value, len(strObj.Chars))
-	for i, r := range strObj.Chars {
-		chars[i] = runtime.ObjVal(runtime.NewObjString(string(r)))
+	chars := make([]runtime.Value, 0, utf8.RuneCountInString(strObj.Chars))
+	for _, r := range strObj.Chars {
+		chars = append(chars, runtime.ObjVal(runtime.NewObjString(string(r))))
 	}
 	return runtime.ObjVal(runtime.NewArray(chars))
 }
 
+// runeIndex converts a byte offset into s to the number of characters (code points) before it,
+// so the string natives report positions the same way char_at and substring accept them. A
+// negative offset (not found) is returned unchanged.
+func runeIndex(s string, byteIndex int) int {
+	if byteIndex < 0 {
+		return byteIndex
+	}
+	return utf8.RuneCountInString(s[:byteIndex])
+}
+
 func charAtNative(argCount int, args []runtime.Value) runtime.Value {
 	if argCount != 2 {
 		runtimeError("'char_at' expects 2 arguments: a string and an index.")
@@ -382,15 +350,16 @@ func charAtNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'char_at' requires a string as first argument.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[1]) {
 		runtimeError("'char_at' requires a number as second argument.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	index := int(args[1].Number)
-	if index < 0 || index >= len(strObj.Chars) {
+	index := int(runtime.AsNumber(args[1]))
+	chars := []rune(strObj.Chars)
+	if index < 0 || index >= len(chars) {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	return runtime.ObjVal(runtime.NewObjString(string(strObj.Chars[index])))
+	return runtime.ObjVal(runtime.NewObjString(string(chars[index])))
 }
 
 func substringNative(argCount int, args []runtime.Value) runtime.Value {
@@ -403,22 +372,23 @@ func substringNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'substring' requires a string as first argument.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_NUMBER || args[2].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[1]) || !runtime.IsNumber(args[2]) {
 		runtimeError("'substring' requires numbers as second and third arguments.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	start := int(args[1].Number)
-	end := int(args[2].Number)
+	start := int(runtime.AsNumber(args[1]))
+	end := int(runtime.AsNumber(args[2]))
+	chars := []rune(strObj.Chars)
 	if start < 0 {
 		start = 0
 	}
-	if end > len(strObj.Chars) {
-		end = len(strObj.Chars)
+	if end > len(chars) {
+		end = len(chars)
 	}
-	if start >= end || start >= len(strObj.Chars) {
+	if start >= end || start >= len(chars) {
 		return runtime.ObjVal(runtime.NewObjString(""))
 	}
-	return runtime.ObjVal(runtime.NewObjString(strObj.Chars[start:end]))
+	return runtime.ObjVal(runtime.NewObjString(string(chars[start:end])))
 }
 
 func strIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
@@ -437,7 +407,7 @@ func strIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	index := strings.Index(strObj.Chars, subStrObj.Chars)
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(index)}
+	return runtime.IntVal(int64(runeIndex(strObj.Chars, index)))
 }
 
 func strLastIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
@@ -456,7 +426,7 @@ func strLastIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	index := strings.LastIndex(strObj.Chars, subStrObj.Chars)
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(index)}
+	return runtime.IntVal(int64(runeIndex(strObj.Chars, index)))
 }
 
 func strContainsNative(argCount int, args []runtime.Value) runtime.Value {
@@ -608,7 +578,55 @@ func strLengthNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'str_length' requires a string argument.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(len(strObj.Chars))}
+	return runtime.IntVal(int64(utf8.RuneCountInString(strObj.Chars)))
+}
+
+// strByteLengthNative returns the length of a string in UTF-8 bytes.
+func strByteLengthNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'str_byte_length' expects 1 argument: a string.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	strObj, ok := args[0].Obj.(*runtime.ObjString)
+	if !ok || args[0].Type != runtime.VAL_OBJ {
+		runtimeError("'str_byte_length' requires a string argument.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.IntVal(int64(len(strObj.Chars)))
+}
+
+// strGraphemesNative splits a string into user-perceived characters (grapheme clusters), so an
+// accented letter, a flag or an emoji ZWJ sequence is a single element.
+func strGraphemesNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'str_graphemes' expects 1 argument: a string.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	strObj, ok := args[0].Obj.(*runtime.ObjString)
+	if !ok || args[0].Type != runtime.VAL_OBJ {
+		runtimeError("'str_graphemes' requires a string argument.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	graphemes := runtime.Graphemes(strObj.Chars)
+	result := make([]runtime.Value, len(graphemes))
+	for i, g := range graphemes {
+		result[i] = runtime.ObjVal(runtime.NewObjString(g))
+	}
+	return runtime.ObjVal(runtime.NewArray(result))
+}
+
+// strGraphemeLengthNative returns the number of grapheme clusters in a string.
+func strGraphemeLengthNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'str_grapheme_length' expects 1 argument: a string.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	strObj, ok := args[0].Obj.(*runtime.ObjString)
+	if !ok || args[0].Type != runtime.VAL_OBJ {
+		runtimeError("'str_grapheme_length' requires a string argument.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.IntVal(int64(runtime.GraphemeCount(strObj.Chars)))
 }
 
 func arrayLenNative(argCount int, args []runtime.Value) runtime.Value {
@@ -617,18 +635,17 @@ func arrayLenNative(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	if args[0].Type != runtime.VAL_OBJ {
-		runtimeError("'len' can only be used on arrays.")
+		runtimeError("'len' can only be used on arrays and bytes.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	array, ok := args[0].Obj.(*runtime.ObjArray)
-	if !ok {
-		runtimeError("'len' can only be used on arrays.")
-		return runtime.Value{Type: runtime.VAL_NULL}
-	}
-	return runtime.Value{
-		Type:   runtime.VAL_NUMBER,
-		Number: float64(len(array.Elements)),
+	switch obj := args[0].Obj.(type) {
+	case *runtime.ObjArray:
+		return runtime.IntVal(int64(len(obj.Elements)))
+	case *runtime.ObjBytes:
+		return runtime.IntVal(int64(len(obj.Data)))
 	}
+	runtimeError("'len' can only be used on arrays and bytes.")
+	return runtime.Value{Type: runtime.VAL_NULL}
 }
 
 func arrayPushNative(argCount int, args []runtime.Value) runtime.Value {
@@ -648,10 +665,7 @@ func arrayPushNative(argCount int, args []runtime.Value) runtime.Value {
 	for i := 1; i < argCount; i++ {
 		array.Elements = append(array.Elements, args[i])
 	}
-	return runtime.Value{
-		Type:   runtime.VAL_NUMBER,
-		Number: float64(len(array.Elements)),
-	}
+	return runtime.IntVal(int64(len(array.Elements)))
 }
 
 func arrayPopNative(argCount int, args []runtime.Value) runtime.Value {
@@ -701,16 +715,14 @@ func iterNextNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'iter_next' expects 1 argument (the iterator).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[0].Type != runtime.VAL_OBJ {
+	iter, ok := args[0].Obj.(runtime.Iterator)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
 		runtimeError("'iter_next' can only be used on iterators.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	iter, ok := args[0].Obj.(*runtime.ObjArrayIterator)
-	if !ok {
-		runtimeError("'iter_next' can only be used on iterators.")
-		return runtime.Value{Type: runtime.VAL_NULL}
+	if !iter.Done() {
+		iter.Advance()
 	}
-	iter.Index++
 	return runtime.Value{Type: runtime.VAL_NULL}
 }
 
@@ -719,19 +731,15 @@ func iterValueNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'iter_value' expects 1 argument (the iterator).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[0].Type != runtime.VAL_OBJ {
+	iter, ok := args[0].Obj.(runtime.Iterator)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
 		runtimeError("'iter_value' can only be used on iterators.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	iter, ok := args[0].Obj.(*runtime.ObjArrayIterator)
-	if !ok {
-		runtimeError("'iter_value' can only be used on iterators.")
-		return runtime.Value{Type: runtime.VAL_NULL}
-	}
-	if iter.Index >= len(iter.Array.Elements) {
+	if iter.Done() {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	return iter.Array.Elements[iter.Index]
+	return iter.Value()
 }
 
 func iterDoneNative(argCount int, args []runtime.Value) runtime.Value {
@@ -739,18 +747,14 @@ func iterDoneNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'iter_done' expects 1 argument (the iterator).")
 		return runtime.Value{Type: runtime.VAL_BOOL, Bool: true}
 	}
-	if args[0].Type != runtime.VAL_OBJ {
-		runtimeError("'iter_done' can only be used on iterators.")
-		return runtime.Value{Type: runtime.VAL_BOOL, Bool: true}
-	}
-	iter, ok := args[0].Obj.(*runtime.ObjArrayIterator)
-	if !ok {
+	iter, ok := args[0].Obj.(runtime.Iterator)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
 		runtimeError("'iter_done' can only be used on iterators.")
 		return runtime.Value{Type: runtime.VAL_BOOL, Bool: true}
 	}
 	return runtime.Value{
 		Type: runtime.VAL_BOOL,
-		Bool: iter.Index >= len(iter.Array.Elements),
+		Bool: iter.Done(),
 	}
 }
 
@@ -784,6 +788,8 @@ func arraySortNative(argCount int, args []runtime.Value) runtime.Value {
 			return "null"
 		case runtime.VAL_NUMBER:
 			return fmt.Sprintf("%g", v.Number)
+		case runtime.VAL_INT:
+			return strconv.FormatInt(v.Int, 10)
 		case runtime.VAL_OBJ:
 			if strObj, ok := v.Obj.(*runtime.ObjString); ok {
 				return strObj.Chars
@@ -894,6 +900,8 @@ func arraySortedPushNative(argCount int, args []runtime.Value) runtime.Value {
 			return "null"
 		case runtime.VAL_NUMBER:
 			return fmt.Sprintf("%g", v.Number)
+		case runtime.VAL_INT:
+			return strconv.FormatInt(v.Int, 10)
 		case runtime.VAL_OBJ:
 			if strObj, ok := v.Obj.(*runtime.ObjString); ok {
 				return strObj.Chars
@@ -915,10 +923,7 @@ func arraySortedPushNative(argCount int, args []runtime.Value) runtime.Value {
 	if !inserted {
 		array.Elements = append(array.Elements, newVal)
 	}
-	return runtime.Value{
-		Type:   runtime.VAL_NUMBER,
-		Number: float64(len(array.Elements)),
-	}
+	return runtime.IntVal(int64(len(array.Elements)))
 }
 
 func arrayLinearSearchNative(argCount int, args []runtime.Value) runtime.Value {
@@ -938,10 +943,10 @@ func arrayLinearSearchNative(argCount int, args []runtime.Value) runtime.Value {
 	searchVal := args[1]
 	for i, elem := range array.Elements {
 		if runtime.Equal(elem, searchVal) {
-			return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(i)}
+			return runtime.IntVal(int64(i))
 		}
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: -1}
+	return runtime.IntVal(-1)
 }
 
 func arrayBinarySearchNative(argCount int, args []runtime.Value) runtime.Value {
@@ -970,6 +975,8 @@ func arrayBinarySearchNative(argCount int, args []runtime.Value) runtime.Value {
 			return "null"
 		case runtime.VAL_NUMBER:
 			return fmt.Sprintf("%g", v.Number)
+		case runtime.VAL_INT:
+			return strconv.FormatInt(v.Int, 10)
 		case runtime.VAL_OBJ:
 			if strObj, ok := v.Obj.(*runtime.ObjString); ok {
 				return strObj.Chars
@@ -986,14 +993,14 @@ func arrayBinarySearchNative(argCount int, args []runtime.Value) runtime.Value {
 		mid := (low + high) / 2
 		midStr := valueToString(array.Elements[mid])
 		if midStr == searchStr {
-			return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(mid)}
+			return runtime.IntVal(int64(mid))
 		} else if midStr < searchStr {
 			low = mid + 1
 		} else {
 			high = mid - 1
 		}
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: -1}
+	return runtime.IntVal(-1)
 }
 
 func arrayIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
@@ -1013,10 +1020,10 @@ func arrayIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
 	element := args[1]
 	for i, elem := range array.Elements {
 		if runtime.Equal(elem, element) {
-			return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(i)}
+			return runtime.IntVal(int64(i))
 		}
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: -1}
+	return runtime.IntVal(-1)
 }
 
 func arrayLastIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
@@ -1036,10 +1043,10 @@ func arrayLastIndexOfNative(argCount int, args []runtime.Value) runtime.Value {
 	element := args[1]
 	for i := len(array.Elements) - 1; i >= 0; i-- {
 		if runtime.Equal(array.Elements[i], element) {
-			return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(i)}
+			return runtime.IntVal(int64(i))
 		}
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: -1}
+	return runtime.IntVal(-1)
 }
 
 func arrayContainsNative(argCount int, args []runtime.Value) runtime.Value {
@@ -1172,16 +1179,12 @@ func mapRemoveNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'map_remove' can only be used on maps.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_OBJ {
-		runtimeError("Map key must be a string.")
+	key := args[1]
+	if !runtime.IsHashable(key) {
+		mapKeyError(key)
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	key, ok := args[1].Obj.(*runtime.ObjString)
-	if !ok {
-		runtimeError("Map key must be a string.")
-		return runtime.Value{Type: runtime.VAL_NULL}
-	}
-	delete(mapObj.Entries, key)
+	mapObj.Delete(key)
 	return runtime.Value{Type: runtime.VAL_NULL}
 }
 
@@ -1199,17 +1202,12 @@ func mapContainsKeyNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'map_contains_key' can only be used on maps.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_OBJ {
-		runtimeError("Map key must be a string.")
-		return runtime.Value{Type: runtime.VAL_NULL}
-	}
-	key, ok := args[1].Obj.(*runtime.ObjString)
-	if !ok {
-		runtimeError("Map key must be a string.")
+	key := args[1]
+	if !runtime.IsHashable(key) {
+		mapKeyError(key)
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	_, exists := mapObj.Entries[key]
-	return runtime.Value{Type: runtime.VAL_BOOL, Bool: exists}
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: mapObj.Has(key)}
 }
 
 func mapContainsValueNative(argCount int, args []runtime.Value) runtime.Value {
@@ -1227,7 +1225,7 @@ func mapContainsValueNative(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	searchVal := args[1]
-	for _, val := range mapObj.Entries {
+	for _, val := range mapObj.All() {
 		if runtime.Equal(val, searchVal) {
 			return runtime.Value{Type: runtime.VAL_BOOL, Bool: true}
 		}
@@ -1249,10 +1247,7 @@ func mapSizeNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'map_size' can only be used on maps.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	return runtime.Value{
-		Type:   runtime.VAL_NUMBER,
-		Number: float64(len(mapObj.Entries)),
-	}
+	return runtime.IntVal(int64(mapObj.Len()))
 }
 
 func mapClearNative(argCount int, args []runtime.Value) runtime.Value {
@@ -1269,7 +1264,7 @@ func mapClearNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'map_clear' can only be used on maps.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	mapObj.Entries = make(map[*runtime.ObjString]runtime.Value)
+	mapObj.Clear()
 	return runtime.Value{Type: runtime.VAL_NULL}
 }
 
@@ -1287,9 +1282,9 @@ func mapKeysNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'map_keys' can only be used on maps.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	keys := make([]runtime.Value, 0, len(mapObj.Entries))
-	for key := range mapObj.Entries {
-		keys = append(keys, runtime.ObjVal(key))
+	keys := make([]runtime.Value, 0, mapObj.Len())
+	for key := range mapObj.All() {
+		keys = append(keys, key)
 	}
 	return runtime.ObjVal(runtime.NewArray(keys))
 }
@@ -1308,13 +1303,308 @@ func mapValuesNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'map_values' can only be used on maps.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	values := make([]runtime.Value, 0, len(mapObj.Entries))
-	for _, value := range mapObj.Entries {
+	values := make([]runtime.Value, 0, mapObj.Len())
+	for _, value := range mapObj.All() {
 		values = append(values, value)
 	}
 	return runtime.ObjVal(runtime.NewArray(values))
 }
 
+// ============================================================================
+// Native Functions: Set Operations
+// ============================================================================
+
+// setArgument returns the set passed as the first argument of the named native, reporting a
+// runtime error if it is something else.
+func setArgument(name string, args []runtime.Value) (*runtime.ObjSet, bool) {
+	set, ok := args[0].Obj.(*runtime.ObjSet)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' can only be used on sets (got %s).", name, typeName(args[0]))
+		return nil, false
+	}
+	return set, true
+}
+
+// setAddNative adds a value to a set and returns whether it was not already there.
+func setAddNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'set_add' expects 2 arguments: a set and a value.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	set, ok := setArgument("set_add", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	added := !set.Has(args[1])
+	if !set.Add(args[1]) {
+		runtimeError("Set element must be a string, number, boolean or null (got %s).", typeName(args[1]))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: added}
+}
+
+// setRemoveNative removes a value from a set and returns whether it was there.
+func setRemoveNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'set_remove' expects 2 arguments: a set and a value.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	set, ok := setArgument("set_remove", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: set.Remove(args[1])}
+}
+
+func setHasNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'set_has' expects 2 arguments: a set and a value.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	set, ok := setArgument("set_has", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: set.Has(args[1])}
+}
+
+func setSizeNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'set_size' expects 1 argument: a set.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	set, ok := setArgument("set_size", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.IntVal(int64(set.Len()))
+}
+
+// setValuesNative returns the elements of a set as an array, in insertion order.
+func setValuesNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'set_values' expects 1 argument: a set.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	set, ok := setArgument("set_values", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(runtime.NewArray(set.Values()))
+}
+
+// ============================================================================
+// Native Functions: Higher-Order Operations
+// ============================================================================
+
+// isCallable reports whether value can be passed to a native as a callback.
+func isCallable(value runtime.Value) bool {
+	if value.Type != runtime.VAL_OBJ {
+		return false
+	}
+	switch value.Obj.(type) {
+	case *runtime.ObjClosure, *runtime.ObjNative:
+		return true
+	}
+	return false
+}
+
+// arrayAndCallbackArgs checks the (array, function) arguments shared by the higher-order array
+// natives.
+func arrayAndCallbackArgs(name string, args []runtime.Value) (*runtime.ObjArray, bool) {
+	array, ok := args[0].Obj.(*runtime.ObjArray)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' expects an array as the first argument (got %s).", name, typeName(args[0]))
+		return nil, false
+	}
+	if !isCallable(args[1]) {
+		runtimeError("'%s' expects a function as the second argument (got %s).", name, typeName(args[1]))
+		return nil, false
+	}
+	return array, true
+}
+
+// mapAndCallbackArgs checks the (map, function) arguments shared by the higher-order map natives.
+func mapAndCallbackArgs(name string, args []runtime.Value) (*runtime.ObjMap, bool) {
+	mapObj, ok := args[0].Obj.(*runtime.ObjMap)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' expects a map as the first argument (got %s).", name, typeName(args[0]))
+		return nil, false
+	}
+	if !isCallable(args[1]) {
+		runtimeError("'%s' expects a function as the second argument (got %s).", name, typeName(args[1]))
+		return nil, false
+	}
+	return mapObj, true
+}
+
+// arrayMapNative returns a new array with fn(value, index) applied to every element.
+func arrayMapNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'array_map' expects 2 arguments: an array and a function.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	array, ok := arrayAndCallbackArgs("array_map", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	fn := args[1]
+	elements := append([]runtime.Value(nil), array.Elements...)
+	for i, element := range elements {
+		mapped, ok := callClosureFromNative(fn, element, runtime.IntVal(int64(i)))
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		elements[i] = mapped
+	}
+	return runtime.ObjVal(runtime.NewArray(elements))
+}
+
+// arrayFilterNative returns a new array with the elements for which fn(value, index) is truthy.
+func arrayFilterNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'array_filter' expects 2 arguments: an array and a function.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	array, ok := arrayAndCallbackArgs("array_filter", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	fn := args[1]
+	kept := make([]runtime.Value, 0, len(array.Elements))
+	for i, element := range append([]runtime.Value(nil), array.Elements...) {
+		keep, ok := callClosureFromNative(fn, element, runtime.IntVal(int64(i)))
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		if isTruth(keep) {
+			kept = append(kept, element)
+		}
+	}
+	return runtime.ObjVal(runtime.NewArray(kept))
+}
+
+// arrayReduceNative folds an array into one value with fn(accumulator, value, index). Without an
+// initial value the first element is the starting accumulator.
+func arrayReduceNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 && argCount != 3 {
+		runtimeError("'array_reduce' expects 2 or 3 arguments: an array, a function and an optional initial value.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	array, ok := arrayAndCallbackArgs("array_reduce", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	fn := args[1]
+	elements := append([]runtime.Value(nil), array.Elements...)
+	start := 0
+	var accumulator runtime.Value
+	if argCount == 3 {
+		accumulator = args[2]
+	} else {
+		if len(elements) == 0 {
+			runtimeError("'array_reduce' of an empty array needs an initial value.")
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		accumulator = elements[0]
+		start = 1
+	}
+	for i := start; i < len(elements); i++ {
+		accumulator, ok = callClosureFromNative(fn, accumulator, elements[i], runtime.IntVal(int64(i)))
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+	}
+	return accumulator
+}
+
+// arraySortByNative sorts an array in place with a comparator fn(a, b) that returns a negative
+// number when a comes first, a positive number when b comes first and 0 to keep their order.
+func arraySortByNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'array_sort_by' expects 2 arguments: an array and a comparator function.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	array, ok := arrayAndCallbackArgs("array_sort_by", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	fn := args[1]
+	elements := append([]runtime.Value(nil), array.Elements...)
+	failed := false
+	sort.SliceStable(elements, func(i, j int) bool {
+		if failed {
+			return false
+		}
+		order, ok := callClosureFromNative(fn, elements[i], elements[j])
+		if !ok {
+			failed = true
+			return false
+		}
+		if !runtime.IsNumber(order) {
+			runtimeError("'array_sort_by' comparator must return a number (got %s).", typeName(order))
+			failed = true
+			return false
+		}
+		return runtime.AsNumber(order) < 0
+	})
+	if failed {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	array.Elements = elements
+	return runtime.ObjVal(array)
+}
+
+// mapMapValuesNative returns a new map with the same keys and fn(value, key) as the values.
+func mapMapValuesNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'map_map_values' expects 2 arguments: a map and a function.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	mapObj, ok := mapAndCallbackArgs("map_map_values", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	fn := args[1]
+	result := runtime.NewMap()
+	for it := runtime.NewMapIterator(mapObj, false); !it.Done(); it.Advance() {
+		key := it.Keys[it.Index]
+		value, _ := mapObj.Get(key)
+		mapped, ok := callClosureFromNative(fn, value, key)
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		result.Set(key, mapped)
+	}
+	return runtime.ObjVal(result)
+}
+
+// mapFilterNative returns a new map with the entries for which fn(value, key) is truthy.
+func mapFilterNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'map_filter' expects 2 arguments: a map and a function.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	mapObj, ok := mapAndCallbackArgs("map_filter", args)
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	fn := args[1]
+	result := runtime.NewMap()
+	for it := runtime.NewMapIterator(mapObj, false); !it.Done(); it.Advance() {
+		key := it.Keys[it.Index]
+		value, _ := mapObj.Get(key)
+		keep, ok := callClosureFromNative(fn, value, key)
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		if isTruth(keep) {
+			result.Set(key, value)
+		}
+	}
+	return runtime.ObjVal(result)
+}
+
 // ============================================================================
 // Native Functions: Date
 // ============================================================================
@@ -1328,23 +1618,23 @@ func dateNew(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.ObjVal(runtime.NewDate(year, month, day))
 	case 1:
 		// Set year, default month to January (1), day to 1
-		if args[0].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[0]) {
 			runtimeError("Argument must be a number")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
-		year := int(args[0].Number)
+		year := int(runtime.AsNumber(args[0]))
 		return runtime.ObjVal(runtime.NewDate(year, time.January, 1))
 	case 3:
 		// Set year, month, day
 		for i := 0; i < 3; i++ {
-			if args[i].Type != runtime.VAL_NUMBER {
+			if !runtime.IsNumber(args[i]) {
 				runtimeError("Arguments must be numbers")
 				return runtime.Value{Type: runtime.VAL_NULL}
 			}
 		}
-		year := int(args[0].Number)
-		month := time.Month(args[1].Number) // Assumes month is 1-12
-		day := int(args[2].Number)
+		year := int(runtime.AsNumber(args[0]))
+		month := time.Month(runtime.AsNumber(args[1])) // Assumes month is 1-12
+		day := int(runtime.AsNumber(args[2]))
 		return runtime.ObjVal(runtime.NewDate(year, month, day))
 	default:
 		runtimeError("Date requires 0, 1, or 3 arguments")
@@ -1414,14 +1704,14 @@ func dateAddDateTime(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	for i := 1; i < 4; i++ {
-		if args[i].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[i]) {
 			runtimeError("date_add_datetime() arguments 2-4 must be numbers")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
 	}
-	years := int(args[1].Number)
-	months := int(args[2].Number)
-	days := int(args[3].Number)
+	years := int(runtime.AsNumber(args[1]))
+	months := int(runtime.AsNumber(args[2]))
+	days := int(runtime.AsNumber(args[3]))
 	newTime := dateObj.Time.AddDate(years, months, days)
 	return runtime.ObjVal(runtime.NewDate(newTime.Year(), newTime.Month(), newTime.Day()))
 }
@@ -1437,14 +1727,14 @@ func dateSubtractDateTime(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	for i := 1; i < 4; i++ {
-		if args[i].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[i]) {
 			runtimeError("date_subtract_datetime() arguments 2-4 must be numbers")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
 	}
-	years := int(args[1].Number)
-	months := int(args[2].Number)
-	days := int(args[3].Number)
+	years := int(runtime.AsNumber(args[1]))
+	months := int(runtime.AsNumber(args[2]))
+	days := int(runtime.AsNumber(args[3]))
 	newTime := dateObj.Time.AddDate(-years, -months, -days)
 	return runtime.ObjVal(runtime.NewDate(newTime.Year(), newTime.Month(), newTime.Day()))
 }
@@ -1466,11 +1756,11 @@ func dateGetDateTimeComponent(argCount int, args []runtime.Value) runtime.Value
 	}
 	switch compObj.Chars {
 	case "year":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dateObj.Time.Year())}
+		return runtime.IntVal(int64(dateObj.Time.Year()))
 	case "month":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dateObj.Time.Month())}
+		return runtime.IntVal(int64(dateObj.Time.Month()))
 	case "day":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dateObj.Time.Day())}
+		return runtime.IntVal(int64(dateObj.Time.Day()))
 	default:
 		runtimeError("Invalid component '%s' for Date (use 'year', 'month', 'day')", compObj.Chars)
 		return runtime.Value{Type: runtime.VAL_NULL}
@@ -1492,11 +1782,11 @@ func dateSetDateTimeComponent(argCount int, args []runtime.Value) runtime.Value
 		runtimeError("date_set_component() second argument must be a string")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[2].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[2]) {
 		runtimeError("date_set_component() third argument must be a number")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	value := int(args[2].Number)
+	value := int(runtime.AsNumber(args[2]))
 	switch compObj.Chars {
 	case "year":
 		dateObj.Time = time.Date(value, dateObj.Time.Month(), dateObj.Time.Day(), 0, 0, 0, 0, dateObj.Time.Location())
@@ -1521,11 +1811,11 @@ func dateAddDays(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("date_add_days() first argument must be a Date")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[1]) {
 		runtimeError("date_add_days() second argument must be a number")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	days := int(args[1].Number)
+	days := int(runtime.AsNumber(args[1]))
 	newTime := dateObj.Time.AddDate(0, 0, days)
 	return runtime.ObjVal(runtime.NewDate(newTime.Year(), newTime.Month(), newTime.Day()))
 }
@@ -1540,11 +1830,11 @@ func dateSubtractDays(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("date_subtract_days() first argument must be a Date")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[1]) {
 		runtimeError("date_subtract_days() second argument must be a number")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	days := int(args[1].Number)
+	days := int(runtime.AsNumber(args[1]))
 	newTime := dateObj.Time.AddDate(0, 0, -days)
 	return runtime.ObjVal(runtime.NewDate(newTime.Year(), newTime.Month(), newTime.Day()))
 }
@@ -1562,23 +1852,23 @@ func timeNew(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.ObjVal(runtime.NewTime(hour, minute, second))
 	case 1:
 		// Set hour, default minute and second to 0
-		if args[0].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[0]) {
 			runtimeError("Argument must be a number")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
-		hour := int(args[0].Number)
+		hour := int(runtime.AsNumber(args[0]))
 		return runtime.ObjVal(runtime.NewTime(hour, 0, 0))
 	case 3:
 		// Set hour, minute, second
 		for i := 0; i < 3; i++ {
-			if args[i].Type != runtime.VAL_NUMBER {
+			if !runtime.IsNumber(args[i]) {
 				runtimeError("Arguments must be numbers")
 				return runtime.Value{Type: runtime.VAL_NULL}
 			}
 		}
-		hour := int(args[0].Number)
-		minute := int(args[1].Number)
-		second := int(args[2].Number)
+		hour := int(runtime.AsNumber(args[0]))
+		minute := int(runtime.AsNumber(args[1]))
+		second := int(runtime.AsNumber(args[2]))
 		return runtime.ObjVal(runtime.NewTime(hour, minute, second))
 	default:
 		runtimeError("Time requires 0, 1, or 3 arguments")
@@ -1647,14 +1937,14 @@ func timeAddTime(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	for i := 1; i < 4; i++ {
-		if args[i].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[i]) {
 			runtimeError("time_add() arguments 2-4 must be numbers")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
 	}
-	hours := int(args[1].Number)
-	minutes := int(args[2].Number)
-	seconds := int(args[3].Number)
+	hours := int(runtime.AsNumber(args[1]))
+	minutes := int(runtime.AsNumber(args[2]))
+	seconds := int(runtime.AsNumber(args[3]))
 	duration := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
 	newTime := timeObj.Time.Add(duration)
 	return runtime.ObjVal(runtime.NewTime(newTime.Hour(), newTime.Minute(), newTime.Second()))
@@ -1671,14 +1961,14 @@ func timeSubtractTime(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	for i := 1; i < 4; i++ {
-		if args[i].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[i]) {
 			runtimeError("time_subtract() arguments 2-4 must be numbers")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
 	}
-	hours := int(args[1].Number)
-	minutes := int(args[2].Number)
-	seconds := int(args[3].Number)
+	hours := int(runtime.AsNumber(args[1]))
+	minutes := int(runtime.AsNumber(args[2]))
+	seconds := int(runtime.AsNumber(args[3]))
 	duration := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
 	newTime := timeObj.Time.Add(-duration)
 	return runtime.ObjVal(runtime.NewTime(newTime.Hour(), newTime.Minute(), newTime.Second()))
@@ -1736,26 +2026,26 @@ func dateTimeNew(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.ObjVal(runtime.NewDateTime(year, month, day, hour, minute, second))
 	case 1:
 		// Set year, default rest to minimal values
-		if args[0].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[0]) {
 			runtimeError("Argument must be a number")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
-		year := int(args[0].Number)
+		year := int(runtime.AsNumber(args[0]))
 		return runtime.ObjVal(runtime.NewDateTime(year, time.January, 1, 0, 0, 0))
 	case 6:
 		// Set year, month, day, hour, minute, second
 		for i := 0; i < 6; i++ {
-			if args[i].Type != runtime.VAL_NUMBER {
+			if !runtime.IsNumber(args[i]) {
 				runtimeError("Arguments must be numbers")
 				return runtime.Value{Type: runtime.VAL_NULL}
 			}
 		}
-		year := int(args[0].Number)
-		month := time.Month(args[1].Number) // Assumes month is 1-12
-		day := int(args[2].Number)
-		hour := int(args[3].Number)
-		minute := int(args[4].Number)
-		second := int(args[5].Number)
+		year := int(runtime.AsNumber(args[0]))
+		month := time.Month(runtime.AsNumber(args[1])) // Assumes month is 1-12
+		day := int(runtime.AsNumber(args[2]))
+		hour := int(runtime.AsNumber(args[3]))
+		minute := int(runtime.AsNumber(args[4]))
+		second := int(runtime.AsNumber(args[5]))
 		return runtime.ObjVal(runtime.NewDateTime(year, month, day, hour, minute, second))
 	default:
 		runtimeError("DateTime requires 0, 1, or 6 arguments")
@@ -1824,17 +2114,17 @@ func dateTimeAddDateTime(argCount int, args []runtime.Value) runtime.Value {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	for i := 1; i < 7; i++ {
-		if args[i].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[i]) {
 			runtimeError("datetime_add() arguments 2-7 must be numbers")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
 	}
-	years := int(args[1].Number)
-	months := int(args[2].Number)
-	days := int(args[3].Number)
-	hours := int(args[4].Number)
-	minutes := int(args[5].Number)
-	seconds := int(args[6].Number)
+	years := int(runtime.AsNumber(args[1]))
+	months := int(runtime.AsNumber(args[2]))
+	days := int(runtime.AsNumber(args[3]))
+	hours := int(runtime.AsNumber(args[4]))
+	minutes := int(runtime.AsNumber(args[5]))
+	seconds := int(runtime.AsNumber(args[6]))
 	newTime := dtObj.Time.AddDate(years, months, days).Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
 	return runtime.ObjVal(runtime.NewDateTime(newTime.Year(), newTime.Month(), newTime.Day(), newTime.Hour(), newTime.Minute(), newTime.Second()))
 }
@@ -1850,17 +2140,17 @@ func dateTimeSubtractDateTime(argCount int, args []runtime.Value) runtime.Value
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	for i := 1; i < 7; i++ {
-		if args[i].Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(args[i]) {
 			runtimeError("datetime_subtract() arguments 2-7 must be numbers")
 			return runtime.Value{Type: runtime.VAL_NULL}
 		}
 	}
-	years := int(args[1].Number)
-	months := int(args[2].Number)
-	days := int(args[3].Number)
-	hours := int(args[4].Number)
-	minutes := int(args[5].Number)
-	seconds := int(args[6].Number)
+	years := int(runtime.AsNumber(args[1]))
+	months := int(runtime.AsNumber(args[2]))
+	days := int(runtime.AsNumber(args[3]))
+	hours := int(runtime.AsNumber(args[4]))
+	minutes := int(runtime.AsNumber(args[5]))
+	seconds := int(runtime.AsNumber(args[6]))
 	newTime := dtObj.Time.AddDate(-years, -months, -days).Add(-time.Duration(hours)*time.Hour - time.Duration(minutes)*time.Minute - time.Duration(seconds)*time.Second)
 	return runtime.ObjVal(runtime.NewDateTime(newTime.Year(), newTime.Month(), newTime.Day(), newTime.Hour(), newTime.Minute(), newTime.Second()))
 }
@@ -1882,17 +2172,17 @@ func dateTimeGetDateTimeComponent(argCount int, args []runtime.Value) runtime.Va
 	}
 	switch compObj.Chars {
 	case "year":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dtObj.Time.Year())}
+		return runtime.IntVal(int64(dtObj.Time.Year()))
 	case "month":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dtObj.Time.Month())}
+		return runtime.IntVal(int64(dtObj.Time.Month()))
 	case "day":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dtObj.Time.Day())}
+		return runtime.IntVal(int64(dtObj.Time.Day()))
 	case "hour":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dtObj.Time.Hour())}
+		return runtime.IntVal(int64(dtObj.Time.Hour()))
 	case "minute":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dtObj.Time.Minute())}
+		return runtime.IntVal(int64(dtObj.Time.Minute()))
 	case "second":
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(dtObj.Time.Second())}
+		return runtime.IntVal(int64(dtObj.Time.Second()))
 	default:
 		runtimeError("Invalid component '%s' for DateTime (use 'year', 'month', 'day', 'hour', 'minute', 'second')", compObj.Chars)
 		return runtime.Value{Type: runtime.VAL_NULL}
@@ -1914,11 +2204,11 @@ func dateTimeSetDateTimeComponent(argCount int, args []runtime.Value) runtime.Va
 		runtimeError("datetime_set_component() second argument must be a string")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[2].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[2]) {
 		runtimeError("datetime_set_component() third argument must be a number")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	value := int(args[2].Number)
+	value := int(runtime.AsNumber(args[2]))
 	switch compObj.Chars {
 	case "year":
 		dtObj.Time = time.Date(value, dtObj.Time.Month(), dtObj.Time.Day(), dtObj.Time.Hour(), dtObj.Time.Minute(), dtObj.Time.Second(), dtObj.Time.Nanosecond(), dtObj.Time.Location())
@@ -1949,11 +2239,11 @@ func dateTimeAddDays(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("datetime_add_days() first argument must be a DateTime")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[1]) {
 		runtimeError("datetime_add_days() second argument must be a number")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	days := int(args[1].Number)
+	days := int(runtime.AsNumber(args[1]))
 	newTime := dtObj.Time.AddDate(0, 0, days)
 	return runtime.ObjVal(runtime.NewDateTime(newTime.Year(), newTime.Month(), newTime.Day(), newTime.Hour(), newTime.Minute(), newTime.Second()))
 }
@@ -1968,11 +2258,11 @@ func dateTimeSubtractDays(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("datetime_subtract_days() first argument must be a DateTime")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[1].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[1]) {
 		runtimeError("datetime_subtract_days() second argument must be a number")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	days := int(args[1].Number)
+	days := int(runtime.AsNumber(args[1]))
 	newTime := dtObj.Time.AddDate(0, 0, -days)
 	return runtime.ObjVal(runtime.NewDateTime(newTime.Year(), newTime.Month(), newTime.Day(), newTime.Hour(), newTime.Minute(), newTime.Second()))
 }
@@ -1995,9 +2285,14 @@ func shuffleNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'shuffle' can only be used on arrays.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	rand.Shuffle(len(array.Elements), func(i, j int) {
+	// Fisher-Yates over crypto/rand, so a shuffled deck or draw order cannot be predicted.
+	for i := len(array.Elements) - 1; i > 0; i-- {
+		j, ok := secureIntn("shuffle", i+1)
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
 		array.Elements[i], array.Elements[j] = array.Elements[j], array.Elements[i]
-	})
+	}
 	return args[0]
 }
 
@@ -2006,12 +2301,12 @@ func randomBetweenNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'random_between' expects 2 arguments (min, max).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[0].Type != runtime.VAL_NUMBER || args[1].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[0]) || !runtime.IsNumber(args[1]) {
 		runtimeError("'random_between' expects two numbers.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	min := args[0].Number
-	max := args[1].Number
+	min := runtime.AsNumber(args[0])
+	max := runtime.AsNumber(args[1])
 	if min > max {
 		runtimeError("min must be less than or equal to max.")
 		return runtime.Value{Type: runtime.VAL_NULL}
@@ -2020,7 +2315,7 @@ func randomBetweenNative(argCount int, args []runtime.Value) runtime.Value {
 		minInt := int(min)
 		maxInt := int(max)
 		randomInt := rand.Intn(maxInt-minInt+1) + minInt
-		return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(randomInt)}
+		return runtime.IntVal(int64(randomInt))
 	}
 	randomFloat := min + rand.Float64()*(max-min)
 	return runtime.Value{Type: runtime.VAL_NUMBER, Number: randomFloat}
@@ -2031,19 +2326,23 @@ func randomStringNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'random_string' expects 1 argument (size).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	if args[0].Type != runtime.VAL_NUMBER {
+	if !runtime.IsNumber(args[0]) {
 		runtimeError("'random_string' expects a number (size).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	size := int(args[0].Number)
+	size := int(runtime.AsNumber(args[0]))
 	if size < 0 {
 		runtimeError("Size must be non-negative.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()_+-=[]{}|;:,.<>?"
 	var sb strings.Builder
+	// crypto/rand rather than math/rand, since random strings are used as tokens and passwords.
 	for i := 0; i < size; i++ {
-		index := rand.Intn(len(charset))
+		index, ok := secureIntn("random_string", len(charset))
+		if !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
 		sb.WriteByte(charset[index])
 	}
 	return runtime.ObjVal(runtime.NewObjString(sb.String()))
@@ -2092,65 +2391,22 @@ func printlnNative(argCount int, args []runtime.Value) runtime.Value {
 	return runtime.Value{Type: runtime.VAL_NULL}
 }
 
+// printfNative prints its arguments as laid out by a format string (see formatValues).
 func printfNative(argCount int, args []runtime.Value) runtime.Value {
 	if argCount < 1 {
 		runtimeError("'printf' expects at least 1 argument (format string).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	formatVal := args[0]
-	if formatVal.Type != runtime.VAL_OBJ {
+	formatObj, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
 		runtimeError("'printf' first argument must be a string (format).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	formatObj, ok := formatVal.Obj.(*runtime.ObjString)
+	text, ok := formatValues("printf", unescapeString(formatObj.Chars), args[1:argCount])
 	if !ok {
-		runtimeError("'printf' first argument must be a string (format).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	format := unescapeString(formatObj.Chars) // Unescape the format string
-	var printArgs []interface{}
-	for _, arg := range args[1:] {
-		switch arg.Type {
-		case runtime.VAL_BOOL:
-			printArgs = append(printArgs, arg.Bool)
-		case runtime.VAL_NUMBER:
-			if math.Mod(arg.Number, 1) == 0 {
-				printArgs = append(printArgs, int(arg.Number))
-			} else {
-				printArgs = append(printArgs, arg.Number)
-			}
-		case runtime.VAL_OBJ:
-			switch obj := arg.Obj.(type) {
-			case *runtime.ObjString:
-				printArgs = append(printArgs, unescapeString(obj.Chars)) // Unescape string arguments
-			case *runtime.ObjArray:
-				strVal := arrayToStringNative(1, []runtime.Value{arg})
-				if strObj, ok := strVal.Obj.(*runtime.ObjString); ok {
-					printArgs = append(printArgs, unescapeString(strObj.Chars))
-				} else {
-					printArgs = append(printArgs, "unknown array")
-				}
-			default:
-				strVal := toStr(1, []runtime.Value{arg})
-				if strObj, ok := strVal.Obj.(*runtime.ObjString); ok {
-					printArgs = append(printArgs, unescapeString(strObj.Chars))
-				} else {
-					printArgs = append(printArgs, "unknown")
-				}
-			}
-		case runtime.VAL_NULL:
-			printArgs = append(printArgs, "null")
-		default:
-			printArgs = append(printArgs, "unknown")
-		}
-	}
-
-	// Replace all format specifiers with %v
-	adjustedFormat := strings.ReplaceAll(format, "%s", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%d", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%f", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%g", "%v")
-	fmt.Printf(adjustedFormat, printArgs...)
+	fmt.Print(text)
 	return runtime.Value{Type: runtime.VAL_NULL}
 }
 
@@ -2221,128 +2477,40 @@ func scanfNative(argCount int, args []runtime.Value) runtime.Value {
 // Native Functions: Format Operations
 // ============================================================================
 
+// sprintfNative returns its arguments as laid out by a format string (see formatValues).
 func sprintfNative(argCount int, args []runtime.Value) runtime.Value {
 	if argCount < 1 {
 		runtimeError("'sprintf' expects at least 1 argument (format string).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	formatVal := args[0]
-	if formatVal.Type != runtime.VAL_OBJ {
+	formatObj, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
 		runtimeError("'sprintf' first argument must be a string (format).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	formatObj, ok := formatVal.Obj.(*runtime.ObjString)
+	text, ok := formatValues("sprintf", unescapeString(formatObj.Chars), args[1:argCount])
 	if !ok {
-		runtimeError("'sprintf' first argument must be a string (format).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	format := unescapeString(formatObj.Chars) // Unescape the format string
-	var printArgs []interface{}
-	for _, arg := range args[1:] {
-		switch arg.Type {
-		case runtime.VAL_BOOL:
-			printArgs = append(printArgs, arg.Bool)
-		case runtime.VAL_NUMBER:
-			if math.Mod(arg.Number, 1) == 0 {
-				printArgs = append(printArgs, int(arg.Number))
-			} else {
-				printArgs = append(printArgs, arg.Number)
-			}
-		case runtime.VAL_OBJ:
-			switch obj := arg.Obj.(type) {
-			case *runtime.ObjString:
-				printArgs = append(printArgs, unescapeString(obj.Chars)) // Unescape string arguments
-			case *runtime.ObjArray:
-				strVal := arrayToStringNative(1, []runtime.Value{arg})
-				if strObj, ok := strVal.Obj.(*runtime.ObjString); ok {
-					printArgs = append(printArgs, unescapeString(strObj.Chars))
-				} else {
-					printArgs = append(printArgs, "unknown array")
-				}
-			default:
-				strVal := toStr(1, []runtime.Value{arg})
-				if strObj, ok := strVal.Obj.(*runtime.ObjString); ok {
-					printArgs = append(printArgs, unescapeString(strObj.Chars))
-				} else {
-					printArgs = append(printArgs, "unknown")
-				}
-			}
-		case runtime.VAL_NULL:
-			printArgs = append(printArgs, "null")
-		default:
-			printArgs = append(printArgs, "unknown")
-		}
-	}
-
-	// Replace all format specifiers with %v
-	adjustedFormat := strings.ReplaceAll(format, "%s", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%d", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%f", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%g", "%v")
-	formatted := fmt.Sprintf(adjustedFormat, printArgs...)
-	return runtime.ObjVal(runtime.NewObjString(formatted))
+	return runtime.ObjVal(runtime.NewObjString(text))
 }
 
+// errorfNative builds an error message from a format string, like sprintf.
 func errorfNative(argCount int, args []runtime.Value) runtime.Value {
 	if argCount < 1 {
 		runtimeError("'errorf' expects at least 1 argument (format string).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	formatVal := args[0]
-	if formatVal.Type != runtime.VAL_OBJ {
+	formatObj, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
 		runtimeError("'errorf' first argument must be a string (format).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	formatObj, ok := formatVal.Obj.(*runtime.ObjString)
+	text, ok := formatValues("errorf", unescapeString(formatObj.Chars), args[1:argCount])
 	if !ok {
-		runtimeError("'errorf' first argument must be a string (format).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	format := unescapeString(formatObj.Chars) // Unescape the format string
-	var printArgs []interface{}
-	for _, arg := range args[1:] {
-		switch arg.Type {
-		case runtime.VAL_BOOL:
-			printArgs = append(printArgs, arg.Bool)
-		case runtime.VAL_NUMBER:
-			if math.Mod(arg.Number, 1) == 0 {
-				printArgs = append(printArgs, int(arg.Number))
-			} else {
-				printArgs = append(printArgs, arg.Number)
-			}
-		case runtime.VAL_OBJ:
-			switch obj := arg.Obj.(type) {
-			case *runtime.ObjString:
-				printArgs = append(printArgs, unescapeString(obj.Chars)) // Unescape string arguments
-			case *runtime.ObjArray:
-				strVal := arrayToStringNative(1, []runtime.Value{arg})
-				if strObj, ok := strVal.Obj.(*runtime.ObjString); ok {
-					printArgs = append(printArgs, unescapeString(strObj.Chars))
-				} else {
-					printArgs = append(printArgs, "unknown array")
-				}
-			default:
-				strVal := toStr(1, []runtime.Value{arg})
-				if strObj, ok := strVal.Obj.(*runtime.ObjString); ok {
-					printArgs = append(printArgs, unescapeString(strObj.Chars))
-				} else {
-					printArgs = append(printArgs, "unknown")
-				}
-			}
-		case runtime.VAL_NULL:
-			printArgs = append(printArgs, "null")
-		default:
-			printArgs = append(printArgs, "unknown")
-		}
-	}
-
-	// Replace all format specifiers with %v
-	adjustedFormat := strings.ReplaceAll(format, "%s", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%d", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%f", "%v")
-	adjustedFormat = strings.ReplaceAll(adjustedFormat, "%g", "%v")
-	errMsg := fmt.Sprintf(adjustedFormat, printArgs...)
-	return runtime.ObjVal(runtime.NewObjString(errMsg))
+	return runtime.ObjVal(runtime.NewObjString(text))
 }
 
 // ============================================================================
@@ -2408,13 +2576,165 @@ func writeFileNative(argCount int, args []runtime.Value) runtime.Value {
 	return runtime.Value{Type: runtime.VAL_NULL}
 }
 
+// ============================================================================
+// Native Functions: BigInt and Decimal
+// ============================================================================
+
+// bigintNative converts an int, integral float, decimal (truncated) or numeric string
+// (decimal or 0x/0o/0b prefixed) to a BigInt.
+func bigintNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'bigint' expects 1 argument (value).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	arg := args[0]
+	if n, ok := runtime.AsBigInt(arg); ok {
+		return runtime.ObjVal(runtime.NewBigInt(new(big.Int).Set(n)))
+	}
+	switch {
+	case arg.Type == runtime.VAL_NUMBER:
+		if math.IsNaN(arg.Number) || math.IsInf(arg.Number, 0) || arg.Number != math.Trunc(arg.Number) {
+			runtimeError("'bigint' cannot convert %g; only whole numbers are allowed.", arg.Number)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		n, _ := new(big.Float).SetFloat64(arg.Number).Int(nil)
+		return runtime.ObjVal(runtime.NewBigInt(n))
+	case arg.Type == runtime.VAL_OBJ:
+		switch obj := arg.Obj.(type) {
+		case *runtime.ObjDecimal:
+			return runtime.ObjVal(runtime.NewBigInt(obj.Rescale(0, runtime.ROUND_DOWN).Unscaled))
+		case *runtime.ObjString:
+			n, ok := new(big.Int).SetString(strings.ReplaceAll(strings.TrimSpace(obj.Chars), "_", ""), 0)
+			if !ok {
+				runtimeError("'bigint' cannot parse '%s' as an integer.", obj.Chars)
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return runtime.ObjVal(runtime.NewBigInt(n))
+		}
+	}
+	runtimeError("'bigint' cannot convert %s to a BigInt.", typeName(arg))
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// decimalNative converts a string, int, BigInt or float to a Decimal. Strings keep the
+// number of fractional digits written ("20.00" stays 20.00); floats use their shortest
+// decimal representation, so decimal(0.1) is exactly 0.1.
+func decimalNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'decimal' expects 1 argument (value).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	arg := args[0]
+	if d, ok := runtime.AsDecimal(arg); ok {
+		return runtime.ObjVal(d)
+	}
+	text := ""
+	switch {
+	case arg.Type == runtime.VAL_NUMBER:
+		if math.IsNaN(arg.Number) || math.IsInf(arg.Number, 0) {
+			runtimeError("'decimal' cannot convert %g.", arg.Number)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		text = strconv.FormatFloat(arg.Number, 'f', -1, 64)
+	case arg.Type == runtime.VAL_OBJ:
+		strObj, ok := arg.Obj.(*runtime.ObjString)
+		if !ok {
+			runtimeError("'decimal' cannot convert %s to a decimal.", typeName(arg))
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		text = strObj.Chars
+	default:
+		runtimeError("'decimal' cannot convert %s to a decimal.", typeName(arg))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	d, err := runtime.ParseDecimal(text)
+	if err != nil {
+		runtimeError("'decimal' cannot parse '%s' as a decimal.", text)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(d)
+}
+
+// decimalRoundNative rounds a decimal to the given number of fractional digits, using the
+// optional rounding mode or the one set by decimal_set_rounding.
+func decimalRoundNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 && argCount != 3 {
+		runtimeError("'decimal_round' expects 2 or 3 arguments (decimal, places, [mode]).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	d, ok := runtime.AsDecimal(args[0])
+	if !ok {
+		runtimeError("'decimal_round' expects a decimal as the first argument (got %s).", typeName(args[0]))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	places, ok := runtime.AsInt(args[1])
+	if !ok || places < 0 || places > math.MaxInt16 {
+		runtimeError("'decimal_round' expects a non-negative integer number of places.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	mode := vm.decimalRounding
+	if argCount == 3 {
+		if mode, ok = roundingModeArg("decimal_round", args[2]); !ok {
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+	}
+	return runtime.ObjVal(d.Rescale(int32(places), mode))
+}
+
+// decimalSetScaleNative sets how many fractional digits decimal division keeps and returns
+// the previous setting.
+func decimalSetScaleNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'decimal_set_scale' expects 1 argument (places).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	places, ok := runtime.AsInt(args[0])
+	if !ok || places < 0 || places > math.MaxInt16 {
+		runtimeError("'decimal_set_scale' expects a non-negative integer number of places.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	previous := vm.decimalScale
+	vm.decimalScale = int32(places)
+	return runtime.IntVal(int64(previous))
+}
+
+// decimalSetRoundingNative sets the default rounding mode for decimals and returns the
+// previous mode's name.
+func decimalSetRoundingNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'decimal_set_rounding' expects 1 argument (mode).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	mode, ok := roundingModeArg("decimal_set_rounding", args[0])
+	if !ok {
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	previous := vm.decimalRounding
+	vm.decimalRounding = mode
+	return runtime.ObjVal(runtime.NewObjString(previous.String()))
+}
+
+// roundingModeArg validates a rounding mode name argument.
+func roundingModeArg(native string, arg runtime.Value) (runtime.RoundingMode, bool) {
+	if strObj, ok := arg.Obj.(*runtime.ObjString); ok && arg.Type == runtime.VAL_OBJ {
+		if mode, ok := runtime.ParseRoundingMode(strObj.Chars); ok {
+			return mode, true
+		}
+	}
+	runtimeError("'%s' expects a rounding mode (%s).", native, runtime.RoundingModeNames())
+	return runtime.ROUND_HALF_EVEN, false
+}
+
 // ============================================================================
 // Native Functions: Utility Operations
 // ============================================================================
 
+// parseIntNative parses a string as a 64-bit integer. An optional second argument selects
+// the base (2 to 36); base 0 infers it from a 0x, 0o or 0b prefix. Returns null when the
+// string is not a valid integer.
 func parseIntNative(argCount int, args []runtime.Value) runtime.Value {
-	if argCount != 1 {
-		runtimeError("'parse_int' expects 1 argument (string).")
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'parse_int' expects 1 or 2 arguments (string, [base]).")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
 	if args[0].Type != runtime.VAL_OBJ {
@@ -2426,11 +2746,94 @@ func parseIntNative(argCount int, args []runtime.Value) runtime.Value {
 		runtimeError("'parse_int' expects a string.")
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	num, err := strconv.Atoi(strObj.Chars)
+	base := int64(10)
+	if argCount == 2 {
+		b, ok := runtime.AsInt(args[1])
+		if !ok || b == 1 || b < 0 || b > 36 {
+			runtimeError("'parse_int' base must be 0 or an integer between 2 and 36.")
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		base = b
+	}
+	num, err := strconv.ParseInt(strings.TrimSpace(strObj.Chars), int(base), 64)
 	if err != nil {
 		return runtime.Value{Type: runtime.VAL_NULL}
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(num)}
+	return runtime.IntVal(num)
+}
+
+// toIntNative converts a number, boolean or numeric string to an integer. Floats are
+// truncated toward zero.
+func toIntNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'to_int' expects 1 argument (value).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	switch arg := args[0]; arg.Type {
+	case runtime.VAL_INT:
+		return arg
+	case runtime.VAL_NUMBER:
+		if math.IsNaN(arg.Number) || math.IsInf(arg.Number, 0) || arg.Number < math.MinInt64 || arg.Number >= math.MaxInt64 {
+			runtimeError("'to_int' cannot convert %g to a 64-bit integer.", arg.Number)
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		return runtime.IntVal(int64(arg.Number))
+	case runtime.VAL_BOOL:
+		if arg.Bool {
+			return runtime.IntVal(1)
+		}
+		return runtime.IntVal(0)
+	case runtime.VAL_OBJ:
+		if strObj, ok := arg.Obj.(*runtime.ObjString); ok {
+			text := strings.TrimSpace(strObj.Chars)
+			if num, err := strconv.ParseInt(text, 0, 64); err == nil {
+				return runtime.IntVal(num)
+			}
+			if f, err := strconv.ParseFloat(text, 64); err == nil {
+				return toIntNative(1, []runtime.Value{{Type: runtime.VAL_NUMBER, Number: f}})
+			}
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		if n, ok := runtime.AsBigInt(arg); ok {
+			if !n.IsInt64() {
+				runtimeError("'to_int' cannot convert %s to a 64-bit integer.", n.String())
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return runtime.IntVal(n.Int64())
+		}
+	}
+	runtimeError("'to_int' cannot convert %s to an integer.", typeName(args[0]))
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// toFloatNative converts a number or numeric string to a float.
+func toFloatNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'to_float' expects 1 argument (value).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	arg := args[0]
+	if runtime.IsNumber(arg) {
+		return runtime.Value{Type: runtime.VAL_NUMBER, Number: runtime.AsNumber(arg)}
+	}
+	if strObj, ok := arg.Obj.(*runtime.ObjString); ok && arg.Type == runtime.VAL_OBJ {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(strObj.Chars), 64); err == nil {
+			return runtime.Value{Type: runtime.VAL_NUMBER, Number: f}
+		}
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	runtimeError("'to_float' cannot convert %s to a float.", typeName(arg))
+	return runtime.Value{Type: runtime.VAL_NULL}
+}
+
+// equalsNative compares two values by content: arrays, maps, sets, instances, dates and times
+// match when what they hold matches, unlike '==' which compares them by identity.
+func equalsNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 2 {
+		runtimeError("'equals' expects 2 arguments (a, b).")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: runtime.DeepEqual(args[0], args[1])}
 }
 
 // ============================================================================
@@ -2446,6 +2849,29 @@ func getRunTypeNative(argCount int, args []runtime.Value) runtime.Value {
 	return runtime.Value{Type: runtime.VAL_OBJ, Obj: runtime.NewObjString(typeName(args[0]))}
 }
 
+// isIntNative reports whether a value is an integer, an int or a bigint, rather than a float.
+// get_runtype calls both ints and floats "number".
+func isIntNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'is_int' expects 1 argument.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	_, isBig := args[0].Obj.(*runtime.ObjBigInt)
+	isInt := args[0].Type == runtime.VAL_INT || args[0].Type == runtime.VAL_OBJ && isBig
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: isInt}
+}
+
+// isErrorNative reports whether a value is an error value, such as the ones the file system
+// natives return when an operation fails.
+func isErrorNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 {
+		runtimeError("'is_error' expects 1 argument.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	_, isError := args[0].Obj.(*runtime.ObjError)
+	return runtime.Value{Type: runtime.VAL_BOOL, Bool: args[0].Type == runtime.VAL_OBJ && isError}
+}
+
 // ============================================================================
 // Native Functions: Others Operations
 // ============================================================================
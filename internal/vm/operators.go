@@ -2,14 +2,33 @@ package vm
 
 import (
 	"math"
+	"math/big"
 	"strings"
 
 	"github.com/cryptrunner49/tulipscript/internal/runtime"
 )
 
+// bothInts reports whether both operands are integers, in which case arithmetic is exact: a
+// result that does not fit in 64 bits promotes to a BigInt. Any float operand promotes to float.
+func bothInts(a, b runtime.Value) bool {
+	return a.Type == runtime.VAL_INT && b.Type == runtime.VAL_INT
+}
+
+// bigIntVal returns n as a BigInt value.
+func bigIntVal(n *big.Int) runtime.Value {
+	return runtime.ObjVal(runtime.NewBigInt(n))
+}
+
 // Helper function for numeric addition
 func addNumbers(a, b runtime.Value) runtime.Value {
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: a.Number + b.Number}
+	if bothInts(a, b) {
+		sum := a.Int + b.Int
+		if (a.Int^sum)&(b.Int^sum) < 0 {
+			return bigIntVal(new(big.Int).Add(big.NewInt(a.Int), big.NewInt(b.Int)))
+		}
+		return runtime.IntVal(sum)
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: runtime.AsNumber(a) + runtime.AsNumber(b)}
 }
 
 // Helper function for string concatenation
@@ -35,7 +54,7 @@ func addArrays(arr1, arr2 *runtime.ObjArray) runtime.Value {
 	for i := 0; i < minLen; i++ {
 		v1 := arr1.Elements[i]
 		v2 := arr2.Elements[i]
-		if v1.Type == runtime.VAL_NUMBER && v2.Type == runtime.VAL_NUMBER {
+		if runtime.IsNumber(v1) && runtime.IsNumber(v2) {
 			result[i] = addNumbers(v1, v2)
 		} else {
 			result[i] = addStrings(v1, v2)
@@ -56,11 +75,61 @@ func addArrays(arr1, arr2 *runtime.ObjArray) runtime.Value {
 // Helper function for map addition
 func addMaps(map1, map2 *runtime.ObjMap) runtime.Value {
 	result := runtime.NewMap()
-	for key, value := range map1.Entries {
-		result.Entries[key] = value
+	for key, value := range map1.All() {
+		result.Set(key, value)
 	}
-	for key, value := range map2.Entries {
-		result.Entries[key] = value
+	for key, value := range map2.All() {
+		result.Set(key, value)
+	}
+	return runtime.ObjVal(result)
+}
+
+// Helper function for set union ('+'): the elements of set1 followed by the new ones from set2.
+func unionSets(set1, set2 *runtime.ObjSet) runtime.Value {
+	result := runtime.NewSet()
+	for value := range set1.All() {
+		result.Add(value)
+	}
+	for value := range set2.All() {
+		result.Add(value)
+	}
+	return runtime.ObjVal(result)
+}
+
+// Helper function for set difference ('-'): the elements of set1 that are not in set2.
+func differenceSets(set1, set2 *runtime.ObjSet) runtime.Value {
+	result := runtime.NewSet()
+	for value := range set1.All() {
+		if !set2.Has(value) {
+			result.Add(value)
+		}
+	}
+	return runtime.ObjVal(result)
+}
+
+// Helper function for set intersection ('*'): the elements of set1 that are also in set2.
+func intersectSets(set1, set2 *runtime.ObjSet) runtime.Value {
+	result := runtime.NewSet()
+	for value := range set1.All() {
+		if set2.Has(value) {
+			result.Add(value)
+		}
+	}
+	return runtime.ObjVal(result)
+}
+
+// Helper function for symmetric set difference ('^'): the elements in exactly one of the sets.
+func symmetricDifferenceSets(set1, set2 *runtime.ObjSet) runtime.Value {
+	result := runtime.NewSet()
+	for value := range set1.All() {
+		if !set2.Has(value) {
+			result.Add(value)
+		}
+	}
+	for value := range set2.All() {
+		if !set1.Has(value) {
+			result.Add(value)
+		}
 	}
 	return runtime.ObjVal(result)
 }
@@ -80,8 +149,8 @@ func addInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, InterpretRe
 		}
 		var fieldResult runtime.Value
 		switch val1.Type {
-		case runtime.VAL_NUMBER:
-			if val2.Type == runtime.VAL_NUMBER {
+		case runtime.VAL_NUMBER, runtime.VAL_INT:
+			if runtime.IsNumber(val2) {
 				fieldResult = addNumbers(val1, val2)
 			} else {
 				return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Incompatible field types for addition in struct: expected number, got %s.", typeName(val2))
@@ -132,7 +201,14 @@ func addInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, InterpretRe
 
 // Helper function for numeric subtraction
 func subtractNumbers(a, b runtime.Value) runtime.Value {
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: a.Number - b.Number}
+	if bothInts(a, b) {
+		difference := a.Int - b.Int
+		if (a.Int^b.Int)&(a.Int^difference) < 0 {
+			return bigIntVal(new(big.Int).Sub(big.NewInt(a.Int), big.NewInt(b.Int)))
+		}
+		return runtime.IntVal(difference)
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: runtime.AsNumber(a) - runtime.AsNumber(b)}
 }
 
 // Helper function for string cropping
@@ -162,7 +238,7 @@ func subtractArrays(arr1, arr2 *runtime.ObjArray) runtime.Value {
 	for i := 0; i < minLen; i++ {
 		v1 := arr1.Elements[i]
 		v2 := arr2.Elements[i]
-		if v1.Type == runtime.VAL_NUMBER && v2.Type == runtime.VAL_NUMBER {
+		if runtime.IsNumber(v1) && runtime.IsNumber(v2) {
 			result[i] = subtractNumbers(v1, v2)
 		} else {
 			result[i] = subtractStrings(v1, v2)
@@ -183,11 +259,10 @@ func subtractArrays(arr1, arr2 *runtime.ObjArray) runtime.Value {
 // Helper function for map subtraction
 func subtractMaps(map1, map2 *runtime.ObjMap) runtime.Value {
 	result := runtime.NewMap()
-	for key, value := range map1.Entries {
-		result.Entries[key] = value
-	}
-	for key := range map2.Entries {
-		delete(result.Entries, key)
+	for key, value := range map1.All() {
+		if !map2.Has(key) {
+			result.Set(key, value)
+		}
 	}
 	return runtime.ObjVal(result)
 }
@@ -207,8 +282,8 @@ func subtractInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, Interp
 		}
 		var fieldResult runtime.Value
 		switch val1.Type {
-		case runtime.VAL_NUMBER:
-			if val2.Type == runtime.VAL_NUMBER {
+		case runtime.VAL_NUMBER, runtime.VAL_INT:
+			if runtime.IsNumber(val2) {
 				fieldResult = subtractNumbers(val1, val2)
 			} else {
 				return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Incompatible field types for subtraction in struct: expected number, got %s.", typeName(val2))
@@ -259,7 +334,13 @@ func subtractInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, Interp
 
 // Helper function for numeric multiplication
 func multiplyNumbers(a, b runtime.Value) runtime.Value {
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: a.Number * b.Number}
+	if bothInts(a, b) {
+		if product, ok := mulInt64(a.Int, b.Int); ok {
+			return runtime.IntVal(product)
+		}
+		return bigIntVal(new(big.Int).Mul(big.NewInt(a.Int), big.NewInt(b.Int)))
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: runtime.AsNumber(a) * runtime.AsNumber(b)}
 }
 
 // Helper function for array multiplication
@@ -278,7 +359,7 @@ func multiplyArrays(arr1, arr2 *runtime.ObjArray) (runtime.Value, InterpretResul
 	for i := 0; i < minLen; i++ {
 		v1 := arr1.Elements[i]
 		v2 := arr2.Elements[i]
-		if v1.Type != runtime.VAL_NUMBER || v2.Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(v1) || !runtime.IsNumber(v2) {
 			return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Array elements for '*' must be numbers (found %s and %s at index %d).", typeName(v1), typeName(v2), i)
 		}
 		result[i] = multiplyNumbers(v1, v2)
@@ -308,8 +389,8 @@ func multiplyInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, Interp
 		}
 		var fieldResult runtime.Value
 		switch val1.Type {
-		case runtime.VAL_NUMBER:
-			if val2.Type == runtime.VAL_NUMBER {
+		case runtime.VAL_NUMBER, runtime.VAL_INT:
+			if runtime.IsNumber(val2) {
 				fieldResult = multiplyNumbers(val1, val2)
 			} else {
 				fieldResult = val1
@@ -348,11 +429,11 @@ func multiplyInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, Interp
 
 // Helper function for numeric division
 func divideNumbers(a, b runtime.Value) runtime.Value {
-	if b.Number == 0 {
+	if runtime.AsNumber(b) == 0 {
 		runtimeError("Division by zero in struct field operation.")
 		return a
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: a.Number / b.Number}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: runtime.AsNumber(a) / runtime.AsNumber(b)}
 }
 
 // Helper function for array division
@@ -371,7 +452,7 @@ func divideArrays(arr1, arr2 *runtime.ObjArray) (runtime.Value, InterpretResult)
 	for i := 0; i < minLen; i++ {
 		v1 := arr1.Elements[i]
 		v2 := arr2.Elements[i]
-		if v1.Type != runtime.VAL_NUMBER || v2.Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(v1) || !runtime.IsNumber(v2) {
 			return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Array elements for '/' must be numbers (found %s and %s at index %d).", typeName(v1), typeName(v2), i)
 		}
 		result[i] = divideNumbers(v1, v2)
@@ -401,8 +482,8 @@ func divideInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, Interpre
 		}
 		var fieldResult runtime.Value
 		switch val1.Type {
-		case runtime.VAL_NUMBER:
-			if val2.Type == runtime.VAL_NUMBER {
+		case runtime.VAL_NUMBER, runtime.VAL_INT:
+			if runtime.IsNumber(val2) {
 				fieldResult = divideNumbers(val1, val2)
 			} else {
 				fieldResult = val1
@@ -441,11 +522,14 @@ func divideInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, Interpre
 
 // Helper function for numeric modulo
 func modNumbers(a, b runtime.Value) runtime.Value {
-	if b.Number == 0 {
+	if runtime.AsNumber(b) == 0 {
 		runtimeError("Modulo by zero in struct field operation.")
 		return a
 	}
-	return runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Mod(a.Number, b.Number)}
+	if bothInts(a, b) {
+		return runtime.IntVal(a.Int % b.Int)
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Mod(runtime.AsNumber(a), runtime.AsNumber(b))}
 }
 
 // Helper function for array modulo
@@ -464,7 +548,7 @@ func modArrays(arr1, arr2 *runtime.ObjArray) (runtime.Value, InterpretResult) {
 	for i := 0; i < minLen; i++ {
 		v1 := arr1.Elements[i]
 		v2 := arr2.Elements[i]
-		if v1.Type != runtime.VAL_NUMBER || v2.Type != runtime.VAL_NUMBER {
+		if !runtime.IsNumber(v1) || !runtime.IsNumber(v2) {
 			return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Array elements for '%%' must be numbers (found %s and %s at index %d).", typeName(v1), typeName(v2), i)
 		}
 		result[i] = modNumbers(v1, v2)
@@ -494,8 +578,8 @@ func modInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, InterpretRe
 		}
 		var fieldResult runtime.Value
 		switch val1.Type {
-		case runtime.VAL_NUMBER:
-			if val2.Type == runtime.VAL_NUMBER {
+		case runtime.VAL_NUMBER, runtime.VAL_INT:
+			if runtime.IsNumber(val2) {
 				fieldResult = modNumbers(val1, val2)
 			} else {
 				fieldResult = val1
@@ -531,3 +615,347 @@ func modInstances(inst1, inst2 *runtime.ObjInstance) (runtime.Value, InterpretRe
 	}
 	return runtime.ObjVal(result), INTERPRET_OK
 }
+
+// isNumeric reports whether v is an int, float, BigInt or Decimal.
+func isNumeric(v runtime.Value) bool {
+	return runtime.IsNumber(v) || runtime.IsExactNumber(v)
+}
+
+// Helper function for numeric comparison; returns -1, 0 or 1. Two integers compare exactly,
+// and so does any comparison involving a BigInt or Decimal.
+func compareNumbers(a, b runtime.Value) int {
+	if runtime.IsExactNumber(a) || runtime.IsExactNumber(b) {
+		x, okA := runtime.AsRat(a)
+		y, okB := runtime.AsRat(b)
+		if okA && okB {
+			return x.Cmp(y)
+		}
+		// One side is an infinite or NaN float; an approximate comparison is exact enough.
+		return compareNumbers(runtime.Value{Type: runtime.VAL_NUMBER, Number: exactToFloat(a)},
+			runtime.Value{Type: runtime.VAL_NUMBER, Number: exactToFloat(b)})
+	}
+	if bothInts(a, b) {
+		switch {
+		case a.Int < b.Int:
+			return -1
+		case a.Int > b.Int:
+			return 1
+		}
+		return 0
+	}
+	x, y := runtime.AsNumber(a), runtime.AsNumber(b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// Helper function for unary minus; integers stay integers, except that negating the smallest
+// int gives a BigInt.
+func negateNumber(a runtime.Value) runtime.Value {
+	if a.Type == runtime.VAL_INT {
+		if a.Int == math.MinInt64 {
+			return bigIntVal(new(big.Int).Neg(big.NewInt(a.Int)))
+		}
+		return runtime.IntVal(-a.Int)
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: -a.Number}
+}
+
+// Helper function for exponentiation. An integer raised to a non-negative integer power
+// stays an integer, promoted to a BigInt if the result does not fit in 64 bits; every other
+// combination is computed in floating point.
+func powNumbers(a, b runtime.Value) (runtime.Value, InterpretResult) {
+	if bothInts(a, b) && b.Int >= 0 {
+		result, base, exp := int64(1), a.Int, b.Int
+		for exp > 0 {
+			var ok bool
+			if exp&1 == 1 {
+				if result, ok = mulInt64(result, base); !ok {
+					return exactArithmetic(runtime.OP_EXPONENTIAL, bigIntVal(big.NewInt(a.Int)), b)
+				}
+			}
+			exp >>= 1
+			if exp > 0 {
+				if base, ok = mulInt64(base, base); !ok {
+					return exactArithmetic(runtime.OP_EXPONENTIAL, bigIntVal(big.NewInt(a.Int)), b)
+				}
+			}
+		}
+		return runtime.IntVal(result), INTERPRET_OK
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Pow(runtime.AsNumber(a), runtime.AsNumber(b))}, INTERPRET_OK
+}
+
+// mulInt64 returns x*y, or false if the product overflows an int64.
+func mulInt64(x, y int64) (int64, bool) {
+	if x == 0 || y == 0 {
+		return 0, true
+	}
+	product := x * y
+	if product/y != x || (x == -1 && y == math.MinInt64) || (y == -1 && x == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// Helper function for floor division ('/_'). Integers use exact floored division, so the
+// result rounds toward negative infinity like math.Floor does for floats. The one quotient that
+// does not fit in 64 bits, the smallest int divided by -1, is a BigInt.
+func floorDivideNumbers(a, b runtime.Value) runtime.Value {
+	if bothInts(a, b) {
+		if a.Int == math.MinInt64 && b.Int == -1 {
+			return negateNumber(a)
+		}
+		q := a.Int / b.Int
+		if (a.Int%b.Int != 0) && ((a.Int < 0) != (b.Int < 0)) {
+			q--
+		}
+		return runtime.IntVal(q)
+	}
+	return runtime.Value{Type: runtime.VAL_NUMBER, Number: math.Floor(runtime.AsNumber(a) / runtime.AsNumber(b))}
+}
+
+// operatorSymbols maps binary operator opcodes to their source operator for error messages.
+var operatorSymbols = map[runtime.OpCode]string{
+	runtime.OP_ADD:         "+",
+	runtime.OP_SUBTRACT:    "-",
+	runtime.OP_MULTIPLY:    "*",
+	runtime.OP_DIVIDE:      "/",
+	runtime.OP_MOD:         "%",
+	runtime.OP_EXPONENTIAL: "**",
+	runtime.OP_FLOOR:       "/_",
+	runtime.OP_BIT_AND:     "&",
+	runtime.OP_BIT_OR:      "|",
+	runtime.OP_BIT_XOR:     "^",
+	runtime.OP_SHIFT_LEFT:  "<<",
+	runtime.OP_SHIFT_RIGHT: ">>",
+}
+
+// Helper function for the bitwise operators. Operands must be integers; floats without a
+// fractional part are accepted and converted. Shift counts must be non-negative. Like the
+// arithmetic operators, a left shift that overflows 64 bits promotes to a BigInt, and BigInt
+// operands give BigInt results.
+func bitwiseNumbers(op runtime.OpCode, a, b runtime.Value) (runtime.Value, InterpretResult) {
+	symbol := operatorSymbols[op]
+	x, okA := runtime.AsInt(a)
+	y, okB := runtime.AsInt(b)
+	if !okA || !okB {
+		if isBitwiseInt(a) && isBitwiseInt(b) {
+			return bitwiseBigInts(op, a, b)
+		}
+		return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Operands for '%s' must be integers (got %s and %s).", symbol, typeName(a), typeName(b))
+	}
+	switch op {
+	case runtime.OP_BIT_AND:
+		return runtime.IntVal(x & y), INTERPRET_OK
+	case runtime.OP_BIT_OR:
+		return runtime.IntVal(x | y), INTERPRET_OK
+	case runtime.OP_BIT_XOR:
+		return runtime.IntVal(x ^ y), INTERPRET_OK
+	case runtime.OP_SHIFT_LEFT, runtime.OP_SHIFT_RIGHT:
+		if y < 0 {
+			return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Shift count for '%s' must not be negative (got %d).", symbol, y)
+		}
+		if op == runtime.OP_SHIFT_RIGHT {
+			return runtime.IntVal(x >> uint64(y)), INTERPRET_OK
+		}
+		if y < 64 && (x<<uint64(y))>>uint64(y) == x {
+			return runtime.IntVal(x << uint64(y)), INTERPRET_OK
+		}
+		if x == 0 {
+			return runtime.IntVal(0), INTERPRET_OK
+		}
+		return bitwiseBigInts(op, runtime.IntVal(x), runtime.IntVal(y))
+	}
+	return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Unknown bitwise operator '%s'.", symbol)
+}
+
+// isBitwiseInt reports whether v can be an operand of a bitwise operator: an int, a BigInt or a
+// float without a fractional part.
+func isBitwiseInt(v runtime.Value) bool {
+	if _, ok := runtime.AsInt(v); ok {
+		return true
+	}
+	_, ok := v.Obj.(*runtime.ObjBigInt)
+	return v.Type == runtime.VAL_OBJ && ok
+}
+
+// bitwiseBigInts applies a bitwise operator in arbitrary precision, returning a BigInt.
+func bitwiseBigInts(op runtime.OpCode, a, b runtime.Value) (runtime.Value, InterpretResult) {
+	null := runtime.Value{Type: runtime.VAL_NULL}
+	symbol := operatorSymbols[op]
+	x, y := bitwiseBigInt(a), bitwiseBigInt(b)
+	result := new(big.Int)
+	switch op {
+	case runtime.OP_BIT_AND:
+		result.And(x, y)
+	case runtime.OP_BIT_OR:
+		result.Or(x, y)
+	case runtime.OP_BIT_XOR:
+		result.Xor(x, y)
+	case runtime.OP_SHIFT_LEFT, runtime.OP_SHIFT_RIGHT:
+		if y.Sign() < 0 {
+			return null, runtimeError("Shift count for '%s' must not be negative (got %s).", symbol, y.String())
+		}
+		if op == runtime.OP_SHIFT_RIGHT {
+			if !y.IsInt64() || y.Int64() > math.MaxInt32 {
+				// Every bit is shifted out, leaving the sign.
+				return runtime.ObjVal(runtime.NewBigInt(big.NewInt(int64(min(x.Sign(), 0))))), INTERPRET_OK
+			}
+			result.Rsh(x, uint(y.Int64()))
+			break
+		}
+		if !y.IsInt64() || y.Int64() > math.MaxInt32 {
+			return null, runtimeError("Shift count %s is too large for '%s'.", y.String(), symbol)
+		}
+		result.Lsh(x, uint(y.Int64()))
+	default:
+		return null, runtimeError("Unknown bitwise operator '%s'.", symbol)
+	}
+	return runtime.ObjVal(runtime.NewBigInt(result)), INTERPRET_OK
+}
+
+// bitwiseNot applies '~', which gives -x-1 for an int or BigInt operand.
+func bitwiseNot(v runtime.Value) (runtime.Value, InterpretResult) {
+	if x, ok := runtime.AsInt(v); ok {
+		return runtime.IntVal(^x), INTERPRET_OK
+	}
+	if isBitwiseInt(v) {
+		return bigIntVal(new(big.Int).Not(bitwiseBigInt(v))), INTERPRET_OK
+	}
+	return runtime.Value{Type: runtime.VAL_NULL}, runtimeError("Operand for '~' must be an integer (got %s).", typeName(v))
+}
+
+// bitwiseBigInt returns an operand accepted by isBitwiseInt as a big.Int.
+func bitwiseBigInt(v runtime.Value) *big.Int {
+	if n, ok := runtime.AsInt(v); ok {
+		return big.NewInt(n)
+	}
+	n, _ := runtime.AsBigInt(v)
+	return n
+}
+
+// exactToFloat converts any numeric value, including BigInt and Decimal, to a float64.
+func exactToFloat(v runtime.Value) float64 {
+	if r, ok := runtime.AsRat(v); ok && runtime.IsExactNumber(v) {
+		f, _ := r.Float64()
+		return f
+	}
+	return runtime.AsNumber(v)
+}
+
+// exactArithmetic applies a binary arithmetic operator when at least one operand is a BigInt
+// or Decimal and the other is numeric. Integers promote to BigInt, and integers and BigInts
+// promote to Decimal. Floats are rejected because mixing them in would silently lose the
+// exactness these types exist for.
+func exactArithmetic(op runtime.OpCode, a, b runtime.Value) (runtime.Value, InterpretResult) {
+	null := runtime.Value{Type: runtime.VAL_NULL}
+	symbol := operatorSymbols[op]
+	if a.Type == runtime.VAL_NUMBER || b.Type == runtime.VAL_NUMBER {
+		return null, runtimeError("Cannot mix %s and %s in '%s'; convert explicitly with decimal(), bigint() or to_float().", typeName(a), typeName(b), symbol)
+	}
+
+	_, aIsDecimal := a.Obj.(*runtime.ObjDecimal)
+	_, bIsDecimal := b.Obj.(*runtime.ObjDecimal)
+	if aIsDecimal || bIsDecimal {
+		x, _ := runtime.AsDecimal(a)
+		y, _ := runtime.AsDecimal(b)
+		switch op {
+		case runtime.OP_ADD:
+			return runtime.ObjVal(runtime.DecimalAdd(x, y)), INTERPRET_OK
+		case runtime.OP_SUBTRACT:
+			return runtime.ObjVal(runtime.DecimalSub(x, y)), INTERPRET_OK
+		case runtime.OP_MULTIPLY:
+			return runtime.ObjVal(runtime.DecimalMul(x, y)), INTERPRET_OK
+		case runtime.OP_EXPONENTIAL:
+			if bIsDecimal {
+				return null, runtimeError("The exponent of a decimal '**' must be an integer (got %s).", typeName(b))
+			}
+			n, _ := runtime.AsBigInt(b)
+			if !n.IsInt64() || n.Int64() > math.MaxInt32 || n.Int64() < -math.MaxInt32 {
+				return null, runtimeError("Exponent %s is too large for '**'.", n.String())
+			}
+			exponent := n.Int64()
+			if exponent < 0 {
+				exponent = -exponent
+			}
+			power, err := runtime.DecimalPow(x, exponent)
+			if err != nil {
+				return null, runtimeError("Exponent %s is too large for '**': %v.", n.String(), err)
+			}
+			if n.Sign() >= 0 {
+				return runtime.ObjVal(power), INTERPRET_OK
+			}
+			if power.Unscaled.Sign() == 0 {
+				return null, runtimeError("Division by zero in '**' with a negative exponent.")
+			}
+			one := runtime.DecimalFromBigInt(big.NewInt(1))
+			return runtime.ObjVal(runtime.DecimalQuo(one, power, vm.decimalScale, vm.decimalRounding)), INTERPRET_OK
+		}
+		if y.Unscaled.Sign() == 0 {
+			return null, runtimeError("Division by zero in '%s'.", symbol)
+		}
+		switch op {
+		case runtime.OP_DIVIDE:
+			return runtime.ObjVal(runtime.DecimalQuo(x, y, vm.decimalScale, vm.decimalRounding)), INTERPRET_OK
+		case runtime.OP_MOD:
+			return runtime.ObjVal(runtime.DecimalRem(x, y)), INTERPRET_OK
+		case runtime.OP_FLOOR:
+			return runtime.ObjVal(runtime.DecimalFloorQuo(x, y)), INTERPRET_OK
+		}
+		return null, runtimeError("Operator '%s' is not supported for decimals.", symbol)
+	}
+
+	x, _ := runtime.AsBigInt(a)
+	y, _ := runtime.AsBigInt(b)
+	result := new(big.Int)
+	switch op {
+	case runtime.OP_ADD:
+		return runtime.ObjVal(runtime.NewBigInt(result.Add(x, y))), INTERPRET_OK
+	case runtime.OP_SUBTRACT:
+		return runtime.ObjVal(runtime.NewBigInt(result.Sub(x, y))), INTERPRET_OK
+	case runtime.OP_MULTIPLY:
+		return runtime.ObjVal(runtime.NewBigInt(result.Mul(x, y))), INTERPRET_OK
+	case runtime.OP_EXPONENTIAL:
+		if y.Sign() < 0 {
+			return null, runtimeError("BigInt '**' requires a non-negative exponent (got %s).", y.String())
+		}
+		if !y.IsInt64() || y.Int64() > math.MaxInt32 {
+			return null, runtimeError("Exponent %s is too large for '**'.", y.String())
+		}
+		return runtime.ObjVal(runtime.NewBigInt(result.Exp(x, y, nil))), INTERPRET_OK
+	}
+	if y.Sign() == 0 {
+		return null, runtimeError("Division by zero in '%s'.", symbol)
+	}
+	switch op {
+	case runtime.OP_DIVIDE:
+		// Like integer division in most languages with BigInts, '/' truncates toward zero.
+		return runtime.ObjVal(runtime.NewBigInt(result.Quo(x, y))), INTERPRET_OK
+	case runtime.OP_MOD:
+		return runtime.ObjVal(runtime.NewBigInt(result.Rem(x, y))), INTERPRET_OK
+	case runtime.OP_FLOOR:
+		remainder := new(big.Int)
+		result.QuoRem(x, y, remainder)
+		if remainder.Sign() != 0 && remainder.Sign() != y.Sign() {
+			result.Sub(result, big.NewInt(1))
+		}
+		return runtime.ObjVal(runtime.NewBigInt(result)), INTERPRET_OK
+	}
+	return null, runtimeError("Operator '%s' is not supported for BigInts.", symbol)
+}
+
+// negateExact applies unary minus to a BigInt or Decimal.
+func negateExact(v runtime.Value) runtime.Value {
+	switch o := v.Obj.(type) {
+	case *runtime.ObjBigInt:
+		return runtime.ObjVal(runtime.NewBigInt(new(big.Int).Neg(o.Value)))
+	case *runtime.ObjDecimal:
+		return runtime.ObjVal(runtime.NewDecimal(new(big.Int).Neg(o.Unscaled), o.Scale))
+	}
+	return v
+}
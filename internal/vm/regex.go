@@ -0,0 +1,210 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// ============================================================================
+// Native Functions: Regular Expressions
+// ============================================================================
+
+// regexNative compiles a pattern, with optional flags, into a regex. It is the runtime
+// counterpart of a /pattern/flags literal for patterns that are built from strings.
+func regexNative(argCount int, args []runtime.Value) runtime.Value {
+	if argCount != 1 && argCount != 2 {
+		runtimeError("'regex' expects 1 or 2 arguments: a pattern and optional flags.")
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	pattern, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'regex' expects a string pattern (got %s).", typeName(args[0]))
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	flags := ""
+	if argCount == 2 {
+		flagsObj, ok := args[1].Obj.(*runtime.ObjString)
+		if args[1].Type != runtime.VAL_OBJ || !ok {
+			runtimeError("'regex' flags must be a string (got %s).", typeName(args[1]))
+			return runtime.Value{Type: runtime.VAL_NULL}
+		}
+		flags = flagsObj.Chars
+	}
+	re, err := runtime.NewRegex(pattern.Chars, flags)
+	if err != nil {
+		runtimeError("'regex' failed: %v", err)
+		return runtime.Value{Type: runtime.VAL_NULL}
+	}
+	return runtime.ObjVal(re)
+}
+
+// regexMember returns the property name of re: 'pattern' and 'flags' are strings and the
+// remaining names are methods, returned as natives bound to re.
+func regexMember(re *runtime.ObjRegex, name string) (runtime.Value, bool) {
+	var method runtime.NativeFn
+	switch name {
+	case "pattern":
+		return runtime.ObjVal(runtime.NewObjString(re.Source)), true
+	case "flags":
+		return runtime.ObjVal(runtime.NewObjString(re.Flags)), true
+	case "test":
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			s, ok := regexSubject("test", argCount, args, 0)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return runtime.Value{Type: runtime.VAL_BOOL, Bool: re.Regexp.MatchString(s)}
+		}
+	case "match":
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			s, ok := regexSubject("match", argCount, args, 0)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			indices := re.Regexp.FindStringSubmatchIndex(s)
+			if indices == nil {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			return runtime.ObjVal(runtime.NewArray(regexGroups(s, indices)))
+		}
+	case "named":
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			s, ok := regexSubject("named", argCount, args, 0)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			indices := re.Regexp.FindStringSubmatchIndex(s)
+			if indices == nil {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			groups := regexGroups(s, indices)
+			named := runtime.NewMap()
+			for i, name := range re.Regexp.SubexpNames() {
+				if name != "" {
+					named.Set(runtime.ObjVal(runtime.NewObjString(name)), groups[i])
+				}
+			}
+			return runtime.ObjVal(named)
+		}
+	case "find_all":
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			s, ok := regexSubject("find_all", argCount, args, 1)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			limit, ok := regexLimit("find_all", args)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			var matches []runtime.Value
+			for _, indices := range re.Regexp.FindAllStringSubmatchIndex(s, limit) {
+				if re.Regexp.NumSubexp() == 0 {
+					matches = append(matches, runtime.ObjVal(runtime.NewObjString(s[indices[0]:indices[1]])))
+				} else {
+					matches = append(matches, runtime.ObjVal(runtime.NewArray(regexGroups(s, indices))))
+				}
+			}
+			return runtime.ObjVal(runtime.NewArray(matches))
+		}
+	case "replace":
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			if argCount != 2 {
+				runtimeError("'replace' expects 2 arguments: a string and a replacement string or function.")
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			s, ok := regexSubject("replace", 1, args, 0)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			if template, isString := args[1].Obj.(*runtime.ObjString); isString && args[1].Type == runtime.VAL_OBJ {
+				return runtime.ObjVal(runtime.NewObjString(re.Regexp.ReplaceAllString(s, template.Chars)))
+			}
+			if !isCallable(args[1]) {
+				runtimeError("'replace' expects a string or a function as the replacement (got %s).", typeName(args[1]))
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			var sb strings.Builder
+			last := 0
+			for _, indices := range re.Regexp.FindAllStringSubmatchIndex(s, -1) {
+				replacement, ok := callClosureFromNative(args[1], regexGroups(s, indices)...)
+				if !ok {
+					return runtime.Value{Type: runtime.VAL_NULL}
+				}
+				sb.WriteString(s[last:indices[0]])
+				sb.WriteString(toStr(1, []runtime.Value{replacement}).Obj.(*runtime.ObjString).Chars)
+				last = indices[1]
+			}
+			sb.WriteString(s[last:])
+			return runtime.ObjVal(runtime.NewObjString(sb.String()))
+		}
+	case "split":
+		method = func(argCount int, args []runtime.Value) runtime.Value {
+			s, ok := regexSubject("split", argCount, args, 1)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			limit, ok := regexLimit("split", args)
+			if !ok {
+				return runtime.Value{Type: runtime.VAL_NULL}
+			}
+			parts := re.Regexp.Split(s, limit)
+			elements := make([]runtime.Value, len(parts))
+			for i, part := range parts {
+				elements[i] = runtime.ObjVal(runtime.NewObjString(part))
+			}
+			return runtime.ObjVal(runtime.NewArray(elements))
+		}
+	default:
+		return runtime.Value{}, false
+	}
+	return runtime.ObjVal(runtime.NewNative(method)), true
+}
+
+// regexSubject checks the arguments of the named regex method, a string followed by at most
+// optional other arguments, and returns the string.
+func regexSubject(name string, argCount int, args []runtime.Value, optional int) (string, bool) {
+	if argCount < 1 || argCount > 1+optional {
+		if optional == 0 {
+			runtimeError("'%s' expects 1 argument: a string.", name)
+		} else {
+			runtimeError("'%s' expects 1 or 2 arguments: a string and an optional limit.", name)
+		}
+		return "", false
+	}
+	s, ok := args[0].Obj.(*runtime.ObjString)
+	if args[0].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' expects a string (got %s).", name, typeName(args[0]))
+		return "", false
+	}
+	return s.Chars, true
+}
+
+// regexLimit returns the optional limit argument of find_all and split, or -1 (no limit) when it
+// is not given.
+func regexLimit(name string, args []runtime.Value) (int, bool) {
+	if len(args) < 2 {
+		return -1, true
+	}
+	limit, ok := runtime.AsInt(args[1])
+	if !ok || limit < 1 {
+		runtimeError("'%s' limit must be a positive integer.", name)
+		return 0, false
+	}
+	return int(limit), true
+}
+
+// regexGroups converts the submatch indices of a match to its text followed by the text of each
+// capture group, with null for groups that did not take part in the match.
+func regexGroups(s string, indices []int) []runtime.Value {
+	groups := make([]runtime.Value, len(indices)/2)
+	for i := range groups {
+		start, end := indices[2*i], indices[2*i+1]
+		if start < 0 {
+			groups[i] = runtime.Value{Type: runtime.VAL_NULL}
+		} else {
+			groups[i] = runtime.ObjVal(runtime.NewObjString(s[start:end]))
+		}
+	}
+	return groups
+}
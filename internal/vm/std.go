@@ -0,0 +1,293 @@
+package vm
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"path/filepath"
+
+	"github.com/cryptrunner49/tulipscript/internal/runtime"
+)
+
+// GlobalAliases controls whether InitVM also defines the functions of the std modules under
+// their historical flat global names (e.g. 'str_contains' for std.str.contains). It is on by
+// default so existing scripts keep working; set it to false before InitVM to leave only the
+// core builtins and the 'std' module in the global scope.
+var GlobalAliases = true
+
+// stdNative binds a native function to its name inside a std module.
+type stdNative struct {
+	name   string           // Name inside the module (e.g., "contains" in std.str).
+	global string           // Flat global name, or "" if the function only lives in the module.
+	core   bool             // Define the global even when GlobalAliases is off (e.g., print).
+	fn     runtime.NativeFn // The native implementation.
+}
+
+// defineStdModules defines the 'std' global, whose fields are the standard library modules, so
+// 'import std.str as s' and 'std.str.trim(x)' resolve like any other module path. A module
+// function and its global alias share one native object.
+func defineStdModules() {
+	std := runtime.NewModule(runtime.NewObjString("std"))
+	add := func(module *runtime.ObjModule) {
+		std.Fields[module.Name] = runtime.ObjVal(module)
+	}
+
+	add(newStdModule("str", []stdNative{
+		{"chars", "to_chars", false, toCharsNative},
+		{"char_at", "char_at", false, charAtNative},
+		{"substring", "substring", false, substringNative},
+		{"index_of", "str_index_of", false, strIndexOfNative},
+		{"last_index_of", "str_last_index_of", false, strLastIndexOfNative},
+		{"contains", "str_contains", false, strContainsNative},
+		{"starts_with", "starts_with", false, startsWithNative},
+		{"ends_with", "ends_with", false, endsWithNative},
+		{"to_upper", "to_upper", false, toUpperNative},
+		{"to_lower", "to_lower", false, toLowerNative},
+		{"trim", "trim", false, trimNative},
+		{"split", "split", false, splitNative},
+		{"replace", "replace", false, replaceNative},
+		{"length", "str_length", false, strLengthNative},
+		{"byte_length", "str_byte_length", false, strByteLengthNative},
+		{"graphemes", "str_graphemes", false, strGraphemesNative},
+		{"grapheme_length", "str_grapheme_length", false, strGraphemeLengthNative},
+	}))
+
+	add(newStdModule("array", []stdNative{
+		{"len", "len", true, arrayLenNative},
+		{"push", "push", false, arrayPushNative},
+		{"pop", "pop", false, arrayPopNative},
+		{"sort", "array_sort", false, arraySortNative},
+		{"split", "array_split", false, arraySplitNative},
+		{"join", "array_join", false, arrayJoinNative},
+		{"sorted_push", "array_sorted_push", false, arraySortedPushNative},
+		{"linear_search", "array_linear_search", false, arrayLinearSearchNative},
+		{"binary_search", "array_binary_search", false, arrayBinarySearchNative},
+		{"index_of", "index_of", false, arrayIndexOfNative},
+		{"last_index_of", "last_index_of", false, arrayLastIndexOfNative},
+		{"contains", "array_contains", false, arrayContainsNative},
+		{"clear", "array_clear", false, arrayClearNative},
+		{"reverse", "array_reverse", false, arrayReverseNative},
+		{"to_string", "array_to_string", false, arrayToStringNative},
+		{"remove", "array_remove", false, arrayRemoveNative},
+		{"map", "array_map", false, arrayMapNative},
+		{"filter", "array_filter", false, arrayFilterNative},
+		{"reduce", "array_reduce", false, arrayReduceNative},
+		{"sort_by", "array_sort_by", false, arraySortByNative},
+		{"iter", "array_iter", false, arrayIterNative},
+	}))
+
+	add(newStdModule("map", []stdNative{
+		{"remove", "map_remove", false, mapRemoveNative},
+		{"contains_key", "map_contains_key", false, mapContainsKeyNative},
+		{"contains_value", "map_contains_value", false, mapContainsValueNative},
+		{"size", "map_size", false, mapSizeNative},
+		{"clear", "map_clear", false, mapClearNative},
+		{"keys", "map_keys", false, mapKeysNative},
+		{"values", "map_values", false, mapValuesNative},
+		{"map_values", "map_map_values", false, mapMapValuesNative},
+		{"filter", "map_filter", false, mapFilterNative},
+	}))
+
+	add(newStdModule("set", []stdNative{
+		{"add", "set_add", false, setAddNative},
+		{"remove", "set_remove", false, setRemoveNative},
+		{"has", "set_has", false, setHasNative},
+		{"size", "set_size", false, setSizeNative},
+		{"values", "set_values", false, setValuesNative},
+	}))
+
+	add(newStdModule("date", []stdNative{
+		{"new", "Date", true, dateNew},
+		{"now", "date_now", false, dateNow},
+		{"parse", "date_parse_datetime", false, dateParseDateTime},
+		{"format", "date_format_datetime", false, dateFormatDateTime},
+		{"add", "date_add_datetime", false, dateAddDateTime},
+		{"subtract", "date_subtract_datetime", false, dateSubtractDateTime},
+		{"get_component", "date_get_component", false, dateGetDateTimeComponent},
+		{"set_component", "date_set_component", false, dateSetDateTimeComponent},
+		{"add_days", "date_add_days", false, dateAddDays},
+		{"subtract_days", "date_subtract_days", false, dateSubtractDays},
+	}))
+
+	add(newStdModule("time", []stdNative{
+		{"new", "Time", true, timeNew},
+		{"now", "time_now", false, timeNow},
+		{"parse", "time_parse", false, timeParseTime},
+		{"format", "time_format", false, timeFormatTime},
+		{"add", "time_add", false, timeAddTime},
+		{"subtract", "time_subtract", false, timeSubtractTime},
+		{"get_timezone", "time_get_timezone", false, timeGetTimeZone},
+		{"convert_timezone", "time_convert_timezone", false, timeConvertTimeZone},
+	}))
+
+	add(newStdModule("datetime", []stdNative{
+		{"new", "DateTime", true, dateTimeNew},
+		{"now", "datetime_now", false, dateTimeNow},
+		{"parse", "datetime_parse", false, dateTimeParseDateTime},
+		{"format", "datetime_format", false, dateTimeFormatDateTime},
+		{"add", "datetime_add", false, dateTimeAddDateTime},
+		{"subtract", "datetime_subtract", false, dateTimeSubtractDateTime},
+		{"get_component", "datetime_get_component", false, dateTimeGetDateTimeComponent},
+		{"set_component", "datetime_set_component", false, dateTimeSetDateTimeComponent},
+		{"add_days", "datetime_add_days", false, dateTimeAddDays},
+		{"subtract_days", "datetime_subtract_days", false, dateTimeSubtractDays},
+	}))
+
+	add(newStdModule("io", []stdNative{
+		{"print", "print", true, printNative},
+		{"println", "println", true, printlnNative},
+		{"printf", "printf", false, printfNative},
+		{"sprintf", "sprintf", false, sprintfNative},
+		{"errorf", "errorf", false, errorfNative},
+		{"scan", "scan", false, scanNative},
+		{"scanln", "scanln", false, scanlnNative},
+		{"scanf", "scanf", false, scanfNative},
+	}))
+
+	add(newStdModule("fs", []stdNative{
+		{"read_file", "read_file", false, readFileNative},
+		{"write_file", "write_file", false, writeFileNative},
+		{"read_file_bytes", "read_file_bytes", false, readFileBytesNative},
+		{"write_file_bytes", "write_file_bytes", false, writeFileBytesNative},
+		{"append_file", "append_file", false, appendFileNative},
+		{"open", "open_file", false, openFileNative},
+		{"with_file", "with_file", false, withFileNative},
+		{"exists", "file_exists", false, fileExistsNative},
+		{"stat", "file_stat", false, fileStatNative},
+		{"list_dir", "list_dir", false, listDirNative},
+		{"glob", "glob", false, globNative},
+		{"mkdir", "make_dir", false, makeDirNative},
+		{"remove", "remove_path", false, removePathNative},
+		{"rename", "rename_path", false, renamePathNative},
+		{"copy", "copy_file", false, copyFileNative},
+		{"temp_file", "temp_file", false, tempFileNative},
+		{"temp_dir", "temp_dir", false, tempDirNative},
+		{"join", "path_join", false, pathJoinNative},
+		{"basename", "path_basename", false, pathNative("path_basename", filepath.Base)},
+		{"dirname", "path_dirname", false, pathNative("path_dirname", filepath.Dir)},
+		{"ext", "path_ext", false, pathNative("path_ext", filepath.Ext)},
+		{"abs", "path_abs", false, pathAbsNative},
+	}))
+
+	bytesModule := newStdModule("bytes", []stdNative{
+		{"new", "bytes", true, bytesNative},
+		{"to_string", "bytes_to_string", false, bytesToStringNative},
+		{"to_hex", "bytes_to_hex", false, encodeNative("bytes_to_hex", hex.EncodeToString)},
+		{"from_hex", "bytes_from_hex", false, bytesDecoder("bytes_from_hex", hex.DecodeString)},
+		{"to_base64", "bytes_to_base64", false, encodeNative("bytes_to_base64", base64.StdEncoding.EncodeToString)},
+		{"from_base64", "bytes_from_base64", false, bytesDecoder("bytes_from_base64", base64.StdEncoding.DecodeString)},
+		{"concat", "bytes_concat", false, bytesConcatNative},
+		{"pack", "bytes_pack", false, packNative},
+		{"unpack", "bytes_unpack", false, unpackNative},
+	})
+	add(bytesModule)
+
+	add(newStdModule("crypto", []stdNative{
+		{"md5", "md5", false, hashNative("md5", md5.New)},
+		{"sha1", "sha1", false, hashNative("sha1", sha1.New)},
+		{"sha256", "sha256", false, hashNative("sha256", sha256.New)},
+		{"sha512", "sha512", false, hashNative("sha512", sha512.New)},
+		{"crc32", "crc32", false, hashNative("crc32", newCRC32)},
+		{"hmac", "hmac", false, hmacNative},
+		{"random_bytes", "random_bytes", false, randomBytesNative},
+		{"uuid_v4", "uuid_v4", false, uuidV4Native},
+		{"uuid_v7", "uuid_v7", false, uuidV7Native},
+	}))
+
+	encoding := newStdModule("encoding", []stdNative{
+		{"base64url_encode", "base64url_encode", false, encodeNative("base64url_encode", base64.RawURLEncoding.EncodeToString)},
+		{"base64url_decode", "base64url_decode", false, bytesDecoder("base64url_decode", base64URLDecode)},
+		{"base32_encode", "base32_encode", false, encodeNative("base32_encode", base32.StdEncoding.EncodeToString)},
+		{"base32_decode", "base32_decode", false, bytesDecoder("base32_decode", base32.StdEncoding.DecodeString)},
+		{"url_encode", "url_encode", false, urlEncodeNative},
+		{"url_decode", "url_decode", false, urlDecodeNative},
+	})
+	// Hex and base64 are the std.bytes conversions, under the names of the other encodings.
+	aliasStdNative(encoding, "hex_encode", bytesModule, "to_hex")
+	aliasStdNative(encoding, "hex_decode", bytesModule, "from_hex")
+	aliasStdNative(encoding, "base64_encode", bytesModule, "to_base64")
+	aliasStdNative(encoding, "base64_decode", bytesModule, "from_base64")
+	add(encoding)
+
+	add(newStdModule("json", []stdNative{
+		{"encode", "json_encode", false, jsonEncodeNative},
+		{"decode", "json_decode", false, jsonDecodeNative},
+	}))
+
+	add(newStdModule("csv", []stdNative{
+		{"parse", "csv_parse", false, csvParseNative},
+		{"reader", "csv_reader", false, csvReaderNative},
+		{"format", "csv_format", false, csvFormatNative},
+		{"write", "csv_write", false, csvWriteNative},
+	}))
+
+	add(newStdModule("regex", []stdNative{
+		{"new", "regex", true, regexNative},
+	}))
+
+	math := newMathModule()
+	add(math)
+	if GlobalAliases {
+		defineGlobal("math", runtime.ObjVal(math))
+	}
+
+	defineGlobal("std", runtime.ObjVal(std))
+}
+
+// aliasStdNative adds the function name of module, and its global alias of the same name, as
+// another name for the function sourceName of source, sharing its native object.
+func aliasStdNative(module *runtime.ObjModule, name string, source *runtime.ObjModule, sourceName string) {
+	value := source.Fields[runtime.NewObjString(sourceName)]
+	module.Fields[runtime.NewObjString(name)] = value
+	if GlobalAliases {
+		defineGlobal(name, value)
+	}
+}
+
+// newStdModule builds the module std.<name> from natives, defining the global alias of each
+// native that has one and is either core or enabled by GlobalAliases.
+func newStdModule(name string, natives []stdNative) *runtime.ObjModule {
+	module := runtime.NewModule(runtime.NewObjString(name))
+	for _, native := range natives {
+		value := runtime.ObjVal(runtime.NewNative(native.fn))
+		module.Fields[runtime.NewObjString(native.name)] = value
+		if native.global != "" && (native.core || GlobalAliases) {
+			defineGlobal(native.global, value)
+		}
+	}
+	return module
+}
+
+// coreBuiltins are the globals that a top-level declaration may not replace: the builtins the
+// language had before the std modules, plus 'std' and 'args'. Every other global the VM defines,
+// such as the flat std aliases and newer natives like bytes and regex, can be replaced by a
+// top-level declaration like an ordinary global, so scripts that already use those names work.
+var coreBuiltins = map[string]bool{
+	"args": true, "std": true,
+	"print": true, "println": true, "len": true, "to_str": true, "get_runtype": true, "clock": true,
+	"iter_next": true, "iter_value": true, "iter_done": true, "parse_int": true,
+	"shuffle": true, "random_between": true, "random_string": true,
+	"enable_debug": true, "disable_debug": true, "enable_trace": true, "disable_trace": true,
+}
+
+// defineGlobal defines a builtin global. Builtins are constant, so assigning to one is a runtime
+// error, and a core builtin cannot be redeclared at the top level either. A declaration inside a
+// block or function still shadows any builtin, since it only hides it within that scope.
+func defineGlobal(name string, value runtime.Value) {
+	vm.globals[runtime.NewObjString(name)] = GlobalVar{Value: value, IsConst: true, IsBuiltin: coreBuiltins[name]}
+}
+
+// isBuiltin reports whether the global name is a core builtin.
+func isBuiltin(name *runtime.ObjString) bool {
+	global, exists := vm.globals[name]
+	return exists && global.IsBuiltin
+}
+
+// builtinRedeclared reports a top-level declaration that would replace the builtin name.
+func builtinRedeclared(name *runtime.ObjString) InterpretResult {
+	return runtimeError("Cannot redeclare builtin '%s' at the top level; use another name, or declare it inside a block or function to shadow it there.", name.Chars)
+}
@@ -2,11 +2,23 @@ package vm
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/cryptrunner49/tulipscript/internal/runtime"
 )
 
+// sortedFieldNames returns the field names of an instance in name order. Fields are stored in a Go
+// map, which has no order of its own, so anything that lists them uses this order to stay
+// deterministic.
+func sortedFieldNames(instance *runtime.ObjInstance) []*runtime.ObjString {
+	return slices.SortedFunc(maps.Keys(instance.Fields), func(a, b *runtime.ObjString) int {
+		return strings.Compare(a.Chars, b.Chars)
+	})
+}
+
 // isFalsey returns true if a value is considered false in boolean context.
 // In TulipScript, only null and false are considered falsey.
 func isFalsey(val runtime.Value) bool {
@@ -25,7 +37,9 @@ func typeName(val runtime.Value) string {
 		return "boolean"
 	case runtime.VAL_NULL:
 		return "null"
-	case runtime.VAL_NUMBER:
+	case runtime.VAL_NUMBER, runtime.VAL_INT:
+		// Integers report "number" like floats, as they did before they had a type of their own;
+		// is_int tells them apart.
 		return "number"
 	case runtime.VAL_OBJ:
 		switch val.Obj.(type) {
@@ -41,6 +55,32 @@ func typeName(val runtime.Value) string {
 			return "struct"
 		case *runtime.ObjInstance:
 			return "instance"
+		case *runtime.ObjBigInt:
+			return "bigint"
+		case *runtime.ObjDecimal:
+			return "decimal"
+		case *runtime.ObjRange:
+			return "range"
+		case *runtime.ObjGenerator:
+			return "generator"
+		case *runtime.ObjArray:
+			return "array"
+		case *runtime.ObjMap:
+			return "map"
+		case *runtime.ObjSet:
+			return "set"
+		case *runtime.ObjModule:
+			return "module"
+		case *runtime.ObjCSVReader:
+			return "csv reader"
+		case *runtime.ObjRegex:
+			return "regex"
+		case *runtime.ObjError:
+			return "error"
+		case *runtime.ObjFile:
+			return "file"
+		case *runtime.ObjBytes:
+			return "bytes"
 		default:
 			return "object"
 		}
@@ -49,6 +89,17 @@ func typeName(val runtime.Value) string {
 	}
 }
 
+// stringArg returns args[index] of the named native, which must be a string; what describes
+// it in the error message (e.g., "file path").
+func stringArg(name string, args []runtime.Value, index int, what string) (string, bool) {
+	s, ok := args[index].Obj.(*runtime.ObjString)
+	if args[index].Type != runtime.VAL_OBJ || !ok {
+		runtimeError("'%s' expects a string (%s) as argument %d (got %s).", name, what, index+1, typeName(args[index]))
+		return "", false
+	}
+	return s.Chars, true
+}
+
 // runtimeError prints a formatted runtime error message along with a backtrace of call frames.
 // It then resets the VM's stack and returns an INTERPRET_RUNTIME_ERROR result.
 func runtimeError(format string, args ...interface{}) InterpretResult {
@@ -70,9 +121,15 @@ func runtimeError(format string, args ...interface{}) InterpretResult {
 		}
 	}
 	resetStack()
+	vm.failed = true
 	return INTERPRET_RUNTIME_ERROR
 }
 
+// mapKeyError reports a value that cannot be used as a map key.
+func mapKeyError(key runtime.Value) InterpretResult {
+	return runtimeError("Map key must be a string, number, boolean or null (got %s).", typeName(key))
+}
+
 // callValue attempts to call a value, which can be a function, native function, or struct constructor.
 func callValue(callee runtime.Value, argCount int) bool {
 	if callee.Type == runtime.VAL_OBJ {
@@ -82,6 +139,10 @@ func callValue(callee runtime.Value, argCount int) bool {
 		case *runtime.ObjNative:
 			native := obj.Function
 			result := native(argCount, vm.stack[vm.stackTop-argCount:vm.stackTop])
+			if vm.frameCount == 0 {
+				// The native reported a runtime error, which has already reset the stack.
+				return false
+			}
 			vm.stackTop -= argCount + 1
 			Push(result)
 			return true
@@ -107,6 +168,11 @@ func call(closure *runtime.ObjClosure, argCount int) bool {
 		runtimeError("Stack overflow; too many nested function calls (max %d).", FRAMES_MAX)
 		return false
 	}
+	if closure.Function.IsGenerator {
+		startGenerator(closure, argCount)
+		return true
+	}
+	reserveStack(vm.stackTop - argCount - 1)
 	frame := &vm.frames[vm.frameCount]
 	vm.frameCount++
 	frame.closure = closure
@@ -115,6 +181,46 @@ func call(closure *runtime.ObjClosure, argCount int) bool {
 	return true
 }
 
+// callValueReentrant calls callee with args from Go code running inside the dispatch loop (e.g.,
+// an opcode that invokes a script method) and returns its result. A closure gets a new frame and
+// run() executes until that frame returns; natives and struct constructors complete immediately.
+func callValueReentrant(callee runtime.Value, args ...runtime.Value) (runtime.Value, bool) {
+	if vm.stackTop+len(args)+1 > len(vm.stack) {
+		runtimeError("Stack overflow.")
+		return runtime.Value{Type: runtime.VAL_NULL}, false
+	}
+	Push(callee)
+	for _, arg := range args {
+		Push(arg)
+	}
+	frameCount := vm.frameCount
+	if !callValue(callee, len(args)) {
+		return runtime.Value{Type: runtime.VAL_NULL}, false
+	}
+	if vm.frameCount > frameCount {
+		previousBase := vm.baseFrame
+		vm.baseFrame = frameCount
+		result := run()
+		vm.baseFrame = previousBase
+		if result != INTERPRET_OK || vm.failed {
+			return runtime.Value{Type: runtime.VAL_NULL}, false
+		}
+	}
+	return Pop(), true
+}
+
+// callClosureFromNative calls a script function handed to a native (e.g., the callback of
+// array_map) and returns its result, running the dispatch loop until the call completes. A closure
+// that declares fewer parameters than len(args) receives only the leading ones, so a callback can
+// leave out the index or key it does not need. ok is false after a runtime error, which has already
+// been reported; the native should return straight away.
+func callClosureFromNative(callee runtime.Value, args ...runtime.Value) (result runtime.Value, ok bool) {
+	if closure, isClosure := callee.Obj.(*runtime.ObjClosure); isClosure && closure.Function.Arity < len(args) {
+		args = args[:closure.Function.Arity]
+	}
+	return callValueReentrant(callee, args...)
+}
+
 // createInstance creates a new struct instance from a struct value, applying key-value pairs
 // from the stack as field initializers, and returns false if validation fails or the callee
 // is not a struct.
@@ -168,3 +274,175 @@ func createInstance(callee runtime.Value, argCount int) bool {
 	runtimeError("Cannot instantiate %s with '{}'; only structs can be instantiated this way.", typeName(callee))
 	return false
 }
+
+// makeIterator returns the iterator an 'iter' loop uses to walk value: native iterators for arrays,
+// maps (keys, or [key, value] pairs when pairs is set), strings (graphemes) and bytes, existing iterators
+// and generators as they are, and struct instances that provide their own next() and done() methods.
+func makeIterator(value runtime.Value, pairs bool) (runtime.Value, InterpretResult) {
+	if value.Type == runtime.VAL_OBJ {
+		switch obj := value.Obj.(type) {
+		case *runtime.ObjArray:
+			return runtime.ObjVal(runtime.NewArrayIterator(obj)), INTERPRET_OK
+		case *runtime.ObjMap:
+			return runtime.ObjVal(runtime.NewMapIterator(obj, pairs)), INTERPRET_OK
+		case *runtime.ObjSet:
+			return runtime.ObjVal(runtime.NewSetIterator(obj)), INTERPRET_OK
+		case *runtime.ObjString:
+			return runtime.ObjVal(runtime.NewStringIterator(obj.Chars)), INTERPRET_OK
+		case *runtime.ObjBytes:
+			return runtime.ObjVal(runtime.NewBytesIterator(obj)), INTERPRET_OK
+		case *runtime.ObjRange:
+			return runtime.ObjVal(runtime.NewRangeIterator(obj)), INTERPRET_OK
+		case runtime.Iterator, *runtime.ObjGenerator:
+			return value, INTERPRET_OK
+		case *runtime.ObjInstance:
+			_, hasNext := obj.Fields[runtime.NewObjString("next")]
+			_, hasDone := obj.Fields[runtime.NewObjString("done")]
+			if hasNext && hasDone {
+				return value, INTERPRET_OK
+			}
+			return value, runtimeError("Cannot iterate over an instance of '%s'; it needs 'next' and 'done' methods.", obj.Structure.Name.Chars)
+		}
+	}
+	return value, runtimeError("Cannot iterate over %s; expected an array, map, set, string, bytes, range, generator or iterator.", typeName(value))
+}
+
+// iteratorNext advances an iterator created by makeIterator, returning its next value or done=true
+// once it is exhausted. Generators are resumed up to their next 'yield'; instances are asked done()
+// first and then next().
+func iteratorNext(iterator runtime.Value) (value runtime.Value, done bool, err InterpretResult) {
+	switch it := iterator.Obj.(type) {
+	case runtime.Iterator:
+		if it.Done() {
+			if fallible, ok := it.(runtime.FallibleIterator); ok && fallible.Err() != nil {
+				return value, true, runtimeError("Error reading file: %v", fallible.Err())
+			}
+			return value, true, INTERPRET_OK
+		}
+		value = it.Value()
+		it.Advance()
+		return value, false, INTERPRET_OK
+	case *runtime.ObjGenerator:
+		return generatorNext(it)
+	case *runtime.ObjInstance:
+		finished, ok := callValueReentrant(it.Fields[runtime.NewObjString("done")])
+		if !ok {
+			return value, true, INTERPRET_RUNTIME_ERROR
+		}
+		if isTruth(finished) {
+			return value, true, INTERPRET_OK
+		}
+		value, ok = callValueReentrant(it.Fields[runtime.NewObjString("next")])
+		if !ok {
+			return value, true, INTERPRET_RUNTIME_ERROR
+		}
+		return value, false, INTERPRET_OK
+	}
+	return value, true, runtimeError("Cannot iterate over %s.", typeName(iterator))
+}
+
+// containsValue implements 'value in container': membership in a range, an element of an array,
+// a key of a map or a substring of a string.
+func containsValue(container, value runtime.Value) (bool, InterpretResult) {
+	if container.Type == runtime.VAL_OBJ {
+		switch obj := container.Obj.(type) {
+		case *runtime.ObjRange:
+			n, ok := runtime.AsInt(value)
+			return ok && obj.Contains(n), INTERPRET_OK
+		case *runtime.ObjArray:
+			for _, element := range obj.Elements {
+				if runtime.Equal(element, value) {
+					return true, INTERPRET_OK
+				}
+			}
+			return false, INTERPRET_OK
+		case *runtime.ObjMap:
+			return obj.Has(value), INTERPRET_OK
+		case *runtime.ObjSet:
+			return obj.Has(value), INTERPRET_OK
+		case *runtime.ObjString:
+			sub, ok := value.Obj.(*runtime.ObjString)
+			if !ok || value.Type != runtime.VAL_OBJ {
+				return false, runtimeError("Left operand of 'in' must be a string when searching a string (got %s).", typeName(value))
+			}
+			return strings.Contains(obj.Chars, sub.Chars), INTERPRET_OK
+		}
+	}
+	return false, runtimeError("Right operand of 'in' must be a range, array, map, set or string (got %s).", typeName(container))
+}
+
+// sliceByRange returns a new array with the elements of array at the indices produced by r
+// (e.g., arr[1..3], arr[0..=4 step 2]). Every index must be within the array.
+func sliceByRange(array *runtime.ObjArray, r *runtime.ObjRange) (runtime.Value, InterpretResult) {
+	if err := checkRangeBounds(r, len(array.Elements), "an array"); err != INTERPRET_OK {
+		return runtime.Value{}, err
+	}
+	count := r.Len()
+	elements := make([]runtime.Value, 0, count)
+	for i := int64(0); i < count; i++ {
+		elements = append(elements, array.Elements[r.At(i)])
+	}
+	return runtime.ObjVal(runtime.NewArray(elements)), INTERPRET_OK
+}
+
+// sliceBytesByRange returns new bytes holding the bytes of b at the indices produced by r, like
+// sliceByRange does for arrays.
+func sliceBytesByRange(b *runtime.ObjBytes, r *runtime.ObjRange) (runtime.Value, InterpretResult) {
+	if err := checkRangeBounds(r, len(b.Data), "bytes"); err != INTERPRET_OK {
+		return runtime.Value{}, err
+	}
+	count := r.Len()
+	data := make([]byte, 0, count)
+	for i := int64(0); i < count; i++ {
+		data = append(data, b.Data[r.At(i)])
+	}
+	return runtime.ObjVal(runtime.NewBytes(data)), INTERPRET_OK
+}
+
+// checkRangeBounds reports a runtime error unless every index produced by r is below length;
+// what names the sliced value in the message.
+func checkRangeBounds(r *runtime.ObjRange, length int, what string) InterpretResult {
+	count := r.Len()
+	if count > 0 {
+		first, last := r.At(0), r.At(count-1)
+		if first < 0 || first >= int64(length) || last < 0 || last >= int64(length) {
+			return runtimeError("Slice range %s is out of bounds for %s of length %d.", r.String(), what, length)
+		}
+	}
+	return INTERPRET_OK
+}
+
+// sliceBounds converts the optional start and end of a [start:end] slice of a value with length
+// elements to indices. A negative index counts from the end, indices past either end are
+// clamped, and reversed bounds are swapped.
+func sliceBounds(startVal, endVal runtime.Value, length int) (int, int, InterpretResult) {
+	start := 0
+	if startVal.Type != runtime.VAL_NULL {
+		if !runtime.IsNumber(startVal) {
+			return 0, 0, runtimeError("Slice start must be a number.")
+		}
+		start = int(runtime.AsNumber(startVal))
+		if start < 0 {
+			start += length
+		}
+	}
+
+	end := length
+	if endVal.Type != runtime.VAL_NULL {
+		if !runtime.IsNumber(endVal) {
+			return 0, 0, runtimeError("Slice end must be a number.")
+		}
+		end = int(runtime.AsNumber(endVal))
+		if end < 0 {
+			end += length
+		}
+	}
+
+	// Clamp indices to valid range.
+	start = max(0, min(start, length))
+	end = max(0, min(end, length))
+	if start > end {
+		start, end = end, start
+	}
+	return start, end, INTERPRET_OK
+}
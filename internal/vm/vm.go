@@ -53,7 +53,6 @@ import "C"
 
 import (
 	"fmt"
-	"math"
 	"os"
 	"time"
 	"unsafe"
@@ -68,6 +67,12 @@ import (
 const (
 	FRAMES_MAX = 64               // Maximum number of call frames (for nested function calls).
 	STACK_MAX  = FRAMES_MAX * 256 // Maximum number of values on the stack.
+
+	DEFAULT_DECIMAL_SCALE = 16 // Fractional digits kept by decimal division unless changed.
+
+	// FRAME_SLOTS_RESERVE is the room a new call frame is guaranteed on its stack: a full set of
+	// locals plus temporaries. Generator stacks start at this size and grow as calls need it.
+	FRAME_SLOTS_RESERVE = 4 * 256
 )
 
 // CallFrame represents an active function call.
@@ -87,22 +92,45 @@ const (
 )
 
 type GlobalVar struct {
-	Value   runtime.Value
-	IsConst bool
+	Value     runtime.Value
+	IsConst   bool
+	IsBuiltin bool // A core builtin; a top-level declaration may not replace it.
+}
+
+// executionStack holds the call frames and value slots of one thread of execution. The VM runs
+// the main script on one of these and gives every generator its own, swapping it in while the
+// generator runs.
+type executionStack struct {
+	frames       []CallFrame         // Call frame stack for function calls.
+	frameCount   int                 // Number of active call frames.
+	stack        []runtime.Value     // Value stack used during execution.
+	stackTop     int                 // Index of the next available slot on the stack.
+	openUpvalues *runtime.ObjUpvalue // Linked list of open upvalues pointing into this stack.
+}
+
+// newExecutionStack allocates an empty execution stack with room for size values.
+func newExecutionStack(size int) executionStack {
+	return executionStack{
+		frames: make([]CallFrame, FRAMES_MAX),
+		stack:  make([]runtime.Value, size),
+	}
 }
 
 // VM represents the virtual machine state.
 type VM struct {
-	frames       [FRAMES_MAX]CallFrame            // Call frame stack for function calls.
-	frameCount   int                              // Number of active call frames.
-	stack        [STACK_MAX]runtime.Value         // Value stack used during execution.
-	stackTop     int                              // Index of the next available slot on the stack.
-	objects      *runtime.Obj                     // Linked list of all allocated objects.
-	globals      map[*runtime.ObjString]GlobalVar // Global variables table.
-	strings      map[uint32]*runtime.ObjString    // Interned strings table.
-	openUpvalues *runtime.ObjUpvalue              // Linked list of open upvalues for closures.
-	libHandles   []unsafe.Pointer                 // List of loaded library handles.
-	lastValue    runtime.Value                    // Store the last value from script execution
+	executionStack                                  // The stack currently executing.
+	objects        *runtime.Obj                     // Linked list of all allocated objects.
+	globals        map[*runtime.ObjString]GlobalVar // Global variables table.
+	strings        map[uint32]*runtime.ObjString    // Interned strings table.
+	libHandles     []unsafe.Pointer                 // List of loaded library handles.
+	lastValue      runtime.Value                    // Store the last value from script execution
+	baseFrame      int                              // Frame count at which a reentrant run() returns.
+	yielded        bool                             // Set by OP_YIELD when a generator suspends.
+	yieldValue     runtime.Value                    // The value passed to 'yield'.
+	failed         bool                             // Set by runtimeError until the next Interpret.
+
+	decimalScale    int32                // Fractional digits kept by decimal division.
+	decimalRounding runtime.RoundingMode // Rounding mode used by decimal division and rounding.
 }
 
 func GetLastValue() runtime.Value {
@@ -114,15 +142,19 @@ var vm VM // Global VM instance.
 // InitVM initializes the virtual machine, sets up the stack and built-in globals,
 // and processes command-line arguments.
 func InitVM(args []string) {
+	vm.executionStack = newExecutionStack(STACK_MAX)
 	resetStack()
 	vm.objects = nil
 	vm.globals = make(map[*runtime.ObjString]GlobalVar)
 	vm.strings = make(map[uint32]*runtime.ObjString)
 	vm.lastValue = runtime.Value{Type: runtime.VAL_NULL}
+	vm.decimalScale = DEFAULT_DECIMAL_SCALE
+	vm.decimalRounding = runtime.ROUND_HALF_EVEN
 
-	// Define built-in native functions and globals, including command-line arguments.
+	// Define built-in native functions and globals, including command-line arguments and the std modules.
 	defineAllNatives()
 	defineArgs(args)
+	defineStdModules()
 }
 
 // FreeVM frees resources used by the VM.
@@ -130,6 +162,7 @@ func FreeVM() {
 	for _, handle := range vm.libHandles {
 		C.close_library(handle)
 	}
+	runtime.CloseFiles()
 
 	vm.globals = nil
 	vm.strings = nil
@@ -170,6 +203,7 @@ func peek(distance int) runtime.Value {
 // It returns an interpretation result indicating success or type of error.
 func Interpret(source string, scriptPath string) InterpretResult {
 	resetStack()
+	vm.failed = false
 	function := compiler.Compile(source, scriptPath)
 	if function == nil {
 		return INTERPRET_COMPILE_ERROR
@@ -279,10 +313,16 @@ func run() InterpretResult {
 			Push(vm.stack[frame.slots+int(slot)])
 		case uint8(runtime.OP_DEFINE_GLOBAL):
 			name := readString(frame)
+			if isBuiltin(name) {
+				return builtinRedeclared(name)
+			}
 			vm.globals[name] = GlobalVar{Value: peek(0), IsConst: false}
 			Pop()
 		case uint8(runtime.OP_DEFINE_CONST_GLOBAL):
 			name := readString(frame)
+			if isBuiltin(name) {
+				return builtinRedeclared(name)
+			}
 			vm.globals[name] = GlobalVar{Value: peek(0), IsConst: true}
 			Pop()
 		case uint8(runtime.OP_SET_GLOBAL):
@@ -335,20 +375,66 @@ func run() InterpretResult {
 				name := readString(frame)
 				if name.Chars == "length" {
 					Pop()
-					Push(runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(len(obj.Elements))})
+					Push(runtime.IntVal(int64(len(obj.Elements))))
 				} else {
 					return runtimeError("Cannot access property '%s' on array; only 'length' is supported.", name.Chars)
 				}
+			case *runtime.ObjGenerator:
+				name := readString(frame)
+				method, found := generatorMethod(obj, name.Chars)
+				if !found {
+					return runtimeError("Cannot access property '%s' on a generator; only 'next' and 'done' are supported.", name.Chars)
+				}
+				Pop()
+				Push(method)
+			case *runtime.ObjRegex:
+				name := readString(frame)
+				member, found := regexMember(obj, name.Chars)
+				if !found {
+					return runtimeError("Property '%s' does not exist on regex.", name.Chars)
+				}
+				Pop()
+				Push(member)
+			case *runtime.ObjBytes:
+				name := readString(frame)
+				if name.Chars != "length" {
+					return runtimeError("Cannot access property '%s' on bytes; only 'length' is supported.", name.Chars)
+				}
+				Pop()
+				Push(runtime.IntVal(int64(len(obj.Data))))
+			case *runtime.ObjFile:
+				name := readString(frame)
+				member, found := fileMember(obj, name.Chars)
+				if !found {
+					return runtimeError("Property '%s' does not exist on file.", name.Chars)
+				}
+				Pop()
+				Push(member)
+			case *runtime.ObjError:
+				name := readString(frame)
+				var value runtime.Value
+				switch name.Chars {
+				case "kind":
+					value = runtime.ObjVal(runtime.NewObjString(obj.Kind))
+				case "message":
+					value = runtime.ObjVal(runtime.NewObjString(obj.Message))
+				case "path":
+					value = runtime.ObjVal(runtime.NewObjString(obj.Path))
+				default:
+					return runtimeError("Property '%s' does not exist on error.", name.Chars)
+				}
+				Pop()
+				Push(value)
 			case *runtime.ObjDate:
 				name := readString(frame)
 				var value runtime.Value
 				switch name.Chars {
 				case "year":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Year())}
+					value = runtime.IntVal(int64(obj.Time.Year()))
 				case "month":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Month())}
+					value = runtime.IntVal(int64(obj.Time.Month()))
 				case "day":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Day())}
+					value = runtime.IntVal(int64(obj.Time.Day()))
 				default:
 					return runtimeError("Property '%s' does not exist on Date.", name.Chars)
 				}
@@ -359,13 +445,13 @@ func run() InterpretResult {
 				var value runtime.Value
 				switch name.Chars {
 				case "hour":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Hour())}
+					value = runtime.IntVal(int64(obj.Time.Hour()))
 				case "minute":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Minute())}
+					value = runtime.IntVal(int64(obj.Time.Minute()))
 				case "second":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Second())}
+					value = runtime.IntVal(int64(obj.Time.Second()))
 				case "nanosecond":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Nanosecond())}
+					value = runtime.IntVal(int64(obj.Time.Nanosecond()))
 				default:
 					return runtimeError("Property '%s' does not exist on Time.", name.Chars)
 				}
@@ -376,19 +462,19 @@ func run() InterpretResult {
 				var value runtime.Value
 				switch name.Chars {
 				case "year":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Year())}
+					value = runtime.IntVal(int64(obj.Time.Year()))
 				case "month":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Month())}
+					value = runtime.IntVal(int64(obj.Time.Month()))
 				case "day":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Day())}
+					value = runtime.IntVal(int64(obj.Time.Day()))
 				case "hour":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Hour())}
+					value = runtime.IntVal(int64(obj.Time.Hour()))
 				case "minute":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Minute())}
+					value = runtime.IntVal(int64(obj.Time.Minute()))
 				case "second":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Second())}
+					value = runtime.IntVal(int64(obj.Time.Second()))
 				case "nanosecond":
-					value = runtime.Value{Type: runtime.VAL_NUMBER, Number: float64(obj.Time.Nanosecond())}
+					value = runtime.IntVal(int64(obj.Time.Nanosecond()))
 				default:
 					return runtimeError("Property '%s' does not exist on DateTime.", name.Chars)
 				}
@@ -417,18 +503,18 @@ func run() InterpretResult {
 			case *runtime.ObjDate:
 				name := readString(frame)
 				value := peek(0)
-				if value.Type != runtime.VAL_NUMBER {
+				if !runtime.IsNumber(value) {
 					return runtimeError("Property '%s' must be a number.", name.Chars)
 				}
 				switch name.Chars {
 				case "year":
-					newYear := int(value.Number)
+					newYear := int(runtime.AsNumber(value))
 					obj.Time = time.Date(newYear, obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "month":
-					newMonth := time.Month(value.Number)
+					newMonth := time.Month(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), newMonth, obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "day":
-					newDay := int(value.Number)
+					newDay := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), newDay, obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				default:
 					return runtimeError("Property '%s' does not exist on Date.", name.Chars)
@@ -439,21 +525,21 @@ func run() InterpretResult {
 			case *runtime.ObjTime:
 				name := readString(frame)
 				value := peek(0)
-				if value.Type != runtime.VAL_NUMBER {
+				if !runtime.IsNumber(value) {
 					return runtimeError("Property '%s' must be a number.", name.Chars)
 				}
 				switch name.Chars {
 				case "hour":
-					newHour := int(value.Number)
+					newHour := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), newHour, obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "minute":
-					newMinute := int(value.Number)
+					newMinute := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), newMinute, obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "second":
-					newSecond := int(value.Number)
+					newSecond := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), newSecond, obj.Time.Nanosecond(), obj.Time.Location())
 				case "nanosecond":
-					newNano := int(value.Number)
+					newNano := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), newNano, obj.Time.Location())
 				default:
 					return runtimeError("Property '%s' does not exist on Time.", name.Chars)
@@ -464,30 +550,30 @@ func run() InterpretResult {
 			case *runtime.ObjDateTime:
 				name := readString(frame)
 				value := peek(0)
-				if value.Type != runtime.VAL_NUMBER {
+				if !runtime.IsNumber(value) {
 					return runtimeError("Property '%s' must be a number.", name.Chars)
 				}
 				switch name.Chars {
 				case "year":
-					newYear := int(value.Number)
+					newYear := int(runtime.AsNumber(value))
 					obj.Time = time.Date(newYear, obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "month":
-					newMonth := time.Month(value.Number)
+					newMonth := time.Month(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), newMonth, obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "day":
-					newDay := int(value.Number)
+					newDay := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), newDay, obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "hour":
-					newHour := int(value.Number)
+					newHour := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), newHour, obj.Time.Minute(), obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "minute":
-					newMinute := int(value.Number)
+					newMinute := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), newMinute, obj.Time.Second(), obj.Time.Nanosecond(), obj.Time.Location())
 				case "second":
-					newSecond := int(value.Number)
+					newSecond := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), newSecond, obj.Time.Nanosecond(), obj.Time.Location())
 				case "nanosecond":
-					newNano := int(value.Number)
+					newNano := int(runtime.AsNumber(value))
 					obj.Time = time.Date(obj.Time.Year(), obj.Time.Month(), obj.Time.Day(), obj.Time.Hour(), obj.Time.Minute(), obj.Time.Second(), newNano, obj.Time.Location())
 				default:
 					return runtimeError("Property '%s' does not exist on DateTime.", name.Chars)
@@ -503,22 +589,32 @@ func run() InterpretResult {
 			a := Pop()
 			Push(runtime.Value{Type: runtime.VAL_BOOL, Bool: runtime.Equal(a, b)})
 		case uint8(runtime.OP_GREATER):
-			if peek(0).Type != runtime.VAL_NUMBER || peek(1).Type != runtime.VAL_NUMBER {
+			if !isNumeric(peek(0)) || !isNumeric(peek(1)) {
 				return runtimeError("Both operands for '>' must be numbers (got %s and %s).", typeName(peek(1)), typeName(peek(0)))
 			}
 			b := Pop()
 			a := Pop()
-			Push(runtime.Value{Type: runtime.VAL_BOOL, Bool: a.Number > b.Number})
+			Push(runtime.Value{Type: runtime.VAL_BOOL, Bool: compareNumbers(a, b) > 0})
 		case uint8(runtime.OP_LESS):
-			if peek(0).Type != runtime.VAL_NUMBER || peek(1).Type != runtime.VAL_NUMBER {
+			if !isNumeric(peek(0)) || !isNumeric(peek(1)) {
 				return runtimeError("Both operands for '<' must be numbers (got %s and %s).", typeName(peek(1)), typeName(peek(0)))
 			}
 			b := Pop()
 			a := Pop()
-			Push(runtime.Value{Type: runtime.VAL_BOOL, Bool: a.Number < b.Number})
+			Push(runtime.Value{Type: runtime.VAL_BOOL, Bool: compareNumbers(a, b) < 0})
 
 		case uint8(runtime.OP_ADD):
-			if peek(0).Type == runtime.VAL_NUMBER && peek(1).Type == runtime.VAL_NUMBER {
+			if (runtime.IsExactNumber(peek(0)) || runtime.IsExactNumber(peek(1))) && isNumeric(peek(0)) && isNumeric(peek(1)) {
+				b := Pop()
+				a := Pop()
+				result, err := exactArithmetic(runtime.OP_ADD, a, b)
+				if err != INTERPRET_OK {
+					return err
+				}
+				Push(result)
+				break
+			}
+			if runtime.IsNumber(peek(0)) && runtime.IsNumber(peek(1)) {
 				b := Pop()
 				a := Pop()
 				Push(addNumbers(a, b))
@@ -547,6 +643,15 @@ func run() InterpretResult {
 					} else {
 						return runtimeError("Operands must be of the same type for '+'. Got %s and %s.", typeName(a), typeName(b))
 					}
+				case *runtime.ObjSet:
+					if set1, ok := a.Obj.(*runtime.ObjSet); ok {
+						result := unionSets(set1, obj2)
+						Pop()
+						Pop()
+						Push(result)
+					} else {
+						return runtimeError("Operands must be of the same type for '+'. Got %s and %s.", typeName(a), typeName(b))
+					}
 				case *runtime.ObjArray:
 					if arr1, ok := a.Obj.(*runtime.ObjArray); ok {
 						result := addArrays(arr1, obj2)
@@ -572,35 +677,35 @@ func run() InterpretResult {
 			} else {
 				b := Pop()
 				a := Pop()
-				if a.Type == runtime.VAL_OBJ && b.Type == runtime.VAL_NUMBER {
+				if a.Type == runtime.VAL_OBJ && runtime.IsNumber(b) {
 					switch obj := a.Obj.(type) {
 					case *runtime.ObjDate:
-						days := int(b.Number)
+						days := int(runtime.AsNumber(b))
 						newTime := obj.Time.AddDate(0, 0, days)
 						Push(runtime.ObjVal(runtime.NewDate(newTime.Year(), newTime.Month(), newTime.Day())))
 					case *runtime.ObjTime:
-						seconds := int64(b.Number)
+						seconds := int64(runtime.AsNumber(b))
 						newTime := obj.Time.Add(time.Duration(seconds) * time.Second)
 						Push(runtime.ObjVal(runtime.NewTime(newTime.Hour(), newTime.Minute(), newTime.Second())))
 					case *runtime.ObjDateTime:
-						seconds := int64(b.Number)
+						seconds := int64(runtime.AsNumber(b))
 						newTime := obj.Time.Add(time.Duration(seconds) * time.Second)
 						Push(runtime.ObjVal(runtime.NewDateTime(newTime.Year(), newTime.Month(), newTime.Day(), newTime.Hour(), newTime.Minute(), newTime.Second())))
 					default:
 						Push(addStrings(a, b)) // Mixed types fallback to string concatenation
 					}
-				} else if a.Type == runtime.VAL_NUMBER && b.Type == runtime.VAL_OBJ {
+				} else if runtime.IsNumber(a) && b.Type == runtime.VAL_OBJ {
 					switch obj := b.Obj.(type) {
 					case *runtime.ObjDate:
-						days := int(a.Number)
+						days := int(runtime.AsNumber(a))
 						newTime := obj.Time.AddDate(0, 0, days)
 						Push(runtime.ObjVal(runtime.NewDate(newTime.Year(), newTime.Month(), newTime.Day())))
 					case *runtime.ObjTime:
-						seconds := int64(a.Number)
+						seconds := int64(runtime.AsNumber(a))
 						newTime := obj.Time.Add(time.Duration(seconds) * time.Second)
 						Push(runtime.ObjVal(runtime.NewTime(newTime.Hour(), newTime.Minute(), newTime.Second())))
 					case *runtime.ObjDateTime:
-						seconds := int64(a.Number)
+						seconds := int64(runtime.AsNumber(a))
 						newTime := obj.Time.Add(time.Duration(seconds) * time.Second)
 						Push(runtime.ObjVal(runtime.NewDateTime(newTime.Year(), newTime.Month(), newTime.Day(), newTime.Hour(), newTime.Minute(), newTime.Second())))
 					default:
@@ -610,7 +715,17 @@ func run() InterpretResult {
 			}
 
 		case uint8(runtime.OP_SUBTRACT):
-			if peek(0).Type == runtime.VAL_NUMBER && peek(1).Type == runtime.VAL_NUMBER {
+			if (runtime.IsExactNumber(peek(0)) || runtime.IsExactNumber(peek(1))) && isNumeric(peek(0)) && isNumeric(peek(1)) {
+				b := Pop()
+				a := Pop()
+				result, err := exactArithmetic(runtime.OP_SUBTRACT, a, b)
+				if err != INTERPRET_OK {
+					return err
+				}
+				Push(result)
+				break
+			}
+			if runtime.IsNumber(peek(0)) && runtime.IsNumber(peek(1)) {
 				b := Pop()
 				a := Pop()
 				Push(subtractNumbers(a, b))
@@ -639,6 +754,15 @@ func run() InterpretResult {
 					} else {
 						return runtimeError("Operands must be of the same type for '-'. Got %s and %s.", typeName(a), typeName(b))
 					}
+				case *runtime.ObjSet:
+					if set1, ok := a.Obj.(*runtime.ObjSet); ok {
+						result := differenceSets(set1, obj2)
+						Pop()
+						Pop()
+						Push(result)
+					} else {
+						return runtimeError("Operands must be of the same type for '-'. Got %s and %s.", typeName(a), typeName(b))
+					}
 				case *runtime.ObjArray:
 					if arr1, ok := a.Obj.(*runtime.ObjArray); ok {
 						result := subtractArrays(arr1, obj2)
@@ -664,18 +788,18 @@ func run() InterpretResult {
 			} else {
 				b := Pop()
 				a := Pop()
-				if a.Type == runtime.VAL_OBJ && b.Type == runtime.VAL_NUMBER {
+				if a.Type == runtime.VAL_OBJ && runtime.IsNumber(b) {
 					switch obj := a.Obj.(type) {
 					case *runtime.ObjDate:
-						days := int(b.Number)
+						days := int(runtime.AsNumber(b))
 						newTime := obj.Time.AddDate(0, 0, -days)
 						Push(runtime.ObjVal(runtime.NewDate(newTime.Year(), newTime.Month(), newTime.Day())))
 					case *runtime.ObjTime:
-						seconds := int64(b.Number)
+						seconds := int64(runtime.AsNumber(b))
 						newTime := obj.Time.Add(-time.Duration(seconds) * time.Second)
 						Push(runtime.ObjVal(runtime.NewTime(newTime.Hour(), newTime.Minute(), newTime.Second())))
 					case *runtime.ObjDateTime:
-						seconds := int64(b.Number)
+						seconds := int64(runtime.AsNumber(b))
 						newTime := obj.Time.Add(-time.Duration(seconds) * time.Second)
 						Push(runtime.ObjVal(runtime.NewDateTime(newTime.Year(), newTime.Month(), newTime.Day(), newTime.Hour(), newTime.Minute(), newTime.Second())))
 					default:
@@ -686,10 +810,20 @@ func run() InterpretResult {
 			}
 
 		case uint8(runtime.OP_MULTIPLY):
+			if (runtime.IsExactNumber(peek(0)) || runtime.IsExactNumber(peek(1))) && isNumeric(peek(0)) && isNumeric(peek(1)) {
+				b := Pop()
+				a := Pop()
+				result, err := exactArithmetic(runtime.OP_MULTIPLY, a, b)
+				if err != INTERPRET_OK {
+					return err
+				}
+				Push(result)
+				break
+			}
 			b := peek(0)
 			a := peek(1)
 			switch {
-			case b.Type == runtime.VAL_NUMBER && a.Type == runtime.VAL_NUMBER:
+			case runtime.IsNumber(b) && runtime.IsNumber(a):
 				bVal := Pop()
 				aVal := Pop()
 				Push(multiplyNumbers(aVal, bVal))
@@ -725,6 +859,15 @@ func run() InterpretResult {
 						runtimeError("Operator '*' requires numbers or arrays of numbers (got %s and %s).", typeName(aVal), typeName(bVal))
 						Push(aVal)
 					}
+				case *runtime.ObjSet:
+					if set1, ok := a.Obj.(*runtime.ObjSet); ok {
+						result := intersectSets(set1, obj2)
+						Pop()
+						Pop()
+						Push(result)
+					} else {
+						return runtimeError("Operands must be of the same type for '*'. Got %s and %s.", typeName(a), typeName(b))
+					}
 				default:
 					bVal := Pop()
 					aVal := Pop()
@@ -739,10 +882,20 @@ func run() InterpretResult {
 			}
 
 		case uint8(runtime.OP_DIVIDE):
+			if (runtime.IsExactNumber(peek(0)) || runtime.IsExactNumber(peek(1))) && isNumeric(peek(0)) && isNumeric(peek(1)) {
+				b := Pop()
+				a := Pop()
+				result, err := exactArithmetic(runtime.OP_DIVIDE, a, b)
+				if err != INTERPRET_OK {
+					return err
+				}
+				Push(result)
+				break
+			}
 			b := peek(0)
 			a := peek(1)
 			switch {
-			case b.Type == runtime.VAL_NUMBER && a.Type == runtime.VAL_NUMBER:
+			case runtime.IsNumber(b) && runtime.IsNumber(a):
 				bVal := Pop()
 				aVal := Pop()
 				Push(divideNumbers(aVal, bVal))
@@ -792,10 +945,20 @@ func run() InterpretResult {
 			}
 
 		case uint8(runtime.OP_MOD):
+			if (runtime.IsExactNumber(peek(0)) || runtime.IsExactNumber(peek(1))) && isNumeric(peek(0)) && isNumeric(peek(1)) {
+				b := Pop()
+				a := Pop()
+				result, err := exactArithmetic(runtime.OP_MOD, a, b)
+				if err != INTERPRET_OK {
+					return err
+				}
+				Push(result)
+				break
+			}
 			b := peek(0)
 			a := peek(1)
 			switch {
-			case b.Type == runtime.VAL_NUMBER && a.Type == runtime.VAL_NUMBER:
+			case runtime.IsNumber(b) && runtime.IsNumber(a):
 				bVal := Pop()
 				aVal := Pop()
 				Push(modNumbers(aVal, bVal))
@@ -850,22 +1013,24 @@ func run() InterpretResult {
 			Push(runtime.Value{Type: runtime.VAL_BOOL, Bool: isFalsey(val)})
 		case uint8(runtime.OP_NEGATE):
 			// Negation: applies unary minus to a number or an array of numbers.
-			if peek(0).Type == runtime.VAL_NUMBER {
+			if runtime.IsNumber(peek(0)) {
 				val := Pop()
-				Push(runtime.Value{Type: runtime.VAL_NUMBER, Number: -val.Number})
+				Push(negateNumber(val))
+			} else if runtime.IsExactNumber(peek(0)) {
+				Push(negateExact(Pop()))
 			} else if peek(0).Type == runtime.VAL_OBJ {
 				// Check if the object is an array.
 				if array, ok := peek(0).Obj.(*runtime.ObjArray); ok {
 					// Verify that every element is a number.
 					for _, elem := range array.Elements {
-						if elem.Type != runtime.VAL_NUMBER {
+						if !runtime.IsNumber(elem) {
 							return runtimeError("Unary '-' requires a number or an array of numbers (got non-number element).")
 						}
 					}
 					// Create a new array with negated numbers.
 					newElements := make([]runtime.Value, len(array.Elements))
 					for i, elem := range array.Elements {
-						newElements[i] = runtime.Value{Type: runtime.VAL_NUMBER, Number: -elem.Number}
+						newElements[i] = negateNumber(elem)
 					}
 					// Pop the original array and push the new negated array.
 					Pop()
@@ -943,6 +1108,10 @@ func run() InterpretResult {
 				vm.stackTop = frame.slots
 				Push(result)
 				frame = &vm.frames[vm.frameCount-1]
+				if vm.frameCount == vm.baseFrame {
+					// The frame started by callValueReentrant has finished.
+					return INTERPRET_OK
+				}
 			}
 		case uint8(runtime.OP_STRUCT):
 			// Create a new struct type instance.
@@ -975,27 +1144,44 @@ func run() InterpretResult {
 
 			switch o := obj.Obj.(type) {
 			case *runtime.ObjArray:
-				if index.Type != runtime.VAL_NUMBER {
+				if r, ok := index.Obj.(*runtime.ObjRange); ok && index.Type == runtime.VAL_OBJ {
+					slice, err := sliceByRange(o, r)
+					if err != INTERPRET_OK {
+						return err
+					}
+					Push(slice)
+					break
+				}
+				if !runtime.IsNumber(index) {
 					runtimeError("Array index must be a number.")
 					break
 				}
-				idx := int(index.Number)
+				idx := int(runtime.AsNumber(index))
 				if idx < 0 || idx >= len(o.Elements) {
 					runtimeError("Array index out of bounds.")
 					break
 				}
 				Push(o.Elements[idx])
-			case *runtime.ObjMap:
-				if index.Type != runtime.VAL_OBJ {
-					runtimeError("Map key must be a string.")
+			case *runtime.ObjBytes:
+				if r, ok := index.Obj.(*runtime.ObjRange); ok && index.Type == runtime.VAL_OBJ {
+					slice, err := sliceBytesByRange(o, r)
+					if err != INTERPRET_OK {
+						return err
+					}
+					Push(slice)
 					break
 				}
-				key, ok := index.Obj.(*runtime.ObjString)
+				idx, ok := bytesIndex(o, index)
 				if !ok {
-					runtimeError("Map key must be a string.")
+					return INTERPRET_RUNTIME_ERROR
+				}
+				Push(runtime.IntVal(int64(o.Data[idx])))
+			case *runtime.ObjMap:
+				if !runtime.IsHashable(index) {
+					mapKeyError(index)
 					break
 				}
-				val, exists := o.Entries[key]
+				val, exists := o.Get(index)
 				if exists {
 					Push(val)
 				} else {
@@ -1017,28 +1203,33 @@ func run() InterpretResult {
 
 			switch o := obj.Obj.(type) {
 			case *runtime.ObjArray:
-				if index.Type != runtime.VAL_NUMBER {
+				if !runtime.IsNumber(index) {
 					runtimeError("Array index must be a number.")
 					break
 				}
-				idx := int(index.Number)
+				idx := int(runtime.AsNumber(index))
 				if idx < 0 || idx >= len(o.Elements) {
 					runtimeError("Array index out of bounds.")
 					break
 				}
 				o.Elements[idx] = value
 				Push(value)
-			case *runtime.ObjMap:
-				if index.Type != runtime.VAL_OBJ {
-					runtimeError("Map key must be a string.")
-					break
-				}
-				key, ok := index.Obj.(*runtime.ObjString)
+			case *runtime.ObjBytes:
+				idx, ok := bytesIndex(o, index)
 				if !ok {
-					runtimeError("Map key must be a string.")
+					return INTERPRET_RUNTIME_ERROR
+				}
+				b, ok := runtime.AsInt(value)
+				if !ok || b < 0 || b > 255 {
+					return runtimeError("A byte must be an integer from 0 to 255 (got %s).", toStr(1, []runtime.Value{value}).Obj.(*runtime.ObjString).Chars)
+				}
+				o.Data[idx] = byte(b)
+				Push(value)
+			case *runtime.ObjMap:
+				if !o.Set(index, value) {
+					mapKeyError(index)
 					break
 				}
-				o.Entries[key] = value
 				Push(value)
 			default:
 				runtimeError("Object does not support indexing.")
@@ -1063,10 +1254,7 @@ func run() InterpretResult {
 			if !ok {
 				return runtimeError("Can only get length of arrays.")
 			}
-			Push(runtime.Value{
-				Type:   runtime.VAL_NUMBER,
-				Number: float64(len(array.Elements)),
-			})
+			Push(runtime.IntVal(int64(len(array.Elements))))
 		case uint8(runtime.OP_ARRAY_SLICE):
 			// Create a slice of an array given start and end indices.
 			endVal := Pop()
@@ -1074,56 +1262,27 @@ func run() InterpretResult {
 			arrayVal := Pop()
 
 			if arrayVal.Type != runtime.VAL_OBJ {
-				return runtimeError("Expected array for slice operation.")
-			}
-			array, ok := arrayVal.Obj.(*runtime.ObjArray)
-			if !ok {
-				return runtimeError("Expected array for slice operation.")
-			}
-
-			start := 0
-			if startVal.Type != runtime.VAL_NULL {
-				if startVal.Type != runtime.VAL_NUMBER {
-					return runtimeError("Slice start must be a number.")
-				}
-				start = int(startVal.Number)
-				if start < 0 {
-					start += len(array.Elements)
-				}
+				return runtimeError("Expected array or bytes for slice operation.")
 			}
-
-			end := len(array.Elements)
-			if endVal.Type != runtime.VAL_NULL {
-				if endVal.Type != runtime.VAL_NUMBER {
-					return runtimeError("Slice end must be a number.")
+			switch obj := arrayVal.Obj.(type) {
+			case *runtime.ObjArray:
+				start, end, err := sliceBounds(startVal, endVal, len(obj.Elements))
+				if err != INTERPRET_OK {
+					return err
 				}
-				end = int(endVal.Number)
-				if end < 0 {
-					end += len(array.Elements)
+				Push(runtime.ObjVal(runtime.NewArray(obj.Elements[start:end])))
+			case *runtime.ObjBytes:
+				// Unlike an array slice, a bytes slice is a copy, so writing to it leaves the
+				// original buffer alone.
+				start, end, err := sliceBounds(startVal, endVal, len(obj.Data))
+				if err != INTERPRET_OK {
+					return err
 				}
+				Push(runtime.ObjVal(runtime.NewBytes(append([]byte(nil), obj.Data[start:end]...))))
+			default:
+				return runtimeError("Expected array or bytes for slice operation.")
 			}
 
-			// Clamp indices to valid range.
-			if start < 0 {
-				start = 0
-			} else if start > len(array.Elements) {
-				start = len(array.Elements)
-			}
-
-			if end < 0 {
-				end = 0
-			} else if end > len(array.Elements) {
-				end = len(array.Elements)
-			}
-
-			if start > end {
-				start, end = end, start
-			}
-
-			elements := array.Elements[start:end]
-			newArray := runtime.NewArray(elements)
-			Push(runtime.Value{Type: runtime.VAL_OBJ, Obj: newArray})
-
 		case uint8(runtime.OP_MODULE):
 			// Create a new module type instance.
 			name := readString(frame)
@@ -1187,25 +1346,22 @@ func run() InterpretResult {
 			}
 			nativeFunc := createNativeFunc(funcName, cFunc, returnType, paramTypes)
 			nameObj := runtime.NewObjString(funcName)
+			if isBuiltin(nameObj) {
+				return builtinRedeclared(nameObj)
+			}
 			vm.globals[nameObj] = GlobalVar{Value: runtime.Value{Type: runtime.VAL_OBJ, Obj: nativeFunc}, IsConst: false}
 
 		case uint8(runtime.OP_MAP):
 			pairCount := int(readByte(frame))
 			mapObj := runtime.NewMap()
-			for i := 0; i < pairCount; i++ {
-				value := Pop()
-				keyVal := Pop()
-				if keyVal.Type != runtime.VAL_OBJ {
-					runtimeError("Map key must be a string")
-					continue
-				}
-				key, ok := keyVal.Obj.(*runtime.ObjString)
-				if !ok {
-					runtimeError("Map key must be a string")
-					continue
+			// Insert pairs in source order so a repeated key keeps its last value.
+			base := vm.stackTop - pairCount*2
+			for i := base; i < vm.stackTop; i += 2 {
+				if !mapObj.Set(vm.stack[i], vm.stack[i+1]) {
+					return mapKeyError(vm.stack[i])
 				}
-				mapObj.Entries[key] = value
 			}
+			vm.stackTop = base
 			Push(runtime.ObjVal(mapObj))
 		case uint8(runtime.OP_MATCH):
 			// TODO
@@ -1217,30 +1373,233 @@ func run() InterpretResult {
 		case uint8(runtime.OP_EXPONENTIAL):
 			b := Pop()
 			a := Pop()
-			if a.Type != runtime.VAL_NUMBER || b.Type != runtime.VAL_NUMBER {
+			if !isNumeric(a) || !isNumeric(b) {
 				return runtimeError("Operands for '**' must be numbers (got %s and %s).", typeName(a), typeName(b))
 			}
-			result := math.Pow(a.Number, b.Number)
-			Push(runtime.Value{Type: runtime.VAL_NUMBER, Number: result})
+			if runtime.IsExactNumber(a) || runtime.IsExactNumber(b) {
+				result, err := exactArithmetic(runtime.OP_EXPONENTIAL, a, b)
+				if err != INTERPRET_OK {
+					return err
+				}
+				Push(result)
+				break
+			}
+			result, err := powNumbers(a, b)
+			if err != INTERPRET_OK {
+				return err
+			}
+			Push(result)
 		case uint8(runtime.OP_FLOOR):
 			b := Pop()
 			a := Pop()
-			if a.Type != runtime.VAL_NUMBER || b.Type != runtime.VAL_NUMBER {
-				return runtimeError("Operands for '__' must be numbers (got %s and %s).", typeName(a), typeName(b))
+			if !isNumeric(a) || !isNumeric(b) {
+				return runtimeError("Operands for '/_' must be numbers (got %s and %s).", typeName(a), typeName(b))
+			}
+			if runtime.IsExactNumber(a) || runtime.IsExactNumber(b) {
+				result, err := exactArithmetic(runtime.OP_FLOOR, a, b)
+				if err != INTERPRET_OK {
+					return err
+				}
+				Push(result)
+				break
 			}
-			if b.Number == 0 {
-				return runtimeError("Division by zero in '__' operator.")
+			if runtime.AsNumber(b) == 0 {
+				return runtimeError("Division by zero in '/_' operator.")
 			}
-			result := math.Floor(a.Number / b.Number)
-			Push(runtime.Value{Type: runtime.VAL_NUMBER, Number: result})
+			Push(floorDivideNumbers(a, b))
 		case uint8(runtime.OP_PERCENT):
 			b := Pop()
 			a := Pop()
-			if a.Type != runtime.VAL_NUMBER || b.Type != runtime.VAL_NUMBER {
+			if !runtime.IsNumber(a) || !runtime.IsNumber(b) {
 				return runtimeError("Operands for '%%' must be numbers (got %s and %s).", typeName(a), typeName(b))
 			}
-			result := (a.Number / 100.0) * b.Number
+			result := (runtime.AsNumber(a) / 100.0) * runtime.AsNumber(b)
 			Push(runtime.Value{Type: runtime.VAL_NUMBER, Number: result})
+		case uint8(runtime.OP_BIT_AND), uint8(runtime.OP_BIT_OR), uint8(runtime.OP_BIT_XOR),
+			uint8(runtime.OP_SHIFT_LEFT), uint8(runtime.OP_SHIFT_RIGHT):
+			op := runtime.OpCode(instruction)
+			b := Pop()
+			a := Pop()
+			if set1, ok := a.Obj.(*runtime.ObjSet); ok && op == runtime.OP_BIT_XOR {
+				if set2, ok := b.Obj.(*runtime.ObjSet); ok {
+					Push(symmetricDifferenceSets(set1, set2))
+					break
+				}
+			}
+			result, err := bitwiseNumbers(op, a, b)
+			if err != INTERPRET_OK {
+				return err
+			}
+			Push(result)
+		case uint8(runtime.OP_BIT_NOT):
+			result, err := bitwiseNot(Pop())
+			if err != INTERPRET_OK {
+				return err
+			}
+			Push(result)
+		case uint8(runtime.OP_ARRAY_SPREAD):
+			// Append the elements of the spread value to the array being built below it.
+			spread := Pop()
+			array := peek(0).Obj.(*runtime.ObjArray)
+			switch source := spread.Obj.(type) {
+			case *runtime.ObjArray:
+				array.Elements = append(array.Elements, source.Elements...)
+			case *runtime.ObjSet:
+				array.Elements = append(array.Elements, source.Values()...)
+			case *runtime.ObjBytes:
+				for _, b := range source.Data {
+					array.Elements = append(array.Elements, runtime.IntVal(int64(b)))
+				}
+			default:
+				return runtimeError("Cannot spread %s into an array; expected an array, set or bytes.", typeName(spread))
+			}
+		case uint8(runtime.OP_MAP_SPREAD):
+			// Copy the entries of the spread map (or instance fields) into the map being built below it.
+			spread := Pop()
+			mapObj := peek(0).Obj.(*runtime.ObjMap)
+			switch obj := spread.Obj.(type) {
+			case *runtime.ObjMap:
+				for key, value := range obj.All() {
+					mapObj.Set(key, value)
+				}
+			case *runtime.ObjInstance:
+				// In name order, as json_encode lists them, so the map's key order is deterministic.
+				for _, name := range sortedFieldNames(obj) {
+					mapObj.Set(runtime.ObjVal(name), obj.Fields[name])
+				}
+			default:
+				return runtimeError("Cannot spread %s into a map; expected a map or struct instance.", typeName(spread))
+			}
+		case uint8(runtime.OP_CALL_SPREAD):
+			// Call with the arguments gathered in an array, so the count is only known at runtime.
+			args := Pop().Obj.(*runtime.ObjArray)
+			argCount := len(args.Elements)
+			if argCount > 255 {
+				return runtimeError("Cannot call with %d arguments; the maximum is 255.", argCount)
+			}
+			if vm.stackTop+argCount > len(vm.stack) {
+				return runtimeError("Stack overflow.")
+			}
+			for _, arg := range args.Elements {
+				Push(arg)
+			}
+			if !callValue(peek(argCount), argCount) {
+				return INTERPRET_RUNTIME_ERROR
+			}
+		case uint8(runtime.OP_ITER):
+			// Replace the iterable with an iterator; the operand asks maps for [key, value] pairs.
+			pairs := readByte(frame) != 0
+			iterator, err := makeIterator(Pop(), pairs)
+			if err != INTERPRET_OK {
+				return err
+			}
+			Push(iterator)
+		case uint8(runtime.OP_ITER_NEXT):
+			// Pop an iterator and push its next value, or jump past the loop when it is exhausted.
+			offset := int(readShort(frame))
+			value, done, err := iteratorNext(Pop())
+			if err != INTERPRET_OK {
+				return err
+			}
+			if done {
+				frame.ip += offset
+				break
+			}
+			Push(value)
+		case uint8(runtime.OP_RANGE):
+			// Build a range from start, end and (optionally) step on the stack.
+			flags := readByte(frame)
+			step := int64(1)
+			if flags&runtime.RANGE_HAS_STEP != 0 {
+				stepVal := Pop()
+				var ok bool
+				if step, ok = runtime.AsInt(stepVal); !ok {
+					return runtimeError("Range step must be an integer (got %s).", typeName(stepVal))
+				}
+				if step == 0 {
+					return runtimeError("Range step cannot be zero.")
+				}
+			}
+			endVal := Pop()
+			startVal := Pop()
+			start, startOk := runtime.AsInt(startVal)
+			end, endOk := runtime.AsInt(endVal)
+			if !startOk || !endOk {
+				return runtimeError("Range bounds must be integers (got %s and %s).", typeName(startVal), typeName(endVal))
+			}
+			Push(runtime.ObjVal(runtime.NewRange(start, end, step, flags&runtime.RANGE_INCLUSIVE != 0)))
+		case uint8(runtime.OP_YIELD):
+			// Suspend the running generator; resumeGenerator collects the value.
+			vm.yieldValue = Pop()
+			vm.yielded = true
+			return INTERPRET_OK
+		case uint8(runtime.OP_SET):
+			// Replace the array of elements on top of the stack with a set of them.
+			elements := Pop().Obj.(*runtime.ObjArray)
+			set := runtime.NewSet()
+			for _, element := range elements.Elements {
+				if !set.Add(element) {
+					return runtimeError("Set element must be a string, number, boolean or null (got %s).", typeName(element))
+				}
+			}
+			Push(runtime.ObjVal(set))
+		case uint8(runtime.OP_IN):
+			container := Pop()
+			value := Pop()
+			found, err := containsValue(container, value)
+			if err != INTERPRET_OK {
+				return err
+			}
+			Push(runtime.Value{Type: runtime.VAL_BOOL, Bool: found})
+		case uint8(runtime.OP_DESTRUCTURE_ARRAY):
+			// Pop the value about to be destructured and check it has the shape of the array pattern.
+			count := int(readByte(frame))
+			hasRest := readByte(frame) != 0
+			source := Pop()
+			array, ok := source.Obj.(*runtime.ObjArray)
+			if source.Type != runtime.VAL_OBJ || !ok {
+				return runtimeError("Cannot destructure %s with an array pattern; expected an array.", typeName(source))
+			}
+			if hasRest && len(array.Elements) < count {
+				return runtimeError("Cannot destructure an array of length %d; expected at least %d elements.", len(array.Elements), count)
+			}
+			if !hasRest && len(array.Elements) != count {
+				return runtimeError("Cannot destructure an array of length %d; expected exactly %d elements.", len(array.Elements), count)
+			}
+		case uint8(runtime.OP_DESTRUCTURE_INDEX):
+			// Replace an already checked array with one of its elements.
+			index := int(readByte(frame))
+			array := Pop().Obj.(*runtime.ObjArray)
+			Push(array.Elements[index])
+		case uint8(runtime.OP_DESTRUCTURE_REST):
+			// Replace an already checked array with a copy of its elements from start onwards.
+			start := int(readByte(frame))
+			array := Pop().Obj.(*runtime.ObjArray)
+			rest := make([]runtime.Value, len(array.Elements)-start)
+			copy(rest, array.Elements[start:])
+			Push(runtime.ObjVal(runtime.NewArray(rest)))
+		case uint8(runtime.OP_DESTRUCTURE_KEY):
+			// Replace a map or instance with the value stored under a key. When the key is missing,
+			// execution falls through into the default value expression, if the pattern has one.
+			key := readString(frame)
+			offset := int(readShort(frame))
+			source := Pop()
+			var value runtime.Value
+			var found bool
+			switch obj := source.Obj.(type) {
+			case *runtime.ObjMap:
+				value, found = obj.Get(runtime.ObjVal(key))
+			case *runtime.ObjInstance:
+				value, found = obj.Fields[key]
+			default:
+				return runtimeError("Cannot destructure %s with a map pattern; expected a map or struct instance.", typeName(source))
+			}
+			if found {
+				Push(value)
+				frame.ip += offset
+			} else if offset == 0 {
+				return runtimeError("Cannot destructure missing key '%s' (add a default, e.g. '{%s = null}').", key.Chars, key.Chars)
+			}
 		}
 	}
 }